@@ -1,6 +1,7 @@
 package nexus
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -31,6 +32,28 @@ type Client interface {
 	SetCertFile(certFile string)
 }
 
+// Streamer is implemented by the clients which can perform a request without reading the body of the response
+type Streamer interface {
+	Stream(request *http.Request) (*http.Response, error)
+}
+
+// Stream performs an http.Request without reading the body, regardless of status, if the client is a Streamer.
+// Otherwise the request is performed with Do and the response body is buffered in memory, and is empty unless
+// the status is StatusOK. The caller is responsible for closing the body of the response.
+func Stream(c Client, request *http.Request) (*http.Response, error) {
+	if s, ok := c.(Streamer); ok {
+		return s.Stream(request)
+	}
+
+	body, resp, err := c.Do(request)
+	if resp == nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
 // DefaultClient provides an HTTP wrapper with optimized for communicating with a Nexus server
 type DefaultClient struct {
 	ServerInfo
@@ -55,6 +78,25 @@ func (s *DefaultClient) NewRequest(method, endpoint string, payload io.Reader) (
 
 // Do performs an http.Request and reads the body if StatusOK
 func (s *DefaultClient) Do(request *http.Request) (body []byte, resp *http.Response, err error) {
+	resp, err = s.Stream(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// TODO: Trying to decide if this is a horrible idea or just kinda bad
+	if resp.StatusCode == http.StatusOK {
+		body, err = ioutil.ReadAll(resp.Body)
+		return
+	}
+
+	err = errors.New(resp.Status)
+	return
+}
+
+// Stream performs an http.Request without reading the body, regardless of status.
+// The caller is responsible for closing the body of the response.
+func (s *DefaultClient) Stream(request *http.Request) (*http.Response, error) {
 	if s.Debug {
 		dump, _ := httputil.DumpRequest(request, true)
 		log.Println("debug: http request:")
@@ -88,20 +130,7 @@ func (s *DefaultClient) Do(request *http.Request) (body []byte, resp *http.Respo
 		}
 	}
 
-	resp, err = client.Do(request)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-
-	// TODO: Trying to decide if this is a horrible idea or just kinda bad
-	if resp.StatusCode == http.StatusOK {
-		body, err = ioutil.ReadAll(resp.Body)
-		return
-	}
-
-	err = errors.New(resp.Status)
-	return
+	return client.Do(request)
 }
 
 func (s *DefaultClient) http(method, endpoint string, payload io.Reader) ([]byte, *http.Response, error) {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -12,18 +13,160 @@ const (
 )
 
 type repositoryItemAssetsChecksum struct {
-	Sha1 string `json:"sha1"`
-	Md5  string `json:"md5"`
+	Sha1   string `json:"sha1"`
+	Md5    string `json:"md5"`
+	Sha256 string `json:"sha256,omitempty"`
+	Sha512 string `json:"sha512,omitempty"`
 }
 
 // RepositoryItemAsset describes the assets associated with a component
 type RepositoryItemAsset struct {
-	DownloadURL string                       `json:"downloadUrl"`
-	Path        string                       `json:"path"`
-	ID          string                       `json:"id"`
-	Repository  string                       `json:"repository"`
-	Format      string                       `json:"format"`
-	Checksum    repositoryItemAssetsChecksum `json:"checksum"`
+	DownloadURL    string                       `json:"downloadUrl"`
+	Path           string                       `json:"path"`
+	ID             string                       `json:"id"`
+	Repository     string                       `json:"repository"`
+	Format         string                       `json:"format"`
+	Checksum       repositoryItemAssetsChecksum `json:"checksum"`
+	ContentType    string                       `json:"contentType,omitempty"`
+	LastModified   time.Time                    `json:"lastModified"`
+	LastDownloaded time.Time                    `json:"lastDownloaded"`
+	BlobCreated    time.Time                    `json:"blobCreated"`
+	Uploader       string                       `json:"uploader,omitempty"`
+	UploaderIP     string                       `json:"uploaderIp,omitempty"`
+	FileSize       int64                        `json:"fileSize,omitempty"`
+	Attributes     AssetAttributes              `json:"attributes"`
+}
+
+// AssetAttributes holds the format-specific attributes of an asset.
+// Only the attributes of the format of the asset are set.
+type AssetAttributes struct {
+	Maven2   *MavenAssetAttributes    `json:"maven2,omitempty"`
+	Npm      *NpmAssetAttributes      `json:"npm,omitempty"`
+	Pypi     *PypiAssetAttributes     `json:"pypi,omitempty"`
+	Docker   *DockerAssetAttributes   `json:"docker,omitempty"`
+	Nuget    *NugetAssetAttributes    `json:"nuget,omitempty"`
+	Rubygems *RubygemsAssetAttributes `json:"rubygems,omitempty"`
+	Yum      *YumAssetAttributes      `json:"yum,omitempty"`
+}
+
+// MavenAssetAttributes are the attributes of a maven2 asset
+type MavenAssetAttributes struct {
+	GroupID     string `json:"groupId"`
+	ArtifactID  string `json:"artifactId"`
+	Version     string `json:"version"`
+	BaseVersion string `json:"baseVersion,omitempty"`
+	Classifier  string `json:"classifier,omitempty"`
+	Extension   string `json:"extension"`
+}
+
+// NpmAssetAttributes are the attributes of an npm asset
+type NpmAssetAttributes struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description,omitempty"`
+	License     string `json:"license,omitempty"`
+}
+
+// PypiAssetAttributes are the attributes of a pypi asset
+type PypiAssetAttributes struct {
+	Name           string `json:"name"`
+	Version        string `json:"version"`
+	Summary        string `json:"summary,omitempty"`
+	Author         string `json:"author,omitempty"`
+	License        string `json:"license,omitempty"`
+	HomePage       string `json:"homePage,omitempty"`
+	RequiresPython string `json:"requiresPython,omitempty"`
+	PackageType    string `json:"packageType,omitempty"`
+}
+
+// DockerAssetAttributes are the attributes of a docker asset
+type DockerAssetAttributes struct {
+	ImageName     string `json:"imageName,omitempty"`
+	ImageTag      string `json:"imageTag,omitempty"`
+	LayerID       string `json:"layerId,omitempty"`
+	ContentDigest string `json:"contentDigest,omitempty"`
+}
+
+// NugetAssetAttributes are the attributes of a nuget asset
+type NugetAssetAttributes struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	Tags    string `json:"tags,omitempty"`
+}
+
+// RubygemsAssetAttributes are the attributes of a rubygems asset
+type RubygemsAssetAttributes struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Platform string `json:"platform,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+// YumAssetAttributes are the attributes of a yum asset
+type YumAssetAttributes struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Release      string `json:"release,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// UnmarshalJSON decodes an asset, parsing its timestamps. Depending on the RM version,
+// the format-specific attributes are found either in an attributes block or next to the other fields.
+func (a *RepositoryItemAsset) UnmarshalJSON(data []byte) error {
+	type asset RepositoryItemAsset
+	var resp struct {
+		asset
+		LastModified   string `json:"lastModified"`
+		LastDownloaded string `json:"lastDownloaded"`
+		BlobCreated    string `json:"blobCreated"`
+		AssetAttributes
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+
+	*a = RepositoryItemAsset(resp.asset)
+
+	var err error
+	for _, t := range []struct {
+		field *time.Time
+		value string
+	}{
+		{&a.LastModified, resp.LastModified},
+		{&a.LastDownloaded, resp.LastDownloaded},
+		{&a.BlobCreated, resp.BlobCreated},
+	} {
+		if *t.field, err = parseRMTime(t.value); err != nil {
+			return fmt.Errorf("could not parse timestamp of asset '%s': %v", a.Path, err)
+		}
+	}
+
+	top := resp.AssetAttributes
+	attrs := &a.Attributes
+	if attrs.Maven2 == nil {
+		attrs.Maven2 = top.Maven2
+	}
+	if attrs.Npm == nil {
+		attrs.Npm = top.Npm
+	}
+	if attrs.Pypi == nil {
+		attrs.Pypi = top.Pypi
+	}
+	if attrs.Docker == nil {
+		attrs.Docker = top.Docker
+	}
+	if attrs.Nuget == nil {
+		attrs.Nuget = top.Nuget
+	}
+	if attrs.Rubygems == nil {
+		attrs.Rubygems = top.Rubygems
+	}
+	if attrs.Yum == nil {
+		attrs.Yum = top.Yum
+	}
+
+	return nil
 }
 
 type listAssetsResponse struct {
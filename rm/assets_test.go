@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 var dummyAssets = map[string][]RepositoryItemAsset{
@@ -135,7 +136,7 @@ func getAssetsTester(t *testing.T, repo string) {
 		panic(err)
 	}
 
-	fmt.Printf("%q\n", assets)
+	fmt.Printf("%v\n", assets)
 
 	if len(assets) != len(dummyAssets[repo]) {
 		t.Errorf("Received %d assets instead of %d\n", len(assets), len(dummyAssets[repo]))
@@ -167,7 +168,7 @@ func TestGetAssetByID(t *testing.T) {
 		t.Error(err)
 	}
 
-	fmt.Printf("%q\n", asset)
+	fmt.Printf("%v\n", asset)
 
 	if !reflect.DeepEqual(asset, expectedAsset) {
 		t.Error("Did not receive expected asset")
@@ -201,3 +202,74 @@ func TestDeleteAssetByID(t *testing.T) {
 		t.Errorf("Asset not deleted: %v\n", err)
 	}
 }
+
+func TestUnmarshalAssetMetadata(t *testing.T) {
+	data := `{
+		"downloadUrl": "http://localhost:8081/repository/repo-maven/org/test/test/1.0/test-1.0.jar",
+		"path": "org/test/test/1.0/test-1.0.jar",
+		"id": "assetMeta",
+		"repository": "repo-maven",
+		"format": "maven2",
+		"checksum": {"sha1": "s1", "md5": "m5", "sha256": "s256", "sha512": "s512"},
+		"contentType": "application/java-archive",
+		"lastModified": "2020-06-01T12:00:00.000+00:00",
+		"lastDownloaded": null,
+		"blobCreated": "2020-06-01T11:59:59.500+0000",
+		"uploader": "admin",
+		"uploaderIp": "127.0.0.1",
+		"fileSize": 1234,
+		"attributes": {"maven2": {"groupId": "org.test", "artifactId": "test", "version": "1.0", "baseVersion": "1.0", "extension": "jar"}}
+	}`
+
+	var asset RepositoryItemAsset
+	if err := json.Unmarshal([]byte(data), &asset); err != nil {
+		t.Fatal(err)
+	}
+
+	if asset.ContentType != "application/java-archive" || asset.Uploader != "admin" || asset.UploaderIP != "127.0.0.1" || asset.FileSize != 1234 {
+		t.Errorf("Unexpected metadata: %v", asset)
+	}
+
+	if asset.Checksum.Sha256 != "s256" || asset.Checksum.Sha512 != "s512" {
+		t.Errorf("Unexpected checksums: %v", asset.Checksum)
+	}
+
+	if !asset.LastModified.Equal(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)) ||
+		!asset.BlobCreated.Equal(time.Date(2020, 6, 1, 11, 59, 59, 500000000, time.UTC)) ||
+		!asset.LastDownloaded.IsZero() {
+		t.Errorf("Unexpected timestamps: %v, %v, %v", asset.LastModified, asset.BlobCreated, asset.LastDownloaded)
+	}
+
+	expected := MavenAssetAttributes{GroupID: "org.test", ArtifactID: "test", Version: "1.0", BaseVersion: "1.0", Extension: "jar"}
+	if asset.Attributes.Maven2 == nil || *asset.Attributes.Maven2 != expected {
+		t.Errorf("Expected maven attributes %v but got %v", expected, asset.Attributes.Maven2)
+	}
+
+	if asset.Attributes.Npm != nil {
+		t.Errorf("Unexpected npm attributes: %v", asset.Attributes.Npm)
+	}
+
+	// some versions return the format attributes next to the other fields
+	if err := json.Unmarshal([]byte(`{"path": "test/-/test-1.0.0.tgz", "format": "npm", "npm": {"name": "test", "version": "1.0.0"}}`), &asset); err != nil {
+		t.Fatal(err)
+	}
+
+	if asset.Attributes.Npm == nil || asset.Attributes.Npm.Name != "test" || asset.Attributes.Maven2 != nil {
+		t.Errorf("Unexpected attributes: %v", asset.Attributes)
+	}
+
+	// encoding and decoding again keeps the asset as it was
+	encoded, err := json.Marshal(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded RepositoryItemAsset
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, asset) {
+		t.Errorf("Expected %v but got %v", asset, decoded)
+	}
+}
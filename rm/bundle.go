@@ -0,0 +1,572 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Archive formats of a bundle
+const (
+	BundleTar    = "tar"
+	BundleTarGz  = "tar.gz"
+	BundleZip    = "zip"
+	bundleSchema = 1
+)
+
+const (
+	bundleManifestFile  = "manifest.json"
+	bundleSignatureFile = "manifest.sig"
+	bundleContentDir    = "content"
+)
+
+// BundleEntry describes an asset in a bundle
+type BundleEntry struct {
+	Repository string                       `json:"repository"`
+	Format     string                       `json:"format"`
+	Group      string                       `json:"group,omitempty"`
+	Name       string                       `json:"name"`
+	Version    string                       `json:"version,omitempty"`
+	Path       string                       `json:"path"`
+	File       string                       `json:"file"` // location of the content within the bundle
+	Size       int64                        `json:"size"`
+	Checksum   repositoryItemAssetsChecksum `json:"checksum"`
+}
+
+// BundleManifest lists the content of a bundle. Its signature is stored next to it in the bundle.
+type BundleManifest struct {
+	Schema  int           `json:"schema"`
+	Created time.Time     `json:"created"`
+	Source  string        `json:"source"`
+	Entries []BundleEntry `json:"entries"`
+}
+
+// BundleExportOptions configures the export of a bundle
+type BundleExportOptions struct {
+	// Archive is the archive format of the bundle: BundleTar, BundleTarGz or BundleZip. Defaults to BundleTar.
+	Archive string
+	// PrivateKey signs the manifest of the bundle
+	PrivateKey ed25519.PrivateKey
+}
+
+// BundleImportOptions configures the import of a bundle
+type BundleImportOptions struct {
+	// PublicKey verifies the signature of the manifest of the bundle
+	PublicKey ed25519.PublicKey
+	// Repositories maps the repositories the content was exported from to the hosted repositories it is imported into.
+	// Content of repositories which are not mapped is imported into repositories of the same name.
+	Repositories map[string]string
+}
+
+// BundleImportReport counts the assets imported from a bundle, and lists those which failed keyed by repository and path
+type BundleImportReport struct {
+	Imported int
+	Skipped  int // assets which already exist in the target repository with the same checksum
+	Failures map[string]error
+}
+
+// bundleWriter adds files to a bundle archive
+type bundleWriter interface {
+	add(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+type tarBundleWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (w *tarBundleWriter) add(name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: time.Now()}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(w.tw, r)
+	return err
+}
+
+func (w *tarBundleWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+type zipBundleWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipBundleWriter) add(name string, _ int64, r io.Reader) error {
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (w *zipBundleWriter) Close() error {
+	return w.zw.Close()
+}
+
+func newBundleWriter(w io.Writer, archive string) (bundleWriter, error) {
+	switch archive {
+	case "", BundleTar:
+		return &tarBundleWriter{tw: tar.NewWriter(w)}, nil
+	case BundleTarGz:
+		gz := gzip.NewWriter(w)
+		return &tarBundleWriter{tw: tar.NewWriter(gz), gz: gz}, nil
+	case BundleZip:
+		return &zipBundleWriter{zw: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown bundle archive format '%s'", archive)
+	}
+}
+
+// bundleContentFile returns the location within a bundle of the content of an asset
+func bundleContentFile(asset RepositoryItemAsset) (string, error) {
+	p := path.Clean("/" + asset.Path)
+	if p == "/" || asset.Repository == "" || strings.Contains(asset.Repository, "/") {
+		return "", fmt.Errorf("asset '%s' cannot be bundled", asset.Path)
+	}
+	return path.Join(bundleContentDir, asset.Repository, p), nil
+}
+
+// exportBundleAsset downloads and verifies the asset into a temporary file,
+// then adds it to the bundle and returns its manifest entry
+func exportBundleAsset(rm RM, bw bundleWriter, c RepositoryItem, asset RepositoryItemAsset, file string) (BundleEntry, error) {
+	entry := BundleEntry{
+		Repository: asset.Repository,
+		Format:     asset.Format,
+		Group:      c.Group,
+		Name:       c.Name,
+		Version:    c.Version,
+		Path:       strings.TrimPrefix(asset.Path, "/"),
+		File:       file,
+	}
+	if entry.Format == "" {
+		entry.Format = c.Format
+	}
+
+	f, err := ioutil.TempFile("", "nexusrm-bundle-")
+	if err != nil {
+		return entry, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	sha1sum, md5sum, sha256sum := sha1.New(), md5.New(), sha256.New()
+	if err = DownloadAsset(rm, asset, io.MultiWriter(f, sha1sum, md5sum, sha256sum)); err != nil {
+		return entry, err
+	}
+
+	if entry.Size, err = f.Seek(0, io.SeekCurrent); err != nil {
+		return entry, err
+	}
+
+	entry.Checksum = repositoryItemAssetsChecksum{
+		Sha1:   hex.EncodeToString(sha1sum.Sum(nil)),
+		Md5:    hex.EncodeToString(md5sum.Sum(nil)),
+		Sha256: hex.EncodeToString(sha256sum.Sum(nil)),
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return entry, err
+	}
+
+	return entry, bw.add(entry.File, entry.Size, f)
+}
+
+// ExportBundle writes a self-contained bundle of the given components, for transfer to an RM instance
+// which cannot reach this one. The components can be those of a repository, as returned by GetComponents,
+// or the results of SearchComponents, such as the components with a given tag.
+// The bundle is an archive of the content of every asset along with a manifest of their coordinates,
+// formats and checksums, signed with the given key. Checksum files of maven2 assets are not bundled
+// since RM generates them on upload.
+func ExportBundle(rm RM, components []RepositoryItem, w io.Writer, options BundleExportOptions) (BundleManifest, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not export bundle: %v", err)
+	}
+
+	manifest := BundleManifest{
+		Schema:  bundleSchema,
+		Created: time.Now().UTC(),
+		Source:  rm.Info().Host,
+		Entries: make([]BundleEntry, 0),
+	}
+
+	if len(options.PrivateKey) != ed25519.PrivateKeySize {
+		return manifest, doError(errors.New("a private key is needed to sign the manifest"))
+	}
+
+	bw, err := newBundleWriter(w, options.Archive)
+	if err != nil {
+		return manifest, doError(err)
+	}
+
+	bundled := make(map[string]bool)
+	for _, c := range components {
+		for _, asset := range c.Assets {
+			format := asset.Format
+			if format == "" {
+				format = c.Format
+			}
+			if isGeneratedChecksum(parseRepositoryFormat(format), asset.Path) {
+				continue
+			}
+
+			if asset.Repository == "" {
+				asset.Repository = c.Repository
+			}
+
+			file, err := bundleContentFile(asset)
+			if err != nil {
+				return manifest, doError(err)
+			}
+
+			// the same component can be selected more than once, but its content is only bundled once
+			if bundled[file] {
+				continue
+			}
+			bundled[file] = true
+
+			entry, err := exportBundleAsset(rm, bw, c, asset, file)
+			if err != nil {
+				return manifest, doError(err)
+			}
+
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, doError(err)
+	}
+
+	if err = bw.add(bundleManifestFile, int64(len(buf)), bytes.NewReader(buf)); err != nil {
+		return manifest, doError(err)
+	}
+
+	signature := []byte(hex.EncodeToString(ed25519.Sign(options.PrivateKey, buf)))
+	if err = bw.add(bundleSignatureFile, int64(len(signature)), bytes.NewReader(signature)); err != nil {
+		return manifest, doError(err)
+	}
+
+	if err = bw.Close(); err != nil {
+		return manifest, doError(err)
+	}
+
+	return manifest, nil
+}
+
+// ExportBundleToFile exports a bundle, as with ExportBundle, into the file at the given path.
+// The file is only created once the bundle is complete.
+func ExportBundleToFile(rm RM, components []RepositoryItem, file string, options BundleExportOptions) (BundleManifest, error) {
+	part := file + partialDownloadSuffix
+	f, err := os.Create(part)
+	if err != nil {
+		return BundleManifest{}, fmt.Errorf("could not export bundle: %v", err)
+	}
+	defer os.Remove(part)
+
+	w := bufio.NewWriter(f)
+	manifest, err := ExportBundle(rm, components, w, options)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, os.Rename(part, file)
+}
+
+// walkBundle calls the function with each file of the bundle at the given path, in the order they were added
+func walkBundle(file string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err = io.ReadFull(f, magic); err != nil {
+		return fmt.Errorf("not a bundle: %v", err)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if bytes.Equal(magic, []byte("PK\x03\x04")) {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return err
+		}
+
+		for _, zf := range zr.File {
+			r, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = fn(zf.Name, r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err = fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadBundleManifest reads the manifest of the bundle at the given path and verifies its signature with the given key
+func ReadBundleManifest(file string, key ed25519.PublicKey) (BundleManifest, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not read manifest of bundle '%s': %v", file, err)
+	}
+
+	var manifest BundleManifest
+	if len(key) != ed25519.PublicKeySize {
+		return manifest, doError(errors.New("a public key is needed to verify the manifest"))
+	}
+
+	var buf, signature []byte
+	err := walkBundle(file, func(name string, r io.Reader) (err error) {
+		switch name {
+		case bundleManifestFile:
+			buf, err = ioutil.ReadAll(r)
+		case bundleSignatureFile:
+			signature, err = ioutil.ReadAll(r)
+		}
+		return
+	})
+	if err != nil {
+		return manifest, doError(err)
+	}
+
+	if buf == nil || signature == nil {
+		return manifest, doError(errors.New("bundle has no signed manifest"))
+	}
+
+	sig, err := hex.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, buf, sig) {
+		return manifest, doError(errors.New("signature does not match"))
+	}
+
+	if err = json.Unmarshal(buf, &manifest); err != nil {
+		return manifest, doError(err)
+	}
+
+	if manifest.Schema != bundleSchema {
+		return manifest, doError(fmt.Errorf("unsupported schema %d", manifest.Schema))
+	}
+
+	return manifest, nil
+}
+
+// bundleTargets returns the hosted repository each repository of the manifest is imported into,
+// verifying that it exists and has the same format
+func bundleTargets(rm RM, manifest BundleManifest, mapping map[string]string) (map[string]Repository, error) {
+	repos, err := GetRepositories(rm)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Repository)
+	for _, r := range repos {
+		byName[r.Name] = r
+	}
+
+	targets := make(map[string]Repository)
+	for _, e := range manifest.Entries {
+		if _, ok := targets[e.Repository]; ok {
+			continue
+		}
+
+		name := e.Repository
+		if mapped, ok := mapping[name]; ok {
+			name = mapped
+		}
+
+		target, ok := byName[name]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("repository '%s' not found", name)
+		case target.Type != "hosted":
+			return nil, fmt.Errorf("repository '%s' is not a hosted repository", name)
+		case target.Format != e.Format:
+			return nil, fmt.Errorf("cannot import %s content into %s repository '%s'", e.Format, target.Format, name)
+		}
+		targets[e.Repository] = target
+	}
+
+	return targets, nil
+}
+
+// ImportBundle verifies the signed manifest of the bundle at the given path and uploads its content into
+// hosted repositories of the given RM instance. Every target repository must exist and have the format of
+// the content imported into it, otherwise nothing is uploaded. The content of each asset is verified against
+// the manifest before it is uploaded, and assets which already exist in the target with the same checksum are
+// skipped, so an interrupted import can simply be run again. Files of the bundle which are not listed in the
+// manifest are ignored.
+func ImportBundle(rm RM, file string, options BundleImportOptions) (BundleManifest, BundleImportReport, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not import bundle '%s': %v", file, err)
+	}
+
+	report := BundleImportReport{Failures: make(map[string]error)}
+
+	manifest, err := ReadBundleManifest(file, options.PublicKey)
+	if err != nil {
+		return manifest, report, err
+	}
+
+	targets, err := bundleTargets(rm, manifest, options.Repositories)
+	if err != nil {
+		return manifest, report, doError(err)
+	}
+
+	existing := make(map[string]map[string]string)
+	for _, target := range targets {
+		if _, ok := existing[target.Name]; ok {
+			continue
+		}
+
+		assets, err := GetAssets(rm, target.Name)
+		if err != nil {
+			return manifest, report, doError(err)
+		}
+
+		existing[target.Name] = make(map[string]string)
+		for _, a := range assets {
+			existing[target.Name][strings.TrimPrefix(a.Path, "/")] = a.Checksum.Sha1
+		}
+	}
+
+	entries := make(map[string]BundleEntry)
+	for _, e := range manifest.Entries {
+		entries[e.File] = e
+	}
+
+	importEntry := func(e BundleEntry, r io.Reader) error {
+		f, err := ioutil.TempFile("", "nexusrm-bundle-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		verifier := newChecksumVerifier(e.Checksum)
+		size, err := io.Copy(io.MultiWriter(f, verifier), r)
+		if err != nil {
+			return err
+		}
+
+		if size != e.Size {
+			return fmt.Errorf("expected %d bytes but bundle has %d", e.Size, size)
+		}
+
+		if err = verifier.verify(); err != nil {
+			return err
+		}
+
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		target := targets[e.Repository]
+		asset := RepositoryItemAsset{Path: e.Path, Repository: target.Name, Format: e.Format, Checksum: e.Checksum}
+
+		return uploadComponentAsset(rm, target.Name, parseRepositoryFormat(target.Format), asset, f)
+	}
+
+	seen := make(map[string]bool)
+	err = walkBundle(file, func(name string, r io.Reader) error {
+		e, ok := entries[name]
+		if !ok || seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		key := e.Repository + "/" + e.Path
+		if existing[targets[e.Repository].Name][e.Path] == e.Checksum.Sha1 {
+			report.Skipped++
+			return nil
+		}
+
+		if err := importEntry(e, r); err != nil {
+			report.Failures[key] = err
+			return nil
+		}
+		report.Imported++
+
+		return nil
+	})
+	if err != nil {
+		return manifest, report, doError(err)
+	}
+
+	for name, e := range entries {
+		if !seen[name] {
+			report.Failures[e.Repository+"/"+e.Path] = errors.New("content missing from bundle")
+		}
+	}
+
+	return manifest, report, nil
+}
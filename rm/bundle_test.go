@@ -0,0 +1,227 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ed25519"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func bundleTestRMs(t *testing.T) (source, target *fakeRepositoryManager) {
+	source = newFakeRepositoryManager(t,
+		inventoryRepository("releases", "maven2", "hosted", "default"),
+		inventoryRepository("npm-releases", "npm", "hosted", "default"),
+	)
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.jar", []byte("app jar"))
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.jar.sha1", []byte("sidecar"))
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.pom", []byte("app pom"))
+	source.addAsset("npm-releases", "", "left-pad", "1.3.0", "left-pad/-/left-pad-1.3.0.tgz", []byte("left-pad tarball"))
+
+	target = newFakeRepositoryManager(t,
+		inventoryRepository("airgap-releases", "maven2", "hosted", "default"),
+		inventoryRepository("npm-releases", "npm", "hosted", "default"),
+		inventoryRepository("npm-proxy", "npm", "proxy", "default"),
+	)
+	target.addAsset("airgap-releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.pom", []byte("app pom"))
+	target.uploadCoordinates[fakeChecksum([]byte("left-pad tarball")).Sha1] = RepositoryItem{
+		Name: "left-pad", Version: "1.3.0", Assets: []RepositoryItemAsset{{Path: "left-pad/-/left-pad-1.3.0.tgz"}},
+	}
+
+	return
+}
+
+func exportTestBundle(t *testing.T, source *fakeRepositoryManager, dir, archive string, key ed25519.PrivateKey) string {
+	file := filepath.Join(dir, "bundle."+archive)
+	manifest, err := ExportBundleToFile(source.rm, source.components, file, BundleExportOptions{Archive: archive, PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make([]string, 0)
+	for _, e := range manifest.Entries {
+		files = append(files, e.File)
+	}
+	sort.Strings(files)
+
+	expected := []string{
+		"content/npm-releases/left-pad/-/left-pad-1.3.0.tgz",
+		"content/releases/org/test/app/1.0/app-1.0.jar",
+		"content/releases/org/test/app/1.0/app-1.0.pom",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected bundled files %v but got %v", expected, files)
+	}
+
+	return file
+}
+
+func TestBundleExportChecksumFiles(t *testing.T) {
+	source := newFakeRepositoryManager(t, inventoryRepository("raw-releases", "raw", "hosted", "default"))
+	defer source.Close()
+
+	source.addAsset("raw-releases", "/files", "app.tar.gz", "", "files/app.tar.gz", []byte("app tarball"))
+	source.addAsset("raw-releases", "/files", "app.tar.gz.sha256", "", "files/app.tar.gz.sha256", []byte("uploaded checksum"))
+
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only maven2 checksum files are generated by RM, in other formats they are uploaded content
+	manifest, err := ExportBundle(source.rm, source.components, ioutil.Discard, BundleExportOptions{Archive: BundleTar, PrivateKey: private})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make([]string, 0)
+	for _, e := range manifest.Entries {
+		files = append(files, e.File)
+	}
+	sort.Strings(files)
+
+	expected := []string{"content/raw-releases/files/app.tar.gz", "content/raw-releases/files/app.tar.gz.sha256"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected bundled files %v but got %v", expected, files)
+	}
+}
+
+func TestBundleExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, archive := range []string{BundleTar, BundleTarGz, BundleZip} {
+		t.Run(archive, func(t *testing.T) {
+			source, target := bundleTestRMs(t)
+			defer source.Close()
+			defer target.Close()
+
+			file := exportTestBundle(t, source, dir, archive, private)
+
+			options := BundleImportOptions{PublicKey: public, Repositories: map[string]string{"releases": "airgap-releases"}}
+			manifest, report, err := ImportBundle(target.rm, file, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(manifest.Entries) != 3 || report.Imported != 2 || report.Skipped != 1 || len(report.Failures) != 0 {
+				t.Errorf("Unexpected import: %v", report)
+			}
+
+			sort.Strings(target.uploads)
+			expected := []string{"airgap-releases/org/test/app/1.0/app-1.0.jar", "npm-releases/left-pad/-/left-pad-1.3.0.tgz"}
+			if !reflect.DeepEqual(target.uploads, expected) {
+				t.Errorf("Expected uploads %v but got %v", expected, target.uploads)
+			}
+
+			if string(target.content["airgap-releases/org/test/app/1.0/app-1.0.jar"]) != "app jar" {
+				t.Error("Imported content differs from exported content")
+			}
+		})
+	}
+}
+
+func TestBundleImportRejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, target := bundleTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	file := exportTestBundle(t, source, dir, BundleTar, private)
+	mapping := map[string]string{"releases": "airgap-releases"}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, _, err := ImportBundle(target.rm, file, BundleImportOptions{PublicKey: other, Repositories: mapping}); err == nil {
+		t.Error("Expected bundle signed with another key to be rejected")
+	}
+
+	if _, _, err := ImportBundle(target.rm, file, BundleImportOptions{PublicKey: public}); err == nil {
+		t.Error("Expected import into a missing repository to be rejected")
+	}
+
+	mapping["npm-releases"] = "npm-proxy"
+	if _, _, err := ImportBundle(target.rm, file, BundleImportOptions{PublicKey: public, Repositories: mapping}); err == nil {
+		t.Error("Expected import into a proxy repository to be rejected")
+	}
+	delete(mapping, "npm-releases")
+
+	// replace the content of the jar in the bundle
+	tampered := filepath.Join(dir, "tampered.tar")
+	original, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer original.Close()
+
+	var buf bytes.Buffer
+	tr, tw := tar.NewReader(original), tar.NewWriter(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "content/releases/org/test/app/1.0/app-1.0.jar" {
+			content = []byte("bad jar")
+		}
+
+		hdr.Size = int64(len(content))
+		if err = tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(tampered, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, report, err := ImportBundle(target.rm, tampered, BundleImportOptions{PublicKey: public, Repositories: mapping})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, failed := report.Failures["releases/org/test/app/1.0/app-1.0.jar"]; !failed || report.Imported != 1 {
+		t.Errorf("Expected tampered asset to fail verification: %v", report)
+	}
+
+	for _, u := range target.uploads {
+		if u == "airgap-releases/org/test/app/1.0/app-1.0.jar" {
+			t.Error("Tampered asset was uploaded")
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package nexusrm
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"path/filepath"
 	"strings"
+
+	"github.com/overag3/gonexus/rm/rmmaven"
 )
 
 const (
@@ -117,7 +121,9 @@ func (a UploadComponentMaven) write(w *multipart.Writer) error {
 	return nil
 }
 
-// NewUploadComponentMaven creates a new UploadComponentMaven struct with some defaults
+// NewUploadComponentMaven creates a new UploadComponentMaven struct with some defaults.
+// The classifier and extension of assets which are files, such as an *os.File, are inferred from their names.
+// Other assets are detected as a POM or else taken as a jar. A POM is only generated if no asset is the POM.
 func NewUploadComponentMaven(coordinate string, assets ...io.Reader) (comp UploadComponentMaven, err error) {
 	coordSlice := strings.Split(coordinate, ":")
 
@@ -134,7 +140,24 @@ func NewUploadComponentMaven(coordinate string, assets ...io.Reader) (comp Uploa
 
 	var havePom bool
 	for i, a := range assets {
-		comp.Assets[i] = UploadAssetMaven{Extension: "jar", File: a} // FIXME: highly assumed extension
+		asset := UploadAssetMaven{Extension: "jar", File: a}
+
+		if named, ok := a.(interface{ Name() string }); ok {
+			if _, classifier, extension, err := rmmaven.ParseFilename(comp.ArtifactID, comp.Version, filepath.Base(named.Name())); err == nil {
+				asset.Classifier, asset.Extension = classifier, extension
+			}
+		} else if a != nil {
+			buffered := bufio.NewReader(a)
+			if head, _ := buffered.Peek(pomSniffLength); isPom(head) {
+				asset.Extension = "pom"
+			}
+			asset.File = buffered
+		}
+
+		if asset.Extension == "pom" && asset.Classifier == "" {
+			havePom = true
+		}
+		comp.Assets[i] = asset
 	}
 
 	if !havePom {
@@ -144,6 +167,18 @@ func NewUploadComponentMaven(coordinate string, assets ...io.Reader) (comp Uploa
 	return
 }
 
+// pomSniffLength is how much of an asset is read to detect a POM
+const pomSniffLength = 1024
+
+// isPom returns true if the content starts as a Maven POM does
+func isPom(head []byte) bool {
+	content := strings.TrimSpace(strings.TrimPrefix(string(head), "\ufeff"))
+	if !strings.HasPrefix(content, "<") {
+		return false
+	}
+	return strings.Contains(content, "<project") || strings.Contains(content, ":project")
+}
+
 // UploadAssetRaw encapsulates data needed to upload a raw asset
 type UploadAssetRaw struct {
 	File     io.Reader
@@ -391,3 +426,23 @@ func UploadComponent(rm RM, repo string, component UploadComponentWriter) error
 
 	return nil
 }
+
+// UploadAssetToPath uploads content directly to the given path of a repository, as supported by formats
+// such as maven2, raw, yum and helm
+func UploadAssetToPath(rm RM, repo, path string, content io.Reader) error {
+	doError := func(err error) error {
+		return fmt.Errorf("asset not uploaded to %s/%s: %v", repo, path, err)
+	}
+
+	req, err := rm.NewRequest(http.MethodPut, fmt.Sprintf("repository/%s/%s", repo, strings.TrimPrefix(path, "/")), content)
+	if err != nil {
+		return doError(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if _, resp, err := rm.Do(req); err != nil && (resp == nil || (resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent)) {
+		return doError(err)
+	}
+
+	return nil
+}
@@ -3,8 +3,11 @@ package nexusrm
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -173,7 +176,7 @@ func getComponentsTester(t *testing.T, repo string) {
 		panic(err)
 	}
 
-	fmt.Printf("%q\n", components)
+	fmt.Printf("%v\n", components)
 
 	if len(components) != len(dummyComponents[repo]) {
 		t.Errorf("Received %d components instead of %d\n", len(components), len(dummyComponents[repo]))
@@ -205,7 +208,7 @@ func TestGetComponentByID(t *testing.T) {
 		t.Error(err)
 	}
 
-	fmt.Printf("%q\n", component)
+	fmt.Printf("%v\n", component)
 
 	if !reflect.DeepEqual(component, expectedComponent) {
 		t.Error("Did not receive expected component")
@@ -229,7 +232,7 @@ func componentUploader(t *testing.T, expected RepositoryItem, upload UploadCompo
 		t.Error(err)
 	}
 
-	fmt.Printf("%q\n", component)
+	fmt.Printf("%v\n", component)
 
 	if !reflect.DeepEqual(component, expected) {
 		t.Error("Did not receive expected component")
@@ -339,5 +342,48 @@ func ExampleGetComponents() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("%q\n", items)
+	fmt.Printf("%v\n", items)
+}
+
+func TestNewUploadComponentMavenDetectsAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sourcesPath := filepath.Join(dir, "testComponent-1.0.0-sources.jar")
+	if err = ioutil.WriteFile(sourcesPath, []byte("sources"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := os.Open(sourcesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sources.Close()
+
+	pom := strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"><modelVersion>4.0.0</modelVersion></project>`)
+	jar := strings.NewReader("PK\x03\x04jar")
+
+	upload, err := NewUploadComponentMaven("org.test:testComponent:1.0.0", jar, pom, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.GeneratePom {
+		t.Error("Expected the uploaded POM to be used")
+	}
+
+	expected := [][2]string{{"", "jar"}, {"", "pom"}, {"sources", "jar"}}
+	for i, a := range upload.Assets {
+		if a.Classifier != expected[i][0] || a.Extension != expected[i][1] {
+			t.Errorf("Expected asset %d to be %v but got %s, %s", i, expected[i], a.Classifier, a.Extension)
+		}
+	}
+
+	if content, _ := ioutil.ReadAll(upload.Assets[1].File); !strings.HasPrefix(string(content), "<?xml") {
+		t.Error("Detecting the POM consumed its content")
+	}
 }
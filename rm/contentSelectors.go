@@ -0,0 +1,152 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const restContentSelectors = "service/rest/v1/security/content-selectors"
+
+// ContentSelector encapsulates a Repository Manager content selector
+type ContentSelector struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description"`
+	Expression  string `json:"expression"`
+}
+
+type contentSelectorUpdate struct {
+	Description string `json:"description"`
+	Expression  string `json:"expression"`
+}
+
+// GetContentSelectors returns all of the content selectors configured in the RM instance
+func GetContentSelectors(rm RM) ([]ContentSelector, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list content selectors: %v", err)
+	}
+
+	body, resp, err := rm.Get(restContentSelectors)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	selectors := make([]ContentSelector, 0)
+	if err := json.Unmarshal(body, &selectors); err != nil {
+		return nil, doError(err)
+	}
+
+	return selectors, nil
+}
+
+// GetContentSelectorByName returns the named content selector
+func GetContentSelectorByName(rm RM, name string) (ContentSelector, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find content selector '%s': %v", name, err)
+	}
+
+	var selector ContentSelector
+
+	url := fmt.Sprintf("%s/%s", restContentSelectors, name)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return selector, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &selector); err != nil {
+		return selector, doError(err)
+	}
+
+	return selector, nil
+}
+
+// CreateContentSelector creates a new content selector after validating its expression
+func CreateContentSelector(rm RM, selector ContentSelector) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not create content selector '%s': %v", selector.Name, err)
+	}
+
+	if err := ValidateCSEL(selector.Expression); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(ContentSelector{
+		Name:        selector.Name,
+		Description: selector.Description,
+		Expression:  selector.Expression,
+	})
+	if err != nil {
+		return doError(err)
+	}
+
+	_, resp, err := rm.Post(restContentSelectors, bytes.NewBuffer(buf))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// UpdateContentSelector updates the description and expression of the named content selector
+func UpdateContentSelector(rm RM, selector ContentSelector) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not update content selector '%s': %v", selector.Name, err)
+	}
+
+	if err := ValidateCSEL(selector.Expression); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(contentSelectorUpdate{
+		Description: selector.Description,
+		Expression:  selector.Expression,
+	})
+	if err != nil {
+		return doError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s", restContentSelectors, selector.Name)
+	_, resp, err := rm.Put(url, bytes.NewBuffer(buf))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DeleteContentSelectorByName removes the named content selector
+func DeleteContentSelectorByName(rm RM, name string) error {
+	url := fmt.Sprintf("%s/%s", restContentSelectors, name)
+
+	if resp, err := rm.Del(url); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("content selector not deleted '%s': %v", name, err)
+	}
+
+	return nil
+}
+
+// PreviewContentSelector returns the assets of the given repositories which the expression would select
+func PreviewContentSelector(rm RM, expression string, repos ...string) ([]RepositoryItemAsset, error) {
+	csel, err := ParseCSEL(expression)
+	if err != nil {
+		return nil, fmt.Errorf("could not preview content selector: %v", err)
+	}
+
+	matched := make([]RepositoryItemAsset, 0)
+	for _, repo := range repos {
+		assets, err := GetAssets(rm, repo)
+		if err != nil {
+			return nil, fmt.Errorf("could not preview content selector: %v", err)
+		}
+
+		for _, a := range assets {
+			if csel.MatchesAsset(a) {
+				matched = append(matched, a)
+			}
+		}
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,204 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dummyContentSelectors = []ContentSelector{
+	{Name: "maven-acme", Type: "csel", Description: "acme maven", Expression: `format == "maven2" and path =^ "/org/acme/"`},
+	{Name: "npm-all", Type: "csel", Description: "all npm", Expression: `format == "npm"`},
+}
+
+func contentSelectorsTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	getSelectorByName := func(name string) (int, ContentSelector, bool) {
+		for i, s := range dummyContentSelectors {
+			if s.Name == name {
+				return i, s, true
+			}
+		}
+		return 0, ContentSelector{}, false
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restContentSelectors), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		resp, err := json.Marshal(dummyContentSelectors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet:
+		if _, s, ok := getSelectorByName(name); ok {
+			resp, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost:
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var selector ContentSelector
+		if err = json.Unmarshal(body, &selector); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		selector.Type = "csel"
+
+		dummyContentSelectors = append(dummyContentSelectors, selector)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		i, s, ok := getSelectorByName(name)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var update contentSelectorUpdate
+		if err = json.Unmarshal(body, &update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.Description = update.Description
+		s.Expression = update.Expression
+		dummyContentSelectors[i] = s
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if i, _, ok := getSelectorByName(name); ok {
+			dummyContentSelectors = append(dummyContentSelectors[:i], dummyContentSelectors[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func contentSelectorsTestRM(t *testing.T) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path[1:], restContentSelectors):
+			contentSelectorsTestFunc(t, w, r)
+		default:
+			assetsTestFunc(t, w, r)
+		}
+	})
+}
+
+func TestGetContentSelectors(t *testing.T) {
+	rm, mock := contentSelectorsTestRM(t)
+	defer mock.Close()
+
+	selectors, err := GetContentSelectors(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(selectors, dummyContentSelectors) {
+		t.Errorf("Did not receive expected content selectors: %v", selectors)
+	}
+}
+
+func TestGetContentSelectorByName(t *testing.T) {
+	rm, mock := contentSelectorsTestRM(t)
+	defer mock.Close()
+
+	expected := dummyContentSelectors[0]
+
+	selector, err := GetContentSelectorByName(rm, expected.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(selector, expected) {
+		t.Errorf("Did not receive expected content selector: %v", selector)
+	}
+}
+
+func TestCreateUpdateDeleteContentSelector(t *testing.T) {
+	rm, mock := contentSelectorsTestRM(t)
+	defer mock.Close()
+
+	selector := ContentSelector{Name: "raw-docs", Description: "docs", Expression: `format == "raw" and path =~ "/docs/.*"`}
+
+	if err := CreateContentSelector(rm, selector); err != nil {
+		t.Fatal(err)
+	}
+
+	selector.Expression = `format == "raw"`
+	if err := UpdateContentSelector(rm, selector); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetContentSelectorByName(rm, selector.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Expression != selector.Expression {
+		t.Errorf("Expected expression %q but got %q", selector.Expression, got.Expression)
+	}
+
+	if err := DeleteContentSelectorByName(rm, selector.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetContentSelectorByName(rm, selector.Name); err == nil {
+		t.Error("Content selector not deleted")
+	}
+}
+
+func TestCreateContentSelectorInvalid(t *testing.T) {
+	rm, mock := contentSelectorsTestRM(t)
+	defer mock.Close()
+
+	before := len(dummyContentSelectors)
+
+	err := CreateContentSelector(rm, ContentSelector{Name: "broken", Expression: `format = "maven2"`})
+	if err == nil {
+		t.Fatal("Expected invalid expression to be rejected")
+	}
+
+	if len(dummyContentSelectors) != before {
+		t.Error("Invalid content selector was sent to the server")
+	}
+}
+
+func TestPreviewContentSelector(t *testing.T) {
+	rm, mock := contentSelectorsTestRM(t)
+	defer mock.Close()
+
+	matched, err := PreviewContentSelector(rm, `format == "npm" and path =^ "/testComponent4/"`, "repo-maven", "repo-npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matched) != 1 || !reflect.DeepEqual(matched[0], dummyAssets["repo-npm"][0]) {
+		t.Errorf("Did not match expected assets: %v", matched)
+	}
+}
@@ -0,0 +1,283 @@
+package nexusrm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/overag3/gonexus/rm/rmmaven"
+)
+
+// Write policies of hosted repositories
+const (
+	WritePolicyAllow     = "ALLOW"
+	WritePolicyAllowOnce = "ALLOW_ONCE"
+	WritePolicyDeny      = "DENY"
+)
+
+// ErrWritePolicy indicates that the write policy of the target repository would reject a copied component
+var ErrWritePolicy = errors.New("rejected by the write policy of the repository")
+
+// isMavenSnapshot returns true if the component is a maven2 SNAPSHOT, whether its version is timestamped or not
+func isMavenSnapshot(c RepositoryItem) bool {
+	for _, a := range c.Assets {
+		if a.Attributes.Maven2 != nil && strings.HasSuffix(a.Attributes.Maven2.BaseVersion, "-SNAPSHOT") {
+			return true
+		}
+	}
+	return rmmaven.IsSnapshot(c.Version)
+}
+
+// mavenAssetClassifier returns the classifier and extension of a maven2 asset,
+// from its attributes if RM provided them or else from its file name
+func mavenAssetClassifier(c RepositoryItem, asset RepositoryItemAsset) (classifier, extension string) {
+	if attrs := asset.Attributes.Maven2; attrs != nil && attrs.Extension != "" {
+		return attrs.Classifier, attrs.Extension
+	}
+
+	if _, classifier, extension, err := rmmaven.ParseFilename(c.Name, c.Version, path.Base(asset.Path)); err == nil {
+		return classifier, extension
+	}
+	return "", strings.TrimPrefix(path.Ext(asset.Path), ".")
+}
+
+// copyUploads returns the uploads which recreate the component from the files its assets were downloaded to
+func copyUploads(c RepositoryItem, format repositoryFormat, files map[string]io.Reader) ([]UploadComponentWriter, error) {
+	uploads := make([]UploadComponentWriter, 0)
+
+	switch format {
+	case Maven:
+		maven := UploadComponentMaven{GroupID: c.Group, ArtifactID: c.Name, Version: c.Version, GeneratePom: true}
+		for _, asset := range c.Assets {
+			if f, ok := files[asset.Path]; ok {
+				classifier, extension := mavenAssetClassifier(c, asset)
+				if classifier == "" && extension == "pom" {
+					maven.GeneratePom = false
+				}
+				maven.Assets = append(maven.Assets, UploadAssetMaven{File: f, Classifier: classifier, Extension: extension})
+			}
+		}
+		uploads = append(uploads, maven)
+	case Raw, Yum:
+		// raw and yum components are uploaded a directory at a time
+		directories := make(map[string]int)
+		for _, asset := range c.Assets {
+			f, ok := files[asset.Path]
+			if !ok {
+				continue
+			}
+
+			dir, name := path.Split(asset.Path)
+			dir = strings.Trim(dir, "/")
+
+			i, ok := directories[dir]
+			if !ok {
+				i = len(uploads)
+				directories[dir] = i
+				if format == Raw {
+					uploads = append(uploads, UploadComponentRaw{Directory: dir})
+				} else {
+					uploads = append(uploads, UploadComponentYum{Directory: dir})
+				}
+			}
+
+			switch u := uploads[i].(type) {
+			case UploadComponentRaw:
+				u.Assets = append(u.Assets, UploadAssetRaw{File: f, Filename: name})
+				uploads[i] = u
+			case UploadComponentYum:
+				u.Assets = append(u.Assets, UploadAssetYum{File: f, Filename: name})
+				uploads[i] = u
+			}
+		}
+	case Npm, Pypi, Nuget, Rubygems, Apt:
+		// these formats upload a single file per component, so each asset is uploaded on its own
+		for _, asset := range c.Assets {
+			f, ok := files[asset.Path]
+			if !ok {
+				continue
+			}
+
+			switch format {
+			case Npm:
+				uploads = append(uploads, UploadComponentNpm{File: f})
+			case Pypi:
+				uploads = append(uploads, UploadComponentPyPi{File: f})
+			case Nuget:
+				uploads = append(uploads, UploadComponentNuget{File: f})
+			case Rubygems:
+				uploads = append(uploads, UploadComponentRubyGems{File: f})
+			case Apt:
+				uploads = append(uploads, UploadComponentApt{File: f})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("copying %s components is not supported", c.Format)
+	}
+
+	return uploads, nil
+}
+
+// findComponent returns the component of the repository with the same coordinates as the given one
+func findComponent(rm RM, repo string, c RepositoryItem) (RepositoryItem, bool, error) {
+	components, err := GetComponents(rm, repo)
+	if err != nil {
+		return RepositoryItem{}, false, err
+	}
+
+	for _, candidate := range components {
+		if candidate.Group == c.Group && candidate.Name == c.Name && candidate.Version == c.Version {
+			return candidate, true, nil
+		}
+	}
+
+	return RepositoryItem{}, false, nil
+}
+
+// CopyComponent copies a component to another hosted repository of the same format, as StagingMove does
+// without requiring a Pro license. The assets of the component are downloaded and verified, then uploaded
+// to the target repository through the components API, after which the checksums of the assets in the target
+// repository are verified against the original ones. Checksum files of maven2 assets are regenerated by RM,
+// while those of other formats are copied like any other asset.
+// Since the components API does not accept maven2 SNAPSHOTs, their assets are uploaded directly to their paths.
+// An error wrapping ErrWritePolicy is returned, before anything is uploaded, if the write policy of the target
+// repository denies writes or allows them once and the component already exists in the target repository.
+// Returns the component as found in the target repository.
+func CopyComponent(rm RM, component RepositoryItem, targetRepo string) (RepositoryItem, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not copy component '%s' to '%s': %w", componentKey(component), targetRepo, err)
+	}
+
+	settings, err := GetRepositorySettings(rm)
+	if err != nil {
+		return RepositoryItem{}, doError(err)
+	}
+
+	var target *RepositorySettings
+	for i := range settings {
+		if settings[i].Name == targetRepo {
+			target = &settings[i]
+		}
+	}
+
+	switch {
+	case target == nil:
+		return RepositoryItem{}, doError(errors.New("repository not found"))
+	case target.Type != "hosted":
+		return RepositoryItem{}, doError(errors.New("repository is not a hosted repository"))
+	case target.Format != component.Format:
+		return RepositoryItem{}, doError(fmt.Errorf("cannot copy %s component to a %s repository", component.Format, target.Format))
+	case target.Name == component.Repository:
+		return RepositoryItem{}, doError(errors.New("component is already in the repository"))
+	}
+
+	switch strings.ToUpper(target.Storage.WritePolicy) {
+	case WritePolicyDeny:
+		return RepositoryItem{}, doError(ErrWritePolicy)
+	case WritePolicyAllowOnce:
+		_, exists, err := findComponent(rm, targetRepo, component)
+		if err != nil {
+			return RepositoryItem{}, doError(err)
+		}
+		if exists {
+			return RepositoryItem{}, doError(fmt.Errorf("component already exists: %w", ErrWritePolicy))
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "nexusrm-copy-")
+	if err != nil {
+		return RepositoryItem{}, doError(err)
+	}
+	defer os.RemoveAll(dir)
+
+	format := parseRepositoryFormat(target.Format)
+
+	expected := make(map[string]string)
+	files := make(map[string]io.Reader)
+	for i, asset := range component.Assets {
+		if isGeneratedChecksum(format, asset.Path) {
+			continue
+		}
+
+		name := filepath.Join(dir, fmt.Sprintf("asset%d", i))
+		if err := DownloadAssetToFile(rm, asset, name); err != nil {
+			return RepositoryItem{}, doError(err)
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return RepositoryItem{}, doError(err)
+		}
+		defer f.Close()
+
+		files[asset.Path] = f
+		expected[asset.Path] = asset.Checksum.Sha1
+	}
+
+	if format == Maven && isMavenSnapshot(component) {
+		for _, asset := range component.Assets {
+			if f, ok := files[asset.Path]; ok {
+				if err := UploadAssetToPath(rm, targetRepo, asset.Path, f); err != nil {
+					return RepositoryItem{}, doError(err)
+				}
+			}
+		}
+	} else {
+		uploads, err := copyUploads(component, format, files)
+		if err != nil {
+			return RepositoryItem{}, doError(err)
+		}
+
+		for _, upload := range uploads {
+			if err := UploadComponent(rm, targetRepo, upload); err != nil {
+				return RepositoryItem{}, doError(err)
+			}
+		}
+	}
+
+	copied, found, err := findComponent(rm, targetRepo, component)
+	if err != nil {
+		return RepositoryItem{}, doError(err)
+	}
+	if !found {
+		return RepositoryItem{}, doError(errors.New("component not found after upload"))
+	}
+
+	actual := make(map[string]string)
+	for _, asset := range copied.Assets {
+		actual[strings.TrimPrefix(asset.Path, "/")] = asset.Checksum.Sha1
+	}
+
+	// every asset which was copied must be in the target, as a move deletes the component afterwards
+	for p, sha1 := range expected {
+		found, ok := actual[strings.TrimPrefix(p, "/")]
+		if !ok {
+			return copied, doError(fmt.Errorf("asset '%s' not found after upload", p))
+		}
+		if sha1 != "" && found != sha1 {
+			return copied, doError(fmt.Errorf("asset '%s': %w", p, ChecksumMismatchError{"sha1", sha1, found}))
+		}
+	}
+
+	return copied, nil
+}
+
+// MoveComponent copies a component to another hosted repository, as with CopyComponent,
+// then deletes it from its repository once every asset has been verified in the target repository
+func MoveComponent(rm RM, component RepositoryItem, targetRepo string) (RepositoryItem, error) {
+	moved, err := CopyComponent(rm, component, targetRepo)
+	if err != nil {
+		return moved, err
+	}
+
+	if err := DeleteComponentByID(rm, component.ID); err != nil {
+		return moved, fmt.Errorf("component copied to '%s' but not deleted from '%s': %v", targetRepo, component.Repository, err)
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,185 @@
+package nexusrm
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func copyTestRM(t *testing.T) *fakeRepositoryManager {
+	denied := inventoryRepository("frozen", "maven2", "hosted", "default")
+	denied.Storage.WritePolicy = WritePolicyDeny
+	once := inventoryRepository("releases", "maven2", "hosted", "default")
+	once.Storage.WritePolicy = WritePolicyAllowOnce
+
+	f := newFakeRepositoryManager(t,
+		inventoryRepository("snapshots", "maven2", "hosted", "default"),
+		once,
+		denied,
+		inventoryRepository("maven-proxy", "maven2", "proxy", "default"),
+		inventoryRepository("npm-hosted", "npm", "hosted", "default"),
+	)
+
+	f.addAsset("snapshots", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.jar", []byte("app jar"))
+	f.addAsset("snapshots", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0-sources.jar", []byte("app sources"))
+	f.addAsset("snapshots", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.pom", []byte("app pom"))
+	f.addAsset("snapshots", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.pom.sha1", []byte("sidecar"))
+	f.addAsset("snapshots", "org.test", "app", "1.1-SNAPSHOT", "org/test/app/1.1-SNAPSHOT/app-1.1-20200601.120000-1.jar", []byte("snapshot jar"))
+
+	for content, p := range map[string]string{
+		"app jar":     "org/test/app/1.0/app-1.0.jar",
+		"app sources": "org/test/app/1.0/app-1.0-sources.jar",
+		"app pom":     "org/test/app/1.0/app-1.0.pom",
+	} {
+		f.uploadCoordinates[fakeChecksum([]byte(content)).Sha1] = RepositoryItem{
+			Group: "org.test", Name: "app", Version: "1.0", Assets: []RepositoryItemAsset{{Path: p}},
+		}
+	}
+
+	return f
+}
+
+func copyTestComponent(f *fakeRepositoryManager, version string) RepositoryItem {
+	for _, c := range f.components {
+		if c.Repository == "snapshots" && c.Version == version {
+			return c
+		}
+	}
+	return RepositoryItem{}
+}
+
+func TestCopyComponent(t *testing.T) {
+	f := copyTestRM(t)
+	defer f.Close()
+
+	copied, err := CopyComponent(f.rm, copyTestComponent(f, "1.0"), "releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if copied.Repository != "releases" || len(copied.Assets) != 3 {
+		t.Errorf("Unexpected copy: %v", copied)
+	}
+
+	sort.Strings(f.uploads)
+	expected := []string{"releases/org/test/app/1.0/app-1.0-sources.jar", "releases/org/test/app/1.0/app-1.0.jar", "releases/org/test/app/1.0/app-1.0.pom"}
+	if !reflect.DeepEqual(f.uploads, expected) {
+		t.Errorf("Expected uploads %v but got %v", expected, f.uploads)
+	}
+
+	if len(f.uploadFields) != 1 {
+		t.Fatalf("Expected a single upload but got %d", len(f.uploadFields))
+	}
+	fields := f.uploadFields[0]
+	if fields["maven2.groupId"] != "org.test" || fields["maven2.version"] != "1.0" || fields["maven2.generate-pom"] != "false" ||
+		fields["maven2.asset2.classifier"] != "sources" || fields["maven2.asset2.extension"] != "jar" || fields["maven2.asset3.extension"] != "pom" {
+		t.Errorf("Unexpected upload fields: %v", fields)
+	}
+
+	// the source is left alone
+	if c := copyTestComponent(f, "1.0"); len(c.Assets) != 4 {
+		t.Errorf("Source component changed: %v", c)
+	}
+
+	// releases only allows a component to be written once
+	if _, err = CopyComponent(f.rm, copyTestComponent(f, "1.0"), "releases"); !errors.Is(err, ErrWritePolicy) {
+		t.Errorf("Expected write policy error but got %v", err)
+	}
+}
+
+func TestCopyComponentRefused(t *testing.T) {
+	f := copyTestRM(t)
+	defer f.Close()
+
+	component := copyTestComponent(f, "1.0")
+	if _, err := CopyComponent(f.rm, component, "frozen"); !errors.Is(err, ErrWritePolicy) {
+		t.Errorf("Expected write policy error but got %v", err)
+	}
+
+	for _, repo := range []string{"maven-proxy", "npm-hosted", "missing", "snapshots"} {
+		if _, err := CopyComponent(f.rm, component, repo); err == nil {
+			t.Errorf("Expected copy to '%s' to be refused", repo)
+		}
+	}
+
+	if len(f.uploads) != 0 {
+		t.Errorf("Unexpected uploads: %v", f.uploads)
+	}
+}
+
+func TestMoveComponentSnapshot(t *testing.T) {
+	f := copyTestRM(t)
+	defer f.Close()
+
+	f.repos = append(f.repos, inventoryRepository("other-snapshots", "maven2", "hosted", "default"))
+
+	moved, err := MoveComponent(f.rm, copyTestComponent(f, "1.1-SNAPSHOT"), "other-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if moved.Repository != "other-snapshots" || len(f.uploadFields) != 0 {
+		t.Errorf("Expected snapshot to be uploaded to its path: %v, %v", moved, f.uploadFields)
+	}
+
+	if c := copyTestComponent(f, "1.1-SNAPSHOT"); c.ID != "" {
+		t.Errorf("Source component not deleted: %v", c)
+	}
+}
+
+func TestMoveComponentChecksumFiles(t *testing.T) {
+	f := newFakeRepositoryManager(t,
+		inventoryRepository("raw-staging", "raw", "hosted", "default"),
+		inventoryRepository("raw-releases", "raw", "hosted", "default"),
+		inventoryRepository("raw-partial", "raw", "hosted", "default"),
+	)
+	defer f.Close()
+
+	f.addAsset("raw-staging", "/files", "app", "", "files/app.tar.gz", []byte("app tarball"))
+	f.addAsset("raw-staging", "/files", "app", "", "files/app.tar.gz.sha256", []byte("uploaded checksum"))
+	component := f.components[0]
+
+	for content, p := range map[string]string{
+		"app tarball":       "files/app.tar.gz",
+		"uploaded checksum": "files/app.tar.gz.sha256",
+	} {
+		f.uploadCoordinates[fakeChecksum([]byte(content)).Sha1] = RepositoryItem{
+			Group: "/files", Name: "app", Assets: []RepositoryItemAsset{{Path: p}},
+		}
+	}
+
+	// only maven2 checksum files are generated by RM, in other formats they are uploaded content
+	if _, err := MoveComponent(f.rm, component, "raw-releases"); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(f.uploads)
+	expected := []string{"raw-releases/files/app.tar.gz", "raw-releases/files/app.tar.gz.sha256"}
+	if !reflect.DeepEqual(f.uploads, expected) {
+		t.Errorf("Expected uploads %v but got %v", expected, f.uploads)
+	}
+
+	// a component is not deleted unless all of its assets are in the target
+	f.uploadCoordinates[fakeChecksum([]byte("uploaded checksum")).Sha1] = RepositoryItem{
+		Group: "/files", Name: "other", Assets: []RepositoryItemAsset{{Path: "files/app.tar.gz.sha256"}},
+	}
+
+	var moved RepositoryItem
+	for _, c := range f.components {
+		if c.Repository == "raw-releases" {
+			moved = c
+		}
+	}
+
+	if _, err := MoveComponent(f.rm, moved, "raw-partial"); err == nil {
+		t.Error("Expected move to fail when an asset is missing from the target")
+	}
+
+	for _, c := range f.components {
+		if c.ID == moved.ID {
+			return
+		}
+	}
+	t.Error("Source component deleted although an asset was missing from the target")
+}
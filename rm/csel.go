@@ -0,0 +1,303 @@
+package nexusrm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CSEL attributes which are understood by the local evaluator
+const (
+	CSELFormat = "format"
+	CSELPath   = "path"
+)
+
+const cselCoordinatePrefix = "coordinate."
+
+type cselTokenType int
+
+const (
+	cselEOF cselTokenType = iota
+	cselIdent
+	cselString
+	cselOperator
+	cselAnd
+	cselOr
+	cselLParen
+	cselRParen
+)
+
+type cselToken struct {
+	typ cselTokenType
+	val string
+	pos int
+}
+
+func (t cselToken) String() string {
+	if t.typ == cselEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s' at position %d", t.val, t.pos)
+}
+
+func isCSELIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func lexCSEL(expression string) ([]cselToken, error) {
+	tokens := make([]cselToken, 0)
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, cselToken{cselLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, cselToken{cselRParen, ")", i})
+			i++
+		case c == '=':
+			if i+1 >= len(expression) {
+				return nil, fmt.Errorf("incomplete operator at position %d", i)
+			}
+			op := expression[i : i+2]
+			switch op {
+			case "==", "=~", "=^":
+				tokens = append(tokens, cselToken{cselOperator, op, i})
+			default:
+				return nil, fmt.Errorf("unknown operator '%s' at position %d", op, i)
+			}
+			i += 2
+		case c == '"' || c == '\'':
+			var buf strings.Builder
+			start := i
+			i++
+			for ; i < len(expression) && expression[i] != c; i++ {
+				if expression[i] == '\\' && i+1 < len(expression) {
+					i++
+				}
+				buf.WriteByte(expression[i])
+			}
+			if i >= len(expression) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start)
+			}
+			i++
+			tokens = append(tokens, cselToken{cselString, buf.String(), start})
+		case isCSELIdentChar(c):
+			start := i
+			for i < len(expression) && isCSELIdentChar(expression[i]) {
+				i++
+			}
+			word := expression[start:i]
+			switch word {
+			case "and":
+				tokens = append(tokens, cselToken{cselAnd, word, start})
+			case "or":
+				tokens = append(tokens, cselToken{cselOr, word, start})
+			default:
+				tokens = append(tokens, cselToken{cselIdent, word, start})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+
+	return append(tokens, cselToken{typ: cselEOF, pos: len(expression)}), nil
+}
+
+type cselNode interface {
+	eval(attributes map[string]string) bool
+}
+
+type cselAndNode struct{ left, right cselNode }
+
+func (n cselAndNode) eval(attributes map[string]string) bool {
+	return n.left.eval(attributes) && n.right.eval(attributes)
+}
+
+type cselOrNode struct{ left, right cselNode }
+
+func (n cselOrNode) eval(attributes map[string]string) bool {
+	return n.left.eval(attributes) || n.right.eval(attributes)
+}
+
+type cselComparisonNode struct {
+	attribute, operator, value string
+	re                         *regexp.Regexp
+}
+
+func (n cselComparisonNode) eval(attributes map[string]string) bool {
+	actual, ok := attributes[n.attribute]
+	if !ok {
+		return false
+	}
+
+	switch n.operator {
+	case "==":
+		return actual == n.value
+	case "=^":
+		return strings.HasPrefix(actual, n.value)
+	case "=~":
+		return n.re.MatchString(actual)
+	}
+
+	return false
+}
+
+type cselParser struct {
+	tokens []cselToken
+	pos    int
+}
+
+func (p *cselParser) peek() cselToken {
+	return p.tokens[p.pos]
+}
+
+func (p *cselParser) next() cselToken {
+	t := p.tokens[p.pos]
+	if t.typ != cselEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *cselParser) parseOr() (cselNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().typ == cselOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = cselOrNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *cselParser) parseAnd() (cselNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().typ == cselAnd {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = cselAndNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *cselParser) parsePrimary() (cselNode, error) {
+	t := p.next()
+	switch t.typ {
+	case cselLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.typ != cselRParen {
+			return nil, fmt.Errorf("expected ')' but found %s", closing)
+		}
+		return node, nil
+	case cselIdent:
+		if t.val != CSELFormat && t.val != CSELPath &&
+			!(strings.HasPrefix(t.val, cselCoordinatePrefix) && len(t.val) > len(cselCoordinatePrefix)) {
+			return nil, fmt.Errorf("unknown attribute %s", t)
+		}
+
+		op := p.next()
+		if op.typ != cselOperator {
+			return nil, fmt.Errorf("expected an operator but found %s", op)
+		}
+
+		value := p.next()
+		if value.typ != cselString {
+			return nil, fmt.Errorf("expected a quoted string but found %s", value)
+		}
+
+		node := cselComparisonNode{attribute: t.val, operator: op.val, value: value.val}
+		if op.val == "=~" {
+			// CSEL regular expressions must match the entire value
+			re, err := regexp.Compile("^(?:" + value.val + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %s: %v", value, err)
+			}
+			node.re = re
+		}
+
+		return node, nil
+	default:
+		return nil, fmt.Errorf("expected an attribute or '(' but found %s", t)
+	}
+}
+
+// CSELExpression is a parsed content selector expression which can be evaluated locally
+type CSELExpression struct {
+	expression string
+	root       cselNode
+}
+
+// ParseCSEL parses a content selector expression such as `format == "maven2" and path =^ "/org/acme/"`
+func ParseCSEL(expression string) (*CSELExpression, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("invalid CSEL expression: %v", err)
+	}
+
+	tokens, err := lexCSEL(expression)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	p := &cselParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	if t := p.peek(); t.typ != cselEOF {
+		return nil, doError(fmt.Errorf("unexpected %s", t))
+	}
+
+	return &CSELExpression{expression: expression, root: root}, nil
+}
+
+// ValidateCSEL returns an error if the given content selector expression is not syntactically valid
+func ValidateCSEL(expression string) error {
+	_, err := ParseCSEL(expression)
+	return err
+}
+
+func (e *CSELExpression) String() string {
+	return e.expression
+}
+
+// Matches evaluates the expression against the given attributes (e.g. "format", "path", "coordinate.groupId")
+func (e *CSELExpression) Matches(attributes map[string]string) bool {
+	return e.root.eval(attributes)
+}
+
+// MatchesAsset evaluates the expression against the format and path of the given asset
+func (e *CSELExpression) MatchesAsset(asset RepositoryItemAsset) bool {
+	path := asset.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return e.Matches(map[string]string{
+		CSELFormat: asset.Format,
+		CSELPath:   path,
+	})
+}
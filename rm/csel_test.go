@@ -0,0 +1,78 @@
+package nexusrm
+
+import "testing"
+
+func TestValidateCSEL(t *testing.T) {
+	tests := []struct {
+		expression string
+		valid      bool
+	}{
+		{`format == "maven2"`, true},
+		{`format == "maven2" and path =^ "/org/acme/"`, true},
+		{`format == 'npm' or (format == "maven2" and path =~ "/org/.*\\.jar")`, true},
+		{`coordinate.groupId == "org.acme"`, true},
+		{`(format == "raw")`, true},
+		{``, false},
+		{`format = "maven2"`, false},
+		{`format == maven2`, false},
+		{`format == "maven2" and`, false},
+		{`(format == "maven2"`, false},
+		{`format == "maven2")`, false},
+		{`version == "1.0"`, false},
+		{`path =~ "/org/(acme"`, false},
+		{`path =^ "/org/acme`, false},
+		{`format != "npm"`, false},
+	}
+
+	for _, test := range tests {
+		err := ValidateCSEL(test.expression)
+		if test.valid && err != nil {
+			t.Errorf("Expected %q to be valid: %v", test.expression, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Expected %q to be invalid", test.expression)
+		}
+	}
+}
+
+func TestCSELMatches(t *testing.T) {
+	asset := RepositoryItemAsset{Path: "org/acme/widget/1.0/widget-1.0.jar", Format: "maven2"}
+
+	tests := []struct {
+		expression string
+		matches    bool
+	}{
+		{`format == "maven2"`, true},
+		{`format == "npm"`, false},
+		{`path =^ "/org/acme/"`, true},
+		{`path =^ "/com/acme/"`, false},
+		{`path =~ "/org/acme/.*\\.jar"`, true},
+		{`path =~ "/org/acme"`, false},
+		{`format == "npm" or path =^ "/org/"`, true},
+		{`format == "npm" and path =^ "/org/"`, false},
+		{`format == "maven2" and (path =^ "/com/" or path =^ "/org/")`, true},
+		{`coordinate.groupId == "org.acme"`, false},
+	}
+
+	for _, test := range tests {
+		csel, err := ParseCSEL(test.expression)
+		if err != nil {
+			t.Fatalf("Could not parse %q: %v", test.expression, err)
+		}
+
+		if got := csel.MatchesAsset(asset); got != test.matches {
+			t.Errorf("Expected %q to evaluate to %v but got %v", test.expression, test.matches, got)
+		}
+	}
+}
+
+func TestCSELPrecedence(t *testing.T) {
+	csel, err := ParseCSEL(`format == "npm" and path =^ "/x" or format == "maven2"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !csel.Matches(map[string]string{CSELFormat: "maven2", CSELPath: "/y"}) {
+		t.Error("Expected 'and' to bind tighter than 'or'")
+	}
+}
@@ -0,0 +1,653 @@
+package nexusrm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	nexus "github.com/overag3/gonexus"
+)
+
+// Media types of the manifests of the registry API
+const (
+	DockerManifestV2   = "application/vnd.docker.distribution.manifest.v2+json"
+	DockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	OCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	OCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+const dockerContentDigest = "Docker-Content-Digest"
+
+var (
+	dockerChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	dockerNextLink       = regexp.MustCompile(`<([^>]+)>;\s*rel="?next"?`)
+)
+
+// DockerPlatform is the platform of an image of a manifest list or index
+type DockerPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// DockerDescriptor references a blob or a manifest by its digest
+type DockerDescriptor struct {
+	MediaType string          `json:"mediaType,omitempty"`
+	Size      int64           `json:"size"`
+	Digest    string          `json:"digest"`
+	URLs      []string        `json:"urls,omitempty"`
+	Platform  *DockerPlatform `json:"platform,omitempty"`
+}
+
+// DockerManifest is an image manifest, of the v2 schema 2 or OCI format, or a manifest list or OCI index which
+// references the manifests of an image for several platforms
+type DockerManifest struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	MediaType     string             `json:"mediaType,omitempty"`
+	Config        *DockerDescriptor  `json:"config,omitempty"`
+	Layers        []DockerDescriptor `json:"layers,omitempty"`
+	Manifests     []DockerDescriptor `json:"manifests,omitempty"`
+	// Digest is the digest of the manifest as the registry served it
+	Digest string `json:"-"`
+	// Raw is the manifest as the registry served it, which is what its digest is computed from
+	Raw []byte `json:"-"`
+}
+
+// IsIndex returns true for manifest lists and OCI indexes
+func (m DockerManifest) IsIndex() bool {
+	return m.MediaType == DockerManifestList || m.MediaType == OCIIndex || (m.MediaType == "" && len(m.Manifests) > 0)
+}
+
+// DockerRegistry is a client of the Docker Registry HTTP API V2 served by the connector of an RM docker repository.
+// It authenticates with the credentials of the RM client, exchanging them for bearer tokens when the registry
+// asks for them.
+type DockerRegistry struct {
+	rm   RM
+	base *url.URL
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewDockerRegistry creates a client of the registry served by the HTTP or HTTPS connector of a docker repository,
+// such as https://nexus:8083
+func NewDockerRegistry(rm RM, connector string) (*DockerRegistry, error) {
+	base, err := url.Parse(strings.TrimSuffix(connector, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker connector '%s': %v", connector, err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid docker connector '%s': not an absolute URL", connector)
+	}
+
+	return &DockerRegistry{rm: rm, base: base, tokens: make(map[string]string)}, nil
+}
+
+// NewDockerRepositoryRegistry creates a client of the registry of a docker repository through path based routing,
+// where RM serves it under the path of the repository instead of a connector
+func NewDockerRepositoryRegistry(rm RM, repo string) (*DockerRegistry, error) {
+	return NewDockerRegistry(rm, fmt.Sprintf("%s/repository/%s", rm.Info().Host, repo))
+}
+
+func (d *DockerRegistry) url(format string, a ...interface{}) string {
+	return d.base.String() + fmt.Sprintf(format, a...)
+}
+
+// resolve returns the absolute URL of a location returned by the registry, which can be relative
+func (d *DockerRegistry) resolve(location string) (string, error) {
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return d.base.ResolveReference(ref).String(), nil
+}
+
+// token exchanges the credentials of the RM client for a bearer token, as asked by the challenge of the registry
+func (d *DockerRegistry) token(challenge string) (string, error) {
+	params := make(map[string]string)
+	for _, p := range dockerChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(p[1])] = p[2]
+	}
+
+	realm, ok := params["realm"]
+	if !ok {
+		return "", errors.New("bearer challenge without a realm")
+	}
+
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for _, p := range []string{"service", "scope"} {
+		if v, ok := params[p]; ok {
+			q.Set(p, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if info := d.rm.Info(); info.Username != "" {
+		req.SetBasicAuth(info.Username, info.Password)
+	}
+
+	resp, err := nexus.Stream(d.rm, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+// do sends a request about an image, or the catalog if the image is empty, with the token of the image if one was
+// issued and with the credentials of the RM client otherwise. If the registry challenges the request, it is sent
+// again with the token or credentials it asked for.
+func (d *DockerRegistry) do(req *http.Request, image string, expected ...int) (*http.Response, error) {
+	authorize := func(r *http.Request) {
+		d.mu.Lock()
+		token := d.tokens[image]
+		d.mu.Unlock()
+
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		} else if info := d.rm.Info(); info.Username != "" {
+			r.SetBasicAuth(info.Username, info.Password)
+		}
+	}
+
+	authorize(req)
+	resp, err := nexus.Stream(d.rm, req)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(strings.ToLower(challenge), "bearer") && (req.Body == nil || req.GetBody != nil) {
+		resp.Body.Close()
+
+		token, err := d.token(challenge)
+		if err != nil {
+			return nil, fmt.Errorf("could not authenticate: %v", err)
+		}
+
+		d.mu.Lock()
+		d.tokens[image] = token
+		d.mu.Unlock()
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		authorize(retry)
+
+		if resp, err = nexus.Stream(d.rm, retry); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+
+	defer resp.Body.Close()
+
+	// errors of the registry API have a code and a message
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && len(body.Errors) > 0 {
+		return nil, fmt.Errorf("%s: %s: %s", resp.Status, body.Errors[0].Code, body.Errors[0].Message)
+	}
+	return nil, errors.New(resp.Status)
+}
+
+// list follows the pages of a list of the registry API, such as the catalog or the tags of an image
+func (d *DockerRegistry) list(endpoint, image, field string) ([]string, error) {
+	items := make([]string, 0)
+
+	for endpoint != "" {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := d.do(req, image, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+
+		var page map[string]json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var pageItems []string
+		if raw, ok := page[field]; ok && string(raw) != "null" {
+			if err := json.Unmarshal(raw, &pageItems); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, pageItems...)
+
+		endpoint = ""
+		if m := dockerNextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			if endpoint, err = d.resolve(m[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// Catalog returns the names of the images of the registry
+func (d *DockerRegistry) Catalog() ([]string, error) {
+	images, err := d.list(d.url("/v2/_catalog"), "", "repositories")
+	if err != nil {
+		return nil, fmt.Errorf("could not list images of %s: %v", d.base, err)
+	}
+	return images, nil
+}
+
+// Tags returns the tags of an image
+func (d *DockerRegistry) Tags(image string) ([]string, error) {
+	tags, err := d.list(d.url("/v2/%s/tags/list", image), image, "tags")
+	if err != nil {
+		return nil, fmt.Errorf("could not list tags of '%s': %v", image, err)
+	}
+	return tags, nil
+}
+
+// newDigestVerifier returns a hash of the algorithm of a digest, such as sha256:..., and a function
+// which verifies the digest once the content has been written to the hash
+func newDigestVerifier(digest string) (hash.Hash, func() error, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid digest '%s'", digest)
+	}
+
+	var h hash.Hash
+	switch parts[0] {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, nil, fmt.Errorf("unsupported digest algorithm '%s'", parts[0])
+	}
+
+	return h, func() error {
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != parts[1] {
+			return ChecksumMismatchError{parts[0], parts[1], actual}
+		}
+		return nil
+	}, nil
+}
+
+// isDockerDigest returns true if a reference is a digest rather than a tag
+func isDockerDigest(reference string) bool {
+	return strings.Contains(reference, ":")
+}
+
+// Manifest returns the manifest of an image by tag or digest, preferring v2 schema 2 and OCI manifests and indexes.
+// Manifests requested by digest are verified against it and a ChecksumMismatchError is returned if they differ.
+func (d *DockerRegistry) Manifest(image, reference string) (DockerManifest, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not retrieve manifest of %s:%s: %w", image, reference, err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, d.url("/v2/%s/manifests/%s", image, reference), nil)
+	if err != nil {
+		return DockerManifest{}, doError(err)
+	}
+	req.Header.Set("Accept", strings.Join([]string{DockerManifestV2, DockerManifestList, OCIManifest, OCIIndex}, ", "))
+
+	resp, err := d.do(req, image, http.StatusOK)
+	if err != nil {
+		return DockerManifest{}, doError(err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return DockerManifest{}, doError(err)
+	}
+
+	var m DockerManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return DockerManifest{}, doError(err)
+	}
+	if m.MediaType == "" {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+	m.Raw = raw
+
+	sum := sha256.Sum256(raw)
+	m.Digest = "sha256:" + hex.EncodeToString(sum[:])
+
+	digest := resp.Header.Get(dockerContentDigest)
+	if isDockerDigest(reference) {
+		digest = reference
+	}
+	if digest != "" {
+		h, verify, err := newDigestVerifier(digest)
+		if err != nil {
+			return DockerManifest{}, doError(err)
+		}
+		h.Write(raw)
+		if err := verify(); err != nil {
+			return DockerManifest{}, doError(err)
+		}
+		m.Digest = digest
+	}
+
+	return m, nil
+}
+
+// PutManifest uploads a manifest under a tag or its digest, as it was served by the registry it was retrieved from.
+// Every blob and manifest it references must already be in the registry. Returns the digest of the manifest.
+func (d *DockerRegistry) PutManifest(image, reference string, m DockerManifest) (string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not upload manifest of %s:%s: %v", image, reference, err)
+	}
+
+	raw := m.Raw
+	if raw == nil {
+		var err error
+		if raw, err = json.Marshal(m); err != nil {
+			return "", doError(err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, d.url("/v2/%s/manifests/%s", image, reference), bytes.NewReader(raw))
+	if err != nil {
+		return "", doError(err)
+	}
+	req.Header.Set("Content-Type", m.MediaType)
+
+	resp, err := d.do(req, image, http.StatusCreated, http.StatusOK)
+	if err != nil {
+		return "", doError(err)
+	}
+	resp.Body.Close()
+
+	sum := sha256.Sum256(raw)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if served := resp.Header.Get(dockerContentDigest); served != "" && served != digest {
+		return "", doError(ChecksumMismatchError{"sha256", digest, served})
+	}
+
+	return digest, nil
+}
+
+// DeleteManifest deletes a manifest by digest, which untags every tag of the manifest. The blobs of
+// the image are removed by the Docker garbage collection task (TaskTypeDockerGC).
+func (d *DockerRegistry) DeleteManifest(image, digest string) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not delete manifest %s of '%s': %v", digest, image, err)
+	}
+
+	if !isDockerDigest(digest) {
+		return doError(errors.New("manifests can only be deleted by digest"))
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, d.url("/v2/%s/manifests/%s", image, digest), nil)
+	if err != nil {
+		return doError(err)
+	}
+
+	resp, err := d.do(req, image, http.StatusAccepted, http.StatusOK)
+	if err != nil {
+		return doError(err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// BlobExists returns true if the registry has the blob with the given digest for an image
+func (d *DockerRegistry) BlobExists(image, digest string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, d.url("/v2/%s/blobs/%s", image, digest), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := d.do(req, image, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return false, fmt.Errorf("could not check blob %s of '%s': %v", digest, image, err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// PullBlob downloads the blob with the given digest and writes it, verifying it against its digest as it is written.
+// A ChecksumMismatchError is returned once the whole blob has been written if it does not match its digest.
+func (d *DockerRegistry) PullBlob(image, digest string, w io.Writer) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not pull blob %s of '%s': %w", digest, image, err)
+	}
+
+	h, verify, err := newDigestVerifier(digest)
+	if err != nil {
+		return doError(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, d.url("/v2/%s/blobs/%s", image, digest), nil)
+	if err != nil {
+		return doError(err)
+	}
+
+	resp, err := d.do(req, image, http.StatusOK)
+	if err != nil {
+		return doError(err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return doError(err)
+	}
+
+	if err := verify(); err != nil {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// PushBlob uploads a blob for an image and returns its digest. If the digest of the blob is given, the blob is not
+// uploaded if the registry already has it, and the content is verified against it before the upload is completed.
+func (d *DockerRegistry) PushBlob(image, digest string, content io.Reader) (string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not push blob of '%s': %w", image, err)
+	}
+
+	var h hash.Hash = sha256.New()
+	verify := func() error { return nil }
+	if digest != "" {
+		var err error
+		if h, verify, err = newDigestVerifier(digest); err != nil {
+			return "", doError(err)
+		}
+
+		exists, err := d.BlobExists(image, digest)
+		if err != nil {
+			return "", doError(err)
+		}
+		if exists {
+			return digest, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.url("/v2/%s/blobs/uploads/", image), nil)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	resp, err := d.do(req, image, http.StatusAccepted)
+	if err != nil {
+		return "", doError(err)
+	}
+	resp.Body.Close()
+
+	location, err := d.resolve(resp.Header.Get("Location"))
+	if err != nil {
+		return "", doError(err)
+	}
+
+	if req, err = http.NewRequest(http.MethodPatch, location, io.TeeReader(content, h)); err != nil {
+		return "", doError(err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if resp, err = d.do(req, image, http.StatusAccepted, http.StatusNoContent); err != nil {
+		return "", doError(err)
+	}
+	resp.Body.Close()
+
+	if location, err = d.resolve(resp.Header.Get("Location")); err != nil {
+		return "", doError(err)
+	}
+
+	if err := verify(); err != nil {
+		return "", doError(err)
+	}
+	if digest == "" {
+		digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", doError(err)
+	}
+	q := u.Query()
+	q.Set("digest", digest)
+	u.RawQuery = q.Encode()
+
+	if req, err = http.NewRequest(http.MethodPut, u.String(), nil); err != nil {
+		return "", doError(err)
+	}
+
+	if resp, err = d.do(req, image, http.StatusCreated); err != nil {
+		return "", doError(err)
+	}
+	resp.Body.Close()
+
+	return digest, nil
+}
+
+// copyDockerBlob copies a blob between registries through a pipe, so that it is verified as it is pulled
+// and pushed without being held in memory
+func copyDockerBlob(src *DockerRegistry, srcImage string, dst *DockerRegistry, dstImage string, blob DockerDescriptor) error {
+	exists, err := dst.BlobExists(dstImage, blob.Digest)
+	if err != nil || exists {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(src.PullBlob(srcImage, blob.Digest, pw))
+	}()
+
+	_, err = dst.PushBlob(dstImage, blob.Digest, pr)
+	pr.CloseWithError(err)
+	return err
+}
+
+// copyDockerManifest copies a manifest and everything it references, then uploads it under the given reference
+func copyDockerManifest(src *DockerRegistry, srcImage string, dst *DockerRegistry, dstImage string, m DockerManifest, reference string) (string, error) {
+	if m.IsIndex() {
+		for _, child := range m.Manifests {
+			cm, err := src.Manifest(srcImage, child.Digest)
+			if err != nil {
+				return "", err
+			}
+			if _, err := copyDockerManifest(src, srcImage, dst, dstImage, cm, child.Digest); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		blobs := append([]DockerDescriptor(nil), m.Layers...)
+		if m.Config != nil {
+			blobs = append(blobs, *m.Config)
+		}
+
+		for _, blob := range blobs {
+			// foreign layers are downloaded from their URLs rather than from the registry
+			if len(blob.URLs) > 0 {
+				continue
+			}
+			if err := copyDockerBlob(src, srcImage, dst, dstImage, blob); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return dst.PutManifest(dstImage, reference, m)
+}
+
+// CopyDockerImage copies an image by tag or digest from one registry to another, such as to promote it between
+// RM docker repositories, under the given tag or, if empty, under its digest. The blobs of the image are verified
+// as they are copied and are not copied again if the target registry has them. The images of every platform of a
+// manifest list or OCI index are copied. Returns the digest of the copied manifest.
+func CopyDockerImage(src *DockerRegistry, srcImage, reference string, dst *DockerRegistry, dstImage, tag string) (string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not copy %s:%s to %s: %w", srcImage, reference, dstImage, err)
+	}
+
+	m, err := src.Manifest(srcImage, reference)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	if tag == "" {
+		tag = m.Digest
+	}
+
+	digest, err := copyDockerManifest(src, srcImage, dst, dstImage, m, tag)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,455 @@
+package nexusrm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const dummyDockerToken = "dummy-token"
+
+// fakeDockerRegistry is a registry which requires bearer tokens issued for the credentials of the test RM
+type fakeDockerRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]map[string][]byte // by image then tag or digest
+	types     map[string]string            // media types of manifests by digest
+	uploads   map[string]*bytes.Buffer
+	tokens    int
+	server    *httptest.Server
+}
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func newFakeDockerRegistry(t *testing.T) *fakeDockerRegistry {
+	f := &fakeDockerRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]map[string][]byte),
+		types:     make(map[string]string),
+		uploads:   make(map[string]*bytes.Buffer),
+	}
+
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.URL.Path == "/v2/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "dummy_user" || pass != "dummy_pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			f.tokens++
+			fmt.Fprintf(w, `{"token":%q}`, dummyDockerToken)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+dummyDockerToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/v2/token",service="%s",scope="repository:dummy:pull"`, f.server.URL, f.server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		f.serve(t, w, r)
+	}))
+
+	return f
+}
+
+func (f *fakeDockerRegistry) addManifest(image, tag, mediaType string, m interface{}) string {
+	raw, _ := json.Marshal(m)
+	digest := sha256Digest(raw)
+
+	if f.manifests[image] == nil {
+		f.manifests[image] = make(map[string][]byte)
+	}
+	f.manifests[image][digest] = raw
+	if tag != "" {
+		f.manifests[image][tag] = raw
+	}
+	f.types[digest] = mediaType
+
+	return digest
+}
+
+func (f *fakeDockerRegistry) addBlob(content string) DockerDescriptor {
+	digest := sha256Digest([]byte(content))
+	f.blobs[digest] = []byte(content)
+	return DockerDescriptor{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Size: int64(len(content)), Digest: digest}
+}
+
+func (f *fakeDockerRegistry) serve(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	if p == "_catalog" {
+		images := make([]string, 0)
+		for image := range f.manifests {
+			images = append(images, image)
+		}
+		sort.Strings(images)
+
+		// one image per page
+		last := r.URL.Query().Get("last")
+		for i, image := range images {
+			if image > last {
+				if i < len(images)-1 {
+					w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=%s&n=1>; rel="next"`, image))
+				}
+				fmt.Fprintf(w, `{"repositories":[%q]}`, image)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"repositories":[]}`)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(p, "/tags/list"):
+		image := strings.TrimSuffix(p, "/tags/list")
+		tags := make([]string, 0)
+		for ref := range f.manifests[image] {
+			if !strings.Contains(ref, ":") {
+				tags = append(tags, ref)
+			}
+		}
+		sort.Strings(tags)
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": image, "tags": tags})
+	case strings.Contains(p, "/manifests/"):
+		parts := strings.SplitN(p, "/manifests/", 2)
+		image, ref := parts[0], parts[1]
+
+		switch r.Method {
+		case http.MethodGet:
+			raw, ok := f.manifests[image][ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`)
+				return
+			}
+			w.Header().Set("Content-Type", f.types[sha256Digest(raw)])
+			w.Header().Set(dockerContentDigest, sha256Digest(raw))
+			w.Write(raw)
+		case http.MethodPut:
+			raw, _ := ioutil.ReadAll(r.Body)
+
+			var m DockerManifest
+			if err := json.Unmarshal(raw, &m); err != nil {
+				t.Fatal(err)
+			}
+
+			// everything the manifest references must be in the registry
+			for _, d := range append(m.Layers, m.Manifests...) {
+				_, isBlob := f.blobs[d.Digest]
+				_, isManifest := f.manifests[image][d.Digest]
+				if !isBlob && !isManifest {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"errors":[{"code":"MANIFEST_BLOB_UNKNOWN","message":"blob unknown"}]}`)
+					return
+				}
+			}
+
+			f.addManifest(image, "", r.Header.Get("Content-Type"), json.RawMessage(raw))
+			f.manifests[image][ref] = raw
+			w.Header().Set(dockerContentDigest, sha256Digest(raw))
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			raw, ok := f.manifests[image][ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			for r, candidate := range f.manifests[image] {
+				if bytes.Equal(candidate, raw) {
+					delete(f.manifests[image], r)
+				}
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case strings.Contains(p, "/blobs/uploads/"):
+		id := p[strings.Index(p, "/blobs/uploads/")+len("/blobs/uploads/"):]
+		switch r.Method {
+		case http.MethodPost:
+			id = fmt.Sprintf("upload%d", len(f.uploads))
+			f.uploads[id] = new(bytes.Buffer)
+			w.Header().Set("Location", "/v2/"+strings.Replace(p, "/blobs/uploads/", "/blobs/uploads/"+id, 1))
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPatch:
+			f.uploads[id].ReadFrom(r.Body)
+			w.Header().Set("Location", r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPut:
+			content := f.uploads[id].Bytes()
+			if digest := r.URL.Query().Get("digest"); digest != sha256Digest(content) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors":[{"code":"DIGEST_INVALID","message":"digest did not match"}]}`)
+				return
+			}
+			f.blobs[sha256Digest(content)] = content
+			w.WriteHeader(http.StatusCreated)
+		}
+	case strings.Contains(p, "/blobs/"):
+		content, ok := f.blobs[p[strings.Index(p, "/blobs/")+len("/blobs/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write(content)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestDockerRegistry(t *testing.T) (*fakeDockerRegistry, *DockerRegistry) {
+	fake := newFakeDockerRegistry(t)
+
+	rm, err := New("http://localhost:8081", "dummy_user", "dummy_pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewDockerRegistry(rm, fake.server.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fake, registry
+}
+
+func dummyDockerImage(f *fakeDockerRegistry, image, tag, layer string) (DockerManifest, string) {
+	m := DockerManifest{
+		SchemaVersion: 2,
+		MediaType:     DockerManifestV2,
+		Config:        &DockerDescriptor{MediaType: "application/vnd.docker.container.image.v1+json"},
+		Layers:        []DockerDescriptor{f.addBlob(layer)},
+	}
+	config := f.addBlob(`{"architecture":"amd64","os":"linux"}` + layer)
+	m.Config.Size, m.Config.Digest = config.Size, config.Digest
+
+	return m, f.addManifest(image, tag, DockerManifestV2, m)
+}
+
+func TestNewDockerRegistry(t *testing.T) {
+	rm, err := New("https://nexus:8443", "dummy_user", "dummy_pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := NewDockerRepositoryRegistry(rm, "docker-hosted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := registry.url("/v2/%s/tags/list", "dummy"); actual != "https://nexus:8443/repository/docker-hosted/v2/dummy/tags/list" {
+		t.Errorf("Unexpected URL %s", actual)
+	}
+
+	if _, err := NewDockerRegistry(rm, "nexus:8083"); err == nil {
+		t.Error("Expected a connector without a scheme to be rejected")
+	}
+}
+
+func TestDockerRegistryCatalog(t *testing.T) {
+	fake, registry := newTestDockerRegistry(t)
+	defer fake.server.Close()
+
+	dummyDockerImage(fake, "dummy/app", "1.0", "layer1")
+	dummyDockerImage(fake, "dummy/app", "1.1", "layer2")
+	dummyDockerImage(fake, "other", "latest", "layer3")
+
+	images, err := registry.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(images, []string{"dummy/app", "other"}) {
+		t.Errorf("Unexpected images %v", images)
+	}
+
+	tags, err := registry.Tags("dummy/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tags, []string{"1.0", "1.1"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+
+	// the token issued for each image is reused
+	if fake.tokens != 2 {
+		t.Errorf("Expected a token for the catalog and for the image but %d were issued", fake.tokens)
+	}
+}
+
+func TestDockerRegistryManifest(t *testing.T) {
+	fake, registry := newTestDockerRegistry(t)
+	defer fake.server.Close()
+
+	expected, digest := dummyDockerImage(fake, "dummy/app", "1.0", "layer1")
+	arm, armDigest := dummyDockerImage(fake, "dummy/app", "", "layer-arm")
+
+	index := DockerManifest{SchemaVersion: 2, MediaType: OCIIndex, Manifests: []DockerDescriptor{
+		{MediaType: DockerManifestV2, Digest: digest, Platform: &DockerPlatform{Architecture: "amd64", OS: "linux"}},
+		{MediaType: DockerManifestV2, Digest: armDigest, Platform: &DockerPlatform{Architecture: "arm64", OS: "linux", Variant: "v8"}},
+	}}
+	indexDigest := fake.addManifest("dummy/app", "multi", OCIIndex, index)
+
+	m, err := registry.Manifest("dummy/app", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Digest != digest || m.IsIndex() || !reflect.DeepEqual(m.Layers, expected.Layers) || !reflect.DeepEqual(m.Config, expected.Config) {
+		t.Errorf("Unexpected manifest %v", m)
+	}
+
+	m, err = registry.Manifest("dummy/app", "multi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Digest != indexDigest || !m.IsIndex() || len(m.Manifests) != 2 || m.Manifests[1].Platform.Variant != "v8" {
+		t.Errorf("Unexpected index %v", m)
+	}
+
+	if m, err = registry.Manifest("dummy/app", armDigest); err != nil || m.Layers[0].Digest != arm.Layers[0].Digest {
+		t.Errorf("Unexpected manifest by digest %v: %v", m, err)
+	}
+
+	// manifests which do not match the digest they were requested by are rejected
+	fake.manifests["dummy/app"][armDigest] = fake.manifests["dummy/app"][digest]
+	if _, err = registry.Manifest("dummy/app", armDigest); !errors.As(err, &ChecksumMismatchError{}) {
+		t.Errorf("Expected a checksum mismatch but got %v", err)
+	}
+
+	if _, err = registry.Manifest("dummy/app", "2.0"); err == nil || !strings.Contains(err.Error(), "MANIFEST_UNKNOWN") {
+		t.Errorf("Expected an unknown manifest but got %v", err)
+	}
+}
+
+func TestDockerRegistryDeleteManifest(t *testing.T) {
+	fake, registry := newTestDockerRegistry(t)
+	defer fake.server.Close()
+
+	_, digest := dummyDockerImage(fake, "dummy/app", "1.0", "layer1")
+	dummyDockerImage(fake, "dummy/app", "1.1", "layer2")
+
+	if err := registry.DeleteManifest("dummy/app", "1.0"); err == nil {
+		t.Error("Expected manifests not to be deleted by tag")
+	}
+
+	if err := registry.DeleteManifest("dummy/app", digest); err != nil {
+		t.Fatal(err)
+	}
+
+	if tags, _ := registry.Tags("dummy/app"); !reflect.DeepEqual(tags, []string{"1.1"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+}
+
+func TestDockerRegistryBlobs(t *testing.T) {
+	fake, registry := newTestDockerRegistry(t)
+	defer fake.server.Close()
+
+	content := []byte("dummy layer")
+	digest, err := registry.PushBlob("dummy/app", "", bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest != sha256Digest(content) || !bytes.Equal(fake.blobs[digest], content) {
+		t.Errorf("Unexpected blob %s", digest)
+	}
+
+	if exists, err := registry.BlobExists("dummy/app", digest); err != nil || !exists {
+		t.Errorf("Expected blob to exist: %v", err)
+	}
+
+	// blobs which are already in the registry are not uploaded again
+	uploads := len(fake.uploads)
+	if _, err = registry.PushBlob("dummy/app", digest, bytes.NewReader(content)); err != nil || len(fake.uploads) != uploads {
+		t.Errorf("Unexpected upload: %v", err)
+	}
+
+	if _, err = registry.PushBlob("dummy/app", sha256Digest([]byte("other")), bytes.NewReader(content)); !errors.As(err, &ChecksumMismatchError{}) {
+		t.Errorf("Expected a checksum mismatch but got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := registry.PullBlob("dummy/app", digest, &buf); err != nil || !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("Unexpected pulled blob: %v", err)
+	}
+
+	fake.blobs[digest] = []byte("tampered")
+	if err := registry.PullBlob("dummy/app", digest, ioutil.Discard); !errors.As(err, &ChecksumMismatchError{}) {
+		t.Errorf("Expected a checksum mismatch but got %v", err)
+	}
+}
+
+func TestCopyDockerImage(t *testing.T) {
+	src, srcRegistry := newTestDockerRegistry(t)
+	defer src.server.Close()
+
+	dst, dstRegistry := newTestDockerRegistry(t)
+	defer dst.server.Close()
+
+	amd, amdDigest := dummyDockerImage(src, "dummy/app", "", "layer-amd")
+	arm, armDigest := dummyDockerImage(src, "dummy/app", "", "layer-arm")
+	indexDigest := src.addManifest("dummy/app", "1.0", OCIIndex, DockerManifest{SchemaVersion: 2, MediaType: OCIIndex, Manifests: []DockerDescriptor{
+		{MediaType: DockerManifestV2, Digest: amdDigest, Platform: &DockerPlatform{Architecture: "amd64", OS: "linux"}},
+		{MediaType: DockerManifestV2, Digest: armDigest, Platform: &DockerPlatform{Architecture: "arm64", OS: "linux"}},
+	}})
+
+	digest, err := CopyDockerImage(srcRegistry, "dummy/app", "1.0", dstRegistry, "promoted/app", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest != indexDigest {
+		t.Errorf("Expected digest %s but got %s", indexDigest, digest)
+	}
+
+	for _, m := range []DockerManifest{amd, arm} {
+		for _, blob := range append(m.Layers, *m.Config) {
+			if !bytes.Equal(dst.blobs[blob.Digest], src.blobs[blob.Digest]) {
+				t.Errorf("Blob %s not copied", blob.Digest)
+			}
+		}
+	}
+
+	m, err := dstRegistry.Manifest("promoted/app", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Digest != indexDigest || !m.IsIndex() {
+		t.Errorf("Unexpected copied manifest %v", m)
+	}
+
+	if _, err := dstRegistry.Manifest("promoted/app", amdDigest); err != nil {
+		t.Errorf("Expected platform manifest to be copied: %v", err)
+	}
+
+	// copying again only uploads the manifests
+	uploads := len(dst.uploads)
+	if _, err = CopyDockerImage(srcRegistry, "dummy/app", "1.0", dstRegistry, "promoted/app", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst.uploads) != uploads {
+		t.Errorf("Expected no blob to be uploaded again but %d were", len(dst.uploads)-uploads)
+	}
+}
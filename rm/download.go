@@ -0,0 +1,311 @@
+package nexusrm
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	nexus "github.com/overag3/gonexus"
+)
+
+const partialDownloadSuffix = ".part"
+
+// ChecksumMismatchError indicates that downloaded content does not match the checksum RM has for it
+type ChecksumMismatchError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s but got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+type checksumVerifier struct {
+	expected map[string]string
+	hashes   map[string]hash.Hash
+	writer   io.Writer
+}
+
+// newChecksumVerifier hashes the content written to it with each algorithm RM provided a checksum for
+func newChecksumVerifier(checksum repositoryItemAssetsChecksum) *checksumVerifier {
+	v := &checksumVerifier{
+		expected: make(map[string]string),
+		hashes:   make(map[string]hash.Hash),
+	}
+
+	add := func(algorithm, expected string, h hash.Hash) {
+		if expected != "" {
+			v.expected[algorithm] = strings.ToLower(expected)
+			v.hashes[algorithm] = h
+		}
+	}
+	add("sha1", checksum.Sha1, sha1.New())
+	add("md5", checksum.Md5, md5.New())
+	add("sha256", checksum.Sha256, sha256.New())
+	add("sha512", checksum.Sha512, sha512.New())
+
+	writers := make([]io.Writer, 0, len(v.hashes))
+	for _, h := range v.hashes {
+		writers = append(writers, h)
+	}
+	v.writer = io.MultiWriter(writers...)
+
+	return v
+}
+
+func (v *checksumVerifier) Write(p []byte) (int, error) {
+	return v.writer.Write(p)
+}
+
+func (v *checksumVerifier) verify() error {
+	for _, algorithm := range []string{"sha1", "md5", "sha256", "sha512"} {
+		h, ok := v.hashes[algorithm]
+		if !ok {
+			continue
+		}
+
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != v.expected[algorithm] {
+			return ChecksumMismatchError{algorithm, v.expected[algorithm], actual}
+		}
+	}
+	return nil
+}
+
+// assetEndpoint returns the endpoint, relative to the RM host, from which the asset can be downloaded
+func assetEndpoint(rm RM, asset RepositoryItemAsset) string {
+	if host := strings.TrimSuffix(rm.Info().Host, "/") + "/"; strings.HasPrefix(asset.DownloadURL, host) {
+		return strings.TrimPrefix(asset.DownloadURL, host)
+	}
+	return fmt.Sprintf("repository/%s/%s", asset.Repository, strings.TrimPrefix(asset.Path, "/"))
+}
+
+// streamAsset requests the asset starting at the given offset.
+// Returns the response body and whether RM honored the offset. The offset is ignored unless the client
+// is a nexus.Streamer, as the body of a partial response is otherwise discarded.
+func streamAsset(rm RM, asset RepositoryItemAsset, offset int64) (io.ReadCloser, bool, error) {
+	req, err := rm.NewRequest(http.MethodGet, assetEndpoint(rm, asset), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, ok := rm.(nexus.Streamer); ok && offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, false, nil
+	case http.StatusPartialContent:
+		return resp.Body, true, nil
+	default:
+		resp.Body.Close()
+		return nil, false, errors.New(resp.Status)
+	}
+}
+
+// DownloadAsset streams the content of the asset into the given writer while verifying it
+// against every checksum RM has for the asset. A ChecksumMismatchError is returned if verification fails,
+// in which case the content has nonetheless been written.
+func DownloadAsset(rm RM, asset RepositoryItemAsset, w io.Writer) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not download asset '%s': %w", asset.Path, err)
+	}
+
+	body, _, err := streamAsset(rm, asset, 0)
+	if err != nil {
+		return doError(err)
+	}
+	defer body.Close()
+
+	verifier := newChecksumVerifier(asset.Checksum)
+	if _, err = io.Copy(io.MultiWriter(w, verifier), body); err != nil {
+		return doError(err)
+	}
+
+	if err = verifier.verify(); err != nil {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DownloadAssetToFile downloads the asset into the file at the given path and verifies its checksums.
+// The content is written next to the file with a .part suffix until verified, and an interrupted
+// download resumes from that partial file using a Range request, or starts over if the client is not
+// a nexus.Streamer. If the file already exists with the expected checksums it is left as it is.
+func DownloadAssetToFile(rm RM, asset RepositoryItemAsset, path string) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not download asset '%s' to %s: %w", asset.Path, path, err)
+	}
+
+	if verifyFile(path, asset.Checksum) == nil {
+		return nil
+	}
+
+	partial := path + partialDownloadSuffix
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return doError(err)
+	}
+
+	// hash what was previously downloaded so the complete content can be verified
+	verifier := newChecksumVerifier(asset.Checksum)
+	offset, err := io.Copy(verifier, f)
+	if err != nil {
+		f.Close()
+		return doError(err)
+	}
+
+	body, resumed, err := streamAsset(rm, asset, offset)
+	if err != nil && offset > 0 {
+		// the partial file may be complete already or unusable, so start over
+		offset, resumed = 0, false
+		body, _, err = streamAsset(rm, asset, 0)
+	}
+	if err != nil {
+		f.Close()
+		return doError(err)
+	}
+	defer body.Close()
+
+	if !resumed {
+		verifier = newChecksumVerifier(asset.Checksum)
+		if err = f.Truncate(0); err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			f.Close()
+			return doError(err)
+		}
+	}
+
+	_, err = io.Copy(io.MultiWriter(f, verifier), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return doError(err)
+	}
+
+	if err = verifier.verify(); err != nil {
+		os.Remove(partial)
+		return doError(err)
+	}
+
+	if err = os.Rename(partial, path); err != nil {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// verifyFile checks the content of the file against the given checksums
+func verifyFile(path string, checksum repositoryItemAssetsChecksum) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	verifier := newChecksumVerifier(checksum)
+	if len(verifier.hashes) == 0 {
+		return errors.New("no checksums to verify against")
+	}
+
+	if _, err = io.Copy(verifier, f); err != nil {
+		return err
+	}
+
+	return verifier.verify()
+}
+
+// assetFilePath returns the path under the given directory which mirrors the repository path of the asset
+func assetFilePath(dir string, asset RepositoryItemAsset) (string, error) {
+	rel := filepath.FromSlash(strings.TrimPrefix(asset.Path, "/"))
+	path := filepath.Join(dir, asset.Repository, rel)
+
+	root := filepath.Join(dir, asset.Repository)
+	if rel == "" || !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("asset path '%s' is outside of its repository", asset.Path)
+	}
+
+	return path, nil
+}
+
+// DownloadAssets downloads the given assets into a directory tree which mirrors their repositories
+// and paths, i.e. dir/<repository>/<path>, using the given number of parallel downloads.
+// Each asset is downloaded as with DownloadAssetToFile. The error of each download, if any,
+// is returned keyed by the repository and path of the asset.
+func DownloadAssets(rm RM, assets []RepositoryItemAsset, dir string, parallel int) map[string]error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]error)
+
+	queue := make(chan RepositoryItemAsset)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for asset := range queue {
+				err := downloadAssetTo(rm, asset, dir)
+
+				mu.Lock()
+				results[asset.Repository+"/"+strings.TrimPrefix(asset.Path, "/")] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, asset := range assets {
+		queue <- asset
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+func downloadAssetTo(rm RM, asset RepositoryItemAsset, dir string) error {
+	path, err := assetFilePath(dir, asset)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return DownloadAssetToFile(rm, asset, path)
+}
+
+// DownloadComponents downloads every asset of the given components, such as the results of SearchComponents,
+// in parallel into a directory tree which mirrors their repositories and paths. See DownloadAssets.
+func DownloadComponents(rm RM, components []RepositoryItem, dir string, parallel int) map[string]error {
+	assets := make([]RepositoryItemAsset, 0)
+	for _, c := range components {
+		assets = append(assets, c.Assets...)
+	}
+
+	return DownloadAssets(rm, assets, dir, parallel)
+}
@@ -0,0 +1,265 @@
+package nexusrm
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var dummyDownloads = map[string][]byte{
+	"repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.jar": bytes.Repeat([]byte("jar content "), 1000),
+	"repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.pom": []byte("<project/>"),
+}
+
+func dummyDownloadAsset(rm RM, repoPath string) RepositoryItemAsset {
+	content := dummyDownloads[repoPath]
+	sha1sum := sha1.Sum(content)
+	md5sum := md5.Sum(content)
+	sha256sum := sha256.Sum256(content)
+
+	parts := strings.SplitN(repoPath, "/", 2)
+	return RepositoryItemAsset{
+		DownloadURL: fmt.Sprintf("%s/repository/%s", rm.Info().Host, repoPath),
+		Path:        parts[1],
+		Repository:  parts[0],
+		Format:      "maven2",
+		Checksum: repositoryItemAssetsChecksum{
+			Sha1:   hex.EncodeToString(sha1sum[:]),
+			Md5:    hex.EncodeToString(md5sum[:]),
+			Sha256: hex.EncodeToString(sha256sum[:]),
+		},
+	}
+}
+
+func downloadTestRM(t *testing.T, ranges *[]string) (rm RM, mock *httptest.Server) {
+	var mu sync.Mutex
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		content, ok := dummyDownloads[strings.TrimPrefix(r.URL.Path, "/repository/")]
+		if !ok || r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if rng := r.Header.Get("Range"); rng != "" && ranges != nil {
+			mu.Lock()
+			*ranges = append(*ranges, rng)
+			mu.Unlock()
+		}
+
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	})
+}
+
+func TestDownloadAsset(t *testing.T) {
+	rm, mock := downloadTestRM(t, nil)
+	defer mock.Close()
+
+	repoPath := "repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.jar"
+	asset := dummyDownloadAsset(rm, repoPath)
+
+	var buf bytes.Buffer
+	if err := DownloadAsset(rm, asset, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), dummyDownloads[repoPath]) {
+		t.Error("Downloaded content does not match")
+	}
+
+	asset.Checksum.Sha256 = strings.Repeat("0", 64)
+	err := DownloadAsset(rm, asset, ioutil.Discard)
+
+	var mismatch ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Algorithm != "sha256" {
+		t.Errorf("Expected sha256 mismatch but got %v", err)
+	}
+
+	asset.Path = "nope.jar"
+	asset.DownloadURL = ""
+	if err := DownloadAsset(rm, asset, ioutil.Discard); err == nil {
+		t.Error("Expected error downloading missing asset")
+	}
+}
+
+func TestDownloadAssetToFileResumes(t *testing.T) {
+	var ranges []string
+	rm, mock := downloadTestRM(t, &ranges)
+	defer mock.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repoPath := "repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.jar"
+	content := dummyDownloads[repoPath]
+	asset := dummyDownloadAsset(rm, repoPath)
+
+	path := filepath.Join(dir, "artifact.jar")
+	if err = ioutil.WriteFile(path+partialDownloadSuffix, content[:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DownloadAssetToFile(rm, asset, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "bytes=100-" {
+		t.Errorf("Expected download to resume from byte 100: %v", ranges)
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, content) {
+		t.Error("Downloaded file does not match")
+	}
+
+	if _, err = os.Stat(path + partialDownloadSuffix); !os.IsNotExist(err) {
+		t.Error("Partial download not cleaned up")
+	}
+
+	// a verified file is not downloaded again
+	mock.Close()
+	if err = DownloadAssetToFile(rm, asset, path); err != nil {
+		t.Errorf("Expected existing file to be kept: %v", err)
+	}
+}
+
+func TestDownloadAssetToFileRestartsWithoutStreamer(t *testing.T) {
+	var ranges []string
+	streamer, mock := downloadTestRM(t, &ranges)
+	defer mock.Close()
+
+	// hides the Stream method of the client
+	rm := struct{ RM }{streamer}
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repoPath := "repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.jar"
+	content := dummyDownloads[repoPath]
+
+	path := filepath.Join(dir, "artifact.jar")
+	if err = ioutil.WriteFile(path+partialDownloadSuffix, content[:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DownloadAssetToFile(rm, dummyDownloadAsset(rm, repoPath), path); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 0 {
+		t.Errorf("Expected download to start over without a range: %v", ranges)
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, content) {
+		t.Error("Downloaded file does not match")
+	}
+}
+
+func TestDownloadAssetToFileCorruptPartial(t *testing.T) {
+	rm, mock := downloadTestRM(t, nil)
+	defer mock.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repoPath := "repo-maven/org/test/artifact/1.0.0/artifact-1.0.0.pom"
+	asset := dummyDownloadAsset(rm, repoPath)
+
+	path := filepath.Join(dir, "artifact.pom")
+	if err = ioutil.WriteFile(path+partialDownloadSuffix, []byte("<garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DownloadAssetToFile(rm, asset, path); err == nil {
+		t.Fatal("Expected checksum mismatch resuming from a corrupt partial download")
+	}
+
+	// the corrupt partial download is discarded so a retry starts over
+	if err = DownloadAssetToFile(rm, asset, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadComponents(t *testing.T) {
+	rm, mock := downloadTestRM(t, nil)
+	defer mock.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	component := RepositoryItem{Repository: "repo-maven", Format: "maven2"}
+	for repoPath := range dummyDownloads {
+		component.Assets = append(component.Assets, dummyDownloadAsset(rm, repoPath))
+	}
+
+	results := DownloadComponents(rm, []RepositoryItem{component}, dir, 2)
+	if len(results) != len(dummyDownloads) {
+		t.Errorf("Expected %d results but got %v", len(dummyDownloads), results)
+	}
+
+	for repoPath, content := range dummyDownloads {
+		if err := results[repoPath]; err != nil {
+			t.Errorf("Could not download %s: %v", repoPath, err)
+			continue
+		}
+
+		downloaded, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(repoPath)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !bytes.Equal(downloaded, content) {
+			t.Errorf("Content of %s does not match", repoPath)
+		}
+	}
+}
+
+func TestDownloadAssetsOutsideRepository(t *testing.T) {
+	rm, mock := downloadTestRM(t, nil)
+	defer mock.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	asset := RepositoryItemAsset{Repository: "repo-maven", Path: "../../escape"}
+	if results := DownloadAssets(rm, []RepositoryItemAsset{asset}, dir, 1); results["repo-maven/../../escape"] == nil {
+		t.Errorf("Expected error downloading asset outside of its repository: %v", results)
+	}
+}
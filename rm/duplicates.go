@@ -0,0 +1,217 @@
+package nexusrm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DuplicateCopy is one of the locations of duplicated content
+type DuplicateCopy struct {
+	Asset RepositoryItemAsset
+	// Groups are the group repositories through which the copy is available, including nested groups
+	Groups       []string
+	SafeToDelete bool
+	Reason       string
+}
+
+// DuplicateSet is content which is stored as more than one asset
+type DuplicateSet struct {
+	Checksum string // the sha1 of the content, or its sha256 if RM did not provide a sha1
+	Size     int64
+	Copies   []DuplicateCopy
+}
+
+// WastedBytes returns the storage used by every copy beyond the first
+func (s DuplicateSet) WastedBytes() int64 {
+	return s.Size * int64(len(s.Copies)-1)
+}
+
+// ReclaimableBytes returns the storage used by the copies which are safe to delete
+func (s DuplicateSet) ReclaimableBytes() int64 {
+	var n int64
+	for _, c := range s.Copies {
+		if c.SafeToDelete {
+			n += s.Size
+		}
+	}
+	return n
+}
+
+// DuplicatesReport lists the content stored more than once across repositories
+type DuplicatesReport struct {
+	Sets             []DuplicateSet
+	WastedBytes      int64
+	ReclaimableBytes int64
+}
+
+// FindAssetCopies returns every asset with the given sha1 checksum, using the search API
+func FindAssetCopies(rm RM, sha1 string) ([]RepositoryItemAsset, error) {
+	assets, err := SearchAssets(rm, NewSearchQueryBuilder().Sha1(sha1))
+	if err != nil {
+		return nil, fmt.Errorf("could not find copies of %s: %v", sha1, err)
+	}
+
+	return assets, nil
+}
+
+// FindDuplicateAssets indexes the assets of the selected hosted and proxy repositories by checksum
+// and reports the content stored more than once. A nil filter selects every repository.
+//
+// For each set of copies, one is kept and every other copy is proposed for deletion if
+// deleting it does not change what can be downloaded through group repositories: a copy is
+// safe to delete if a kept copy has the same path and is available through every group the
+// copy is available through. Copies in proxy repositories are always safe to delete, as they
+// are fetched again from the remote on demand. Copies can still be downloaded from their own
+// repository until deleted, so check for clients which do not use group repositories.
+func FindDuplicateAssets(rm RM, filter RepositoryFilter) (DuplicatesReport, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find duplicate assets: %v", err)
+	}
+
+	var report DuplicatesReport
+
+	settings, err := GetRepositorySettings(rm)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	types := make(map[string]string)
+	groups := make(map[string][]string)
+	for _, s := range settings {
+		types[s.Name] = s.Type
+		if s.Type == "group" {
+			groups[s.Name] = s.Group.MemberNames
+		}
+	}
+
+	// the groups through which each repository is available
+	memberOf := make(map[string]map[string]bool)
+	for group := range groups {
+		for _, member := range groupMembers(groups, group) {
+			if memberOf[member] == nil {
+				memberOf[member] = make(map[string]bool)
+			}
+			memberOf[member][group] = true
+		}
+	}
+
+	index := make(map[string][]RepositoryItemAsset)
+	order := make([]string, 0)
+	for _, s := range settings {
+		repo := Repository{Name: s.Name, Format: s.Format, Type: s.Type, URL: s.URL}
+		if repo.Type == "group" || (filter != nil && !filter(repo)) {
+			continue
+		}
+
+		assets, err := GetAssets(rm, repo.Name)
+		if err != nil {
+			return report, doError(err)
+		}
+
+		for _, a := range assets {
+			key := a.Checksum.Sha1
+			if key == "" {
+				key = a.Checksum.Sha256
+			}
+			if key == "" {
+				continue
+			}
+
+			if _, ok := index[key]; !ok {
+				order = append(order, key)
+			}
+			index[key] = append(index[key], a)
+		}
+	}
+
+	for _, key := range order {
+		assets := index[key]
+		if len(assets) < 2 {
+			continue
+		}
+
+		set := DuplicateSet{Checksum: key}
+		for _, a := range assets {
+			if a.FileSize > set.Size {
+				set.Size = a.FileSize
+			}
+		}
+		set.Copies = proposeDuplicateDeletions(assets, types, memberOf)
+
+		report.Sets = append(report.Sets, set)
+		report.WastedBytes += set.WastedBytes()
+		report.ReclaimableBytes += set.ReclaimableBytes()
+	}
+
+	sort.SliceStable(report.Sets, func(i, j int) bool {
+		return report.Sets[i].WastedBytes() > report.Sets[j].WastedBytes()
+	})
+
+	return report, nil
+}
+
+func proposeDuplicateDeletions(assets []RepositoryItemAsset, types map[string]string, memberOf map[string]map[string]bool) []DuplicateCopy {
+	copies := make([]DuplicateCopy, len(assets))
+	for i, a := range assets {
+		copies[i] = DuplicateCopy{Asset: a, Groups: make([]string, 0)}
+		for g := range memberOf[a.Repository] {
+			copies[i].Groups = append(copies[i].Groups, g)
+		}
+		sort.Strings(copies[i].Groups)
+	}
+
+	// prefer keeping hosted copies which are available through the most groups, then the oldest
+	sort.SliceStable(copies, func(i, j int) bool {
+		a, b := copies[i], copies[j]
+		if hostedA, hostedB := types[a.Asset.Repository] != "proxy", types[b.Asset.Repository] != "proxy"; hostedA != hostedB {
+			return hostedA
+		}
+		if len(a.Groups) != len(b.Groups) {
+			return len(a.Groups) > len(b.Groups)
+		}
+		if !a.Asset.BlobCreated.Equal(b.Asset.BlobCreated) {
+			return a.Asset.BlobCreated.Before(b.Asset.BlobCreated)
+		}
+		return a.Asset.Repository < b.Asset.Repository
+	})
+
+	covers := func(kept, c DuplicateCopy) bool {
+		if kept.Asset.Path != c.Asset.Path {
+			return false
+		}
+		for _, g := range c.Groups {
+			if !memberOf[kept.Asset.Repository][g] {
+				return false
+			}
+		}
+		return true
+	}
+
+	kept := make([]DuplicateCopy, 0)
+	for i, c := range copies {
+		switch {
+		case i == 0:
+			copies[i].Reason = "kept as the preferred copy"
+		case types[c.Asset.Repository] == "proxy":
+			copies[i].SafeToDelete = true
+			copies[i].Reason = "cached by a proxy repository"
+		default:
+			for _, k := range kept {
+				if covers(k, c) {
+					copies[i].SafeToDelete = true
+					copies[i].Reason = fmt.Sprintf("also available as %s/%s", k.Asset.Repository, k.Asset.Path)
+					break
+				}
+			}
+			if !copies[i].SafeToDelete {
+				copies[i].Reason = "no other copy is available at the same path through the same groups"
+			}
+		}
+
+		if !copies[i].SafeToDelete {
+			kept = append(kept, copies[i])
+		}
+	}
+
+	return copies
+}
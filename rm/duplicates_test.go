@@ -0,0 +1,118 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func duplicatesInventory() inventory {
+	asset := func(repo, path, sha1 string, size int64) RepositoryItemAsset {
+		a := inventoryAsset(repo, "maven2", path, size, time.Time{})
+		a.Checksum.Sha1 = sha1
+		return a
+	}
+
+	return inventory{
+		repos: []RepositorySettings{
+			inventoryRepository("releases-a", "maven2", "hosted", "default"),
+			inventoryRepository("releases-b", "maven2", "hosted", "default"),
+			inventoryRepository("central", "maven2", "proxy", "default"),
+			inventoryRepository("public", "maven2", "group", "default", "releases-a", "releases-b", "central"),
+			inventoryRepository("team", "maven2", "group", "default", "releases-b"),
+		},
+		assets: []RepositoryItemAsset{
+			asset("releases-a", "org/test/lib/1.0/lib-1.0.jar", "aaa", 100),
+			asset("releases-b", "org/test/lib/1.0/lib-1.0.jar", "aaa", 100),
+			asset("central", "org/test/lib/1.0/lib-1.0.jar", "aaa", 100),
+			asset("releases-a", "org/test/lib/2.0/lib-2.0.jar", "bbb", 50),
+			asset("releases-b", "com/copy/lib/2.0/lib-2.0.jar", "bbb", 50),
+			asset("releases-a", "org/test/unique/1.0/unique-1.0.jar", "ccc", 10),
+		},
+	}
+}
+
+func TestFindDuplicateAssets(t *testing.T) {
+	rm, mock := newTestRM(t, inventoryTestFunc(duplicatesInventory()))
+	defer mock.Close()
+
+	report, err := FindDuplicateAssets(rm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Sets) != 2 {
+		t.Fatalf("Expected 2 duplicate sets but got %v", report.Sets)
+	}
+
+	if report.WastedBytes != 250 || report.ReclaimableBytes != 200 {
+		t.Errorf("Expected 250 wasted and 200 reclaimable bytes but got %d and %d", report.WastedBytes, report.ReclaimableBytes)
+	}
+
+	same := report.Sets[0]
+	if same.Checksum != "aaa" || len(same.Copies) != 3 {
+		t.Fatalf("Unexpected duplicate set: %v", same)
+	}
+
+	expected := []struct {
+		repo string
+		safe bool
+	}{{"releases-b", false}, {"releases-a", true}, {"central", true}}
+	for i, e := range expected {
+		if c := same.Copies[i]; c.Asset.Repository != e.repo || c.SafeToDelete != e.safe {
+			t.Errorf("Expected copy in %s to be safe to delete %v: %v", e.repo, e.safe, c)
+		}
+	}
+
+	moved := report.Sets[1]
+	if moved.Checksum != "bbb" || moved.ReclaimableBytes() != 0 {
+		t.Errorf("Expected copies at different paths to be kept: %v", moved)
+	}
+}
+
+func TestFindDuplicateAssetsFiltered(t *testing.T) {
+	rm, mock := newTestRM(t, inventoryTestFunc(duplicatesInventory()))
+	defer mock.Close()
+
+	report, err := FindDuplicateAssets(rm, RepositoriesByType("proxy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Sets) != 0 {
+		t.Errorf("Expected no duplicates within a single repository: %v", report.Sets)
+	}
+}
+
+func TestFindAssetCopies(t *testing.T) {
+	inv := duplicatesInventory()
+	inventoryHandler := inventoryTestFunc(inv)
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path[1:] != restSearchAssets {
+			inventoryHandler(t, w, r)
+			return
+		}
+
+		resp := searchAssetsResponse{Items: make([]RepositoryItemAsset, 0)}
+		for _, a := range inv.assets {
+			if a.Checksum.Sha1 == r.URL.Query().Get("sha1") {
+				resp.Items = append(resp.Items, a)
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatal(err)
+		}
+	})
+	defer mock.Close()
+
+	copies, err := FindAssetCopies(rm, "bbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(copies) != 2 {
+		t.Errorf("Expected 2 copies but got %v", copies)
+	}
+}
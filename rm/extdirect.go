@@ -0,0 +1,62 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Some functionality of RM, such as verifying LDAP settings, is only available through the
+// Ext.Direct RPC endpoint used by the UI rather than the REST API.
+const restExtDirect = "service/extdirect"
+
+type extDirectRequest struct {
+	Action string        `json:"action"`
+	Method string        `json:"method"`
+	Data   []interface{} `json:"data"`
+	Type   string        `json:"type"`
+	TID    int           `json:"tid"`
+}
+
+type extDirectResponse struct {
+	TID     int    `json:"tid"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Result  struct {
+		Success bool            `json:"success"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	} `json:"result"`
+}
+
+func extDirect(rm RM, action, method string, data ...interface{}) (json.RawMessage, error) {
+	buf, err := json.Marshal(extDirectRequest{
+		Action: action,
+		Method: method,
+		Data:   data,
+		Type:   "rpc",
+		TID:    1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := rm.Post(restExtDirect, bytes.NewBuffer(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp extDirectResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.Type == "exception":
+		return nil, fmt.Errorf("%s.%s failed: %s", action, method, resp.Message)
+	case !resp.Result.Success:
+		return nil, fmt.Errorf("%s.%s failed: %s", action, method, resp.Result.Message)
+	}
+
+	return resp.Result.Data, nil
+}
@@ -0,0 +1,266 @@
+package nexusrm
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRepositoryManager is an in-memory RM which stores uploaded content
+type fakeRepositoryManager struct {
+	mu         sync.Mutex
+	repos      []RepositorySettings
+	components []RepositoryItem
+	content    map[string][]byte // keyed by repository/path
+	tags       map[string]bool
+	uploads    []string // repository/path of uploaded assets
+	// fields of each upload through the components API
+	uploadFields []map[string]string
+	// coordinates of content uploaded through the components API, keyed by its sha1
+	uploadCoordinates map[string]RepositoryItem
+	rm                RM
+	server            *httptest.Server
+}
+
+func newFakeRepositoryManager(t *testing.T, repos ...RepositorySettings) *fakeRepositoryManager {
+	f := &fakeRepositoryManager{
+		repos:             repos,
+		content:           make(map[string][]byte),
+		tags:              make(map[string]bool),
+		uploadCoordinates: make(map[string]RepositoryItem),
+	}
+	f.rm, f.server = newTestRM(t, f.handle)
+	return f
+}
+
+func (f *fakeRepositoryManager) Close() {
+	f.server.Close()
+}
+
+func fakeChecksum(content []byte) repositoryItemAssetsChecksum {
+	s := sha1.Sum(content)
+	m := md5.Sum(content)
+	return repositoryItemAssetsChecksum{Sha1: hex.EncodeToString(s[:]), Md5: hex.EncodeToString(m[:])}
+}
+
+func (f *fakeRepositoryManager) format(repo string) string {
+	for _, r := range f.repos {
+		if r.Name == repo {
+			return r.Format
+		}
+	}
+	return ""
+}
+
+// addAsset stores the content as an asset of the identified component, creating the component if needed
+func (f *fakeRepositoryManager) addAsset(repo, group, name, version, assetPath string, content []byte, tags ...string) {
+	asset := RepositoryItemAsset{
+		ID:          repo + "/" + assetPath,
+		DownloadURL: fmt.Sprintf("%s/repository/%s/%s", f.rm.Info().Host, repo, assetPath),
+		Path:        assetPath,
+		Repository:  repo,
+		Format:      f.format(repo),
+		Checksum:    fakeChecksum(content),
+		FileSize:    int64(len(content)),
+	}
+	f.content[repo+"/"+assetPath] = content
+
+	for i, c := range f.components {
+		if c.Repository == repo && c.Group == group && c.Name == name && c.Version == version {
+			for j, a := range c.Assets {
+				if a.Path == assetPath {
+					f.components[i].Assets[j] = asset
+					return
+				}
+			}
+			f.components[i].Assets = append(f.components[i].Assets, asset)
+			f.components[i].Tags = append(f.components[i].Tags, tags...)
+			return
+		}
+	}
+
+	f.components = append(f.components, RepositoryItem{
+		ID:         fmt.Sprintf("%s/%s:%s:%s", repo, group, name, version),
+		Repository: repo,
+		Format:     asset.Format,
+		Group:      group,
+		Name:       name,
+		Version:    version,
+		Assets:     []RepositoryItemAsset{asset},
+		Tags:       append([]string{}, tags...),
+	})
+}
+
+// coordinatesOfPath infers the component of an asset uploaded directly to a path
+func coordinatesOfPath(format, assetPath string) (group, name, version string) {
+	if format == "maven2" {
+		parts := strings.Split(assetPath, "/")
+		if len(parts) >= 4 {
+			return strings.Join(parts[:len(parts)-3], "."), parts[len(parts)-3], parts[len(parts)-2]
+		}
+	}
+	return path.Dir(assetPath), path.Base(assetPath), ""
+}
+
+func (f *fakeRepositoryManager) handle(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	endpoint := r.URL.Path[1:]
+	repo := r.URL.Query().Get("repository")
+
+	encode := func(v interface{}) {
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodGet && endpoint == restRepositories:
+		repos := make([]Repository, 0)
+		for _, s := range f.repos {
+			repos = append(repos, Repository{Name: s.Name, Format: s.Format, Type: s.Type})
+		}
+		encode(repos)
+	case r.Method == http.MethodGet && endpoint == restRepositorySettings:
+		encode(f.repos)
+	case r.Method == http.MethodGet && endpoint == restComponents:
+		components := make([]RepositoryItem, 0)
+		for _, c := range f.components {
+			if c.Repository == repo {
+				components = append(components, c)
+			}
+		}
+		encode(listComponentsResponse{Items: components})
+	case r.Method == http.MethodGet && endpoint == restAssets:
+		assets := make([]RepositoryItemAsset, 0)
+		for _, c := range f.components {
+			if c.Repository == repo {
+				assets = append(assets, c.Assets...)
+			}
+		}
+		encode(listAssetsResponse{Items: assets})
+	case r.Method == http.MethodPost && endpoint == restComponents:
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fields := make(map[string]string)
+		defer func() { f.uploadFields = append(f.uploadFields, fields) }()
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the upload writers do not always name the files, so assets are recognized by their field
+			field := part.FormName()
+			content, err := ioutil.ReadAll(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(field, "asset") || strings.Count(field, ".") > 1 {
+				fields[field] = string(content)
+				continue
+			}
+
+			c, ok := f.uploadCoordinates[fakeChecksum(content).Sha1]
+			if !ok {
+				t.Errorf("Unexpected upload of %s", field)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			f.addAsset(repo, c.Group, c.Name, c.Version, c.Assets[0].Path, content)
+			f.uploads = append(f.uploads, repo+"/"+c.Assets[0].Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && strings.HasPrefix(endpoint, restComponents+"/"):
+		id := strings.TrimPrefix(endpoint, restComponents+"/")
+		for i, c := range f.components {
+			if c.ID == id {
+				for _, a := range c.Assets {
+					delete(f.content, c.Repository+"/"+a.Path)
+				}
+				f.components = append(f.components[:i], f.components[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet && strings.HasPrefix(endpoint, restTagging+"/"):
+		if !f.tags[path.Base(endpoint)] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		encode(Tag{Name: path.Base(endpoint)})
+	case r.Method == http.MethodPost && endpoint == restTagging:
+		var tag Tag
+		if err := json.NewDecoder(r.Body).Decode(&tag); err != nil || f.tags[tag.Name] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.tags[tag.Name] = true
+		encode(tag)
+	case r.Method == http.MethodPost && strings.HasPrefix(endpoint, path.Dir(restTaggingAssociate)):
+		tag := path.Base(endpoint)
+		if !f.tags[tag] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		q := r.URL.Query()
+		for i, c := range f.components {
+			if c.Repository == repo && c.Name == q.Get("name") && c.Group == q.Get("group") && c.Version == q.Get("version") {
+				f.components[i].Tags = append(f.components[i].Tags, tag)
+			}
+		}
+		encode(associateResponse{Status: 200})
+	case strings.HasPrefix(endpoint, "repository/"):
+		parts := strings.SplitN(strings.TrimPrefix(endpoint, "repository/"), "/", 2)
+		if len(parts) != 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := f.content[parts[0]+"/"+parts[1]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(content)
+		case http.MethodPut:
+			content, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			group, name, version := coordinatesOfPath(f.format(parts[0]), parts[1])
+			f.addAsset(parts[0], group, name, version, parts[1], content)
+			f.uploads = append(f.uploads, parts[0]+"/"+parts[1])
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
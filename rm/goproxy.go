@@ -0,0 +1,327 @@
+package nexusrm
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	nexus "github.com/overag3/gonexus"
+	"github.com/overag3/gonexus/versions"
+)
+
+const goModHashSuffix = "/go.mod"
+
+// GoModuleInfo is the metadata of a version of a Go module
+type GoModuleInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// GoSum holds the hashes of a go.sum file, by module path and version as the go command writes them,
+// such as "example.com/mod v1.0.0" for the hash of the zip of a module version and
+// "example.com/mod v1.0.0/go.mod" for the hash of its go.mod file
+type GoSum map[string]string
+
+// ParseGoSum reads a go.sum file
+func ParseGoSum(r io.Reader) (GoSum, error) {
+	sum := make(GoSum)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid go.sum line %d", n)
+		}
+		sum[fields[0]+" "+fields[1]] = fields[2]
+	}
+
+	return sum, scanner.Err()
+}
+
+// ZipHash returns the hash of the zip of a module version, if the go.sum file has it
+func (s GoSum) ZipHash(module, version string) (string, bool) {
+	h, ok := s[module+" "+version]
+	return h, ok
+}
+
+// ModHash returns the hash of the go.mod file of a module version, if the go.sum file has it
+func (s GoSum) ModHash(module, version string) (string, bool) {
+	h, ok := s[module+" "+version+goModHashSuffix]
+	return h, ok
+}
+
+// GoModuleEscapePath escapes a module path or version for the GOPROXY protocol, where every upper-case
+// letter is replaced by an exclamation mark followed by the letter in lower case
+func GoModuleEscapePath(p string) (string, error) {
+	var buf strings.Builder
+	for _, r := range p {
+		switch {
+		case r == '!' || r >= utf8.RuneSelf:
+			return "", fmt.Errorf("invalid module path or version '%s'", p)
+		case 'A' <= r && r <= 'Z':
+			buf.WriteByte('!')
+			buf.WriteRune(r + 'a' - 'A')
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String(), nil
+}
+
+// GoModuleUnescapePath reverses GoModuleEscapePath
+func GoModuleUnescapePath(escaped string) (string, error) {
+	var buf strings.Builder
+	bang := false
+	for _, r := range escaped {
+		switch {
+		case bang && 'a' <= r && r <= 'z':
+			buf.WriteRune(r + 'A' - 'a')
+			bang = false
+		case bang, 'A' <= r && r <= 'Z':
+			return "", fmt.Errorf("invalid escaped module path or version '%s'", escaped)
+		case r == '!':
+			bang = true
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	if bang {
+		return "", fmt.Errorf("invalid escaped module path or version '%s'", escaped)
+	}
+	return buf.String(), nil
+}
+
+// goProxyEndpoint returns the endpoint of a file of the GOPROXY protocol for a module, such as @v/list,
+// or for a version of a module with an extension such as .info
+func goProxyEndpoint(repo, module, version, file string) (string, error) {
+	escaped, err := GoModuleEscapePath(module)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		return fmt.Sprintf("repository/%s/%s/%s", repo, escaped, file), nil
+	}
+
+	escapedVersion, err := GoModuleEscapePath(version)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("repository/%s/%s/@v/%s%s", repo, escaped, escapedVersion, file), nil
+}
+
+func goProxyGet(rm RM, repo, module, version, file string) ([]byte, error) {
+	endpoint, err := goProxyEndpoint(repo, module, version, file)
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := rm.Get(endpoint)
+	return body, err
+}
+
+// GetGoModuleVersions returns the versions of a module which a go repository lists, ordered as semantic versions
+func GetGoModuleVersions(rm RM, repo, module string) ([]string, error) {
+	body, err := goProxyGet(rm, repo, module, "", "@v/list")
+	if err != nil {
+		return nil, fmt.Errorf("could not list versions of module '%s' in '%s': %v", module, repo, err)
+	}
+
+	vs := strings.Fields(string(body))
+	versions.Sort(versions.SemVer, vs)
+	return vs, nil
+}
+
+func getGoModuleInfo(rm RM, repo, module, version, file string) (GoModuleInfo, error) {
+	var info GoModuleInfo
+
+	body, err := goProxyGet(rm, repo, module, version, file)
+	if err != nil {
+		return info, err
+	}
+
+	err = json.Unmarshal(body, &info)
+	return info, err
+}
+
+// GetGoModuleInfo returns the metadata of a version of a module, which can also be a query such as a branch name
+// which a go proxy resolves to a version
+func GetGoModuleInfo(rm RM, repo, module, version string) (GoModuleInfo, error) {
+	info, err := getGoModuleInfo(rm, repo, module, version, ".info")
+	if err != nil {
+		return info, fmt.Errorf("could not retrieve info of %s@%s from '%s': %v", module, version, repo, err)
+	}
+	return info, nil
+}
+
+// GetGoModuleLatest returns the metadata of the latest version of a module
+func GetGoModuleLatest(rm RM, repo, module string) (GoModuleInfo, error) {
+	info, err := getGoModuleInfo(rm, repo, module, "", "@latest")
+	if err != nil {
+		return info, fmt.Errorf("could not retrieve latest version of module '%s' from '%s': %v", module, repo, err)
+	}
+	return info, nil
+}
+
+// GetGoModuleMod returns the go.mod file of a version of a module. If the go.sum file has its hash, the go.mod file
+// is verified against it and a ChecksumMismatchError is returned if they differ.
+func GetGoModuleMod(rm RM, repo, module, version string, sum GoSum) ([]byte, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not retrieve go.mod of %s@%s from '%s': %w", module, version, repo, err)
+	}
+
+	body, err := goProxyGet(rm, repo, module, version, ".mod")
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	if expected, ok := sum.ModHash(module, version); ok {
+		if actual := HashGoMod(body); actual != expected {
+			return nil, doError(ChecksumMismatchError{"h1", expected, actual})
+		}
+	}
+
+	return body, nil
+}
+
+// hash1 computes the h1: hash of a set of files as the go command does, which is the SHA-256 of a summary
+// listing the SHA-256 of each file followed by its name, in the order of their names
+func hash1(files []string, open func(string) (io.ReadCloser, error)) (string, error) {
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+
+	summary := sha256.New()
+	for _, file := range files {
+		if strings.Contains(file, "\n") {
+			return "", errors.New("file names with new lines cannot be hashed")
+		}
+
+		r, err := open(file)
+		if err != nil {
+			return "", err
+		}
+
+		h := sha256.New()
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(summary, "%x  %s\n", h.Sum(nil), file)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// HashGoMod returns the h1: hash of a go.mod file, as recorded in go.sum files
+func HashGoMod(content []byte) string {
+	h, _ := hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	})
+	return h
+}
+
+// HashGoModuleZip returns the h1: hash of the zip of a module version, as recorded in go.sum files
+func HashGoModuleZip(r io.ReaderAt, size int64) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+
+	files := make([]string, 0, len(zr.File))
+	byName := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		if _, ok := byName[f.Name]; ok {
+			return "", fmt.Errorf("duplicate file '%s' in zip", f.Name)
+		}
+		files = append(files, f.Name)
+		byName[f.Name] = f
+	}
+
+	return hash1(files, func(name string) (io.ReadCloser, error) {
+		return byName[name].Open()
+	})
+}
+
+// DownloadGoModuleZip downloads the zip of a version of a module to a file and returns its h1: hash.
+// If the go.sum file has the hash of the zip, the zip is verified against it and a ChecksumMismatchError
+// is returned if they differ, in which case the file is not created.
+func DownloadGoModuleZip(rm RM, repo, module, version, file string, sum GoSum) (string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not download zip of %s@%s from '%s': %w", module, version, repo, err)
+	}
+
+	endpoint, err := goProxyEndpoint(repo, module, version, ".zip")
+	if err != nil {
+		return "", doError(err)
+	}
+
+	req, err := rm.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return "", doError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", doError(errors.New(resp.Status))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", doError(err)
+	}
+
+	partial := file + partialDownloadSuffix
+	f, err := os.Create(partial)
+	if err != nil {
+		return "", doError(err)
+	}
+	defer os.Remove(partial)
+	defer f.Close()
+
+	size, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	hash, err := HashGoModuleZip(f, size)
+	if err != nil {
+		return "", doError(err)
+	}
+
+	if expected, ok := sum.ZipHash(module, version); ok && hash != expected {
+		return hash, doError(ChecksumMismatchError{"h1", expected, hash})
+	}
+
+	if err := f.Close(); err != nil {
+		return "", doError(err)
+	}
+
+	if err := os.Rename(partial, file); err != nil {
+		return "", doError(err)
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,237 @@
+package nexusrm
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const dummyGoModule = "github.com/Dummy/mod"
+
+func dummyGoModuleZip(t *testing.T, version string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	prefix := dummyGoModule + "@" + version + "/"
+	for name, content := range map[string]string{
+		"go.mod":  "module " + dummyGoModule + "\n",
+		"mod.go":  "package mod\n",
+		"LICENSE": "MIT\n",
+	} {
+		w, err := zw.Create(prefix + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newFakeGoProxy(t *testing.T, zips map[string][]byte) (RM, func()) {
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		prefix := "/repository/go-proxy/github.com/!dummy/mod/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		file := strings.TrimPrefix(r.URL.Path, prefix)
+		switch {
+		case file == "@v/list":
+			fmt.Fprint(w, "v1.10.0\nv1.2.0\nv1.9.0-rc.1\n")
+		case file == "@latest":
+			fmt.Fprint(w, `{"Version":"v1.10.0","Time":"2020-06-01T12:00:00Z"}`)
+		case strings.HasSuffix(file, ".info"):
+			fmt.Fprintf(w, `{"Version":"%s","Time":"2020-05-01T12:00:00Z"}`, strings.TrimSuffix(strings.TrimPrefix(file, "@v/"), ".info"))
+		case strings.HasSuffix(file, ".mod"):
+			fmt.Fprint(w, "module "+dummyGoModule+"\n")
+		case strings.HasSuffix(file, ".zip"):
+			content, ok := zips[strings.TrimSuffix(strings.TrimPrefix(file, "@v/"), ".zip")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	return rm, mock.Close
+}
+
+func TestGoModuleEscapePath(t *testing.T) {
+	tests := []struct {
+		path, escaped string
+	}{
+		{"github.com/Dummy/mod", "github.com/!dummy/mod"},
+		{"github.com/BurntSushi/toml", "github.com/!burnt!sushi/toml"},
+		{"example.com/mod", "example.com/mod"},
+		{"v1.0.0-RC1", "v1.0.0-!r!c1"},
+	}
+
+	for _, test := range tests {
+		escaped, err := GoModuleEscapePath(test.path)
+		if err != nil || escaped != test.escaped {
+			t.Errorf("Expected %s to be escaped as %s but got %s (%v)", test.path, test.escaped, escaped, err)
+		}
+
+		unescaped, err := GoModuleUnescapePath(escaped)
+		if err != nil || unescaped != test.path {
+			t.Errorf("Expected %s to be unescaped as %s but got %s (%v)", escaped, test.path, unescaped, err)
+		}
+	}
+
+	if _, err := GoModuleEscapePath("example.com/m!d"); err == nil {
+		t.Error("Expected exclamation marks to be rejected")
+	}
+
+	for _, invalid := range []string{"github.com/Dummy", "github.com/!", "github.com/!1"} {
+		if _, err := GoModuleUnescapePath(invalid); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestHashGoMod(t *testing.T) {
+	// the hash of golang.org/x/text v0.3.0/go.mod in go.sum files
+	if actual := HashGoMod([]byte("module golang.org/x/text\n")); actual != "h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=" {
+		t.Errorf("Unexpected hash %s", actual)
+	}
+}
+
+func TestParseGoSum(t *testing.T) {
+	sum, err := ParseGoSum(strings.NewReader(`golang.org/x/text v0.3.0 h1:g61tztE5qeGQ89tm6NTjjM9VPIm088od1l6aSorWRWg=
+golang.org/x/text v0.3.0/go.mod h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=
+
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h, ok := sum.ZipHash("golang.org/x/text", "v0.3.0"); !ok || h != "h1:g61tztE5qeGQ89tm6NTjjM9VPIm088od1l6aSorWRWg=" {
+		t.Errorf("Unexpected zip hash %s", h)
+	}
+
+	if h, ok := sum.ModHash("golang.org/x/text", "v0.3.0"); !ok || h != "h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=" {
+		t.Errorf("Unexpected go.mod hash %s", h)
+	}
+
+	if _, err := ParseGoSum(strings.NewReader("golang.org/x/text v0.3.0\n")); err == nil {
+		t.Error("Expected an invalid go.sum to be rejected")
+	}
+}
+
+func TestGoModuleInfo(t *testing.T) {
+	rm, done := newFakeGoProxy(t, nil)
+	defer done()
+
+	vs, err := GetGoModuleVersions(rm, "go-proxy", dummyGoModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(vs, []string{"v1.2.0", "v1.9.0-rc.1", "v1.10.0"}) {
+		t.Errorf("Unexpected versions %v", vs)
+	}
+
+	latest, err := GetGoModuleLatest(rm, "go-proxy", dummyGoModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Version != "v1.10.0" || !latest.Time.Equal(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected latest version %v", latest)
+	}
+
+	info, err := GetGoModuleInfo(rm, "go-proxy", dummyGoModule, "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != "v1.2.0" {
+		t.Errorf("Unexpected info %v", info)
+	}
+
+	if _, err = GetGoModuleInfo(rm, "go-proxy", "example.com/other", "v1.2.0"); err == nil {
+		t.Error("Expected an unknown module not to be found")
+	}
+
+	sum := GoSum{dummyGoModule + " v1.2.0/go.mod": HashGoMod([]byte("module " + dummyGoModule + "\n"))}
+	if _, err = GetGoModuleMod(rm, "go-proxy", dummyGoModule, "v1.2.0", sum); err != nil {
+		t.Error(err)
+	}
+
+	sum[dummyGoModule+" v1.2.0/go.mod"] = HashGoMod([]byte("module example.com/other\n"))
+	if _, err = GetGoModuleMod(rm, "go-proxy", dummyGoModule, "v1.2.0", sum); !errors.As(err, &ChecksumMismatchError{}) {
+		t.Errorf("Expected a checksum mismatch but got %v", err)
+	}
+}
+
+func TestDownloadGoModuleZip(t *testing.T) {
+	zips := map[string][]byte{"v1.2.0": dummyGoModuleZip(t, "v1.2.0")}
+
+	rm, done := newFakeGoProxy(t, zips)
+	defer done()
+
+	dir, err := ioutil.TempDir("", "goproxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "mod", "v1.2.0.zip")
+	hash, err := DownloadGoModuleZip(rm, "go-proxy", dummyGoModule, "v1.2.0", file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := HashGoModuleZip(bytes.NewReader(zips["v1.2.0"]), int64(len(zips["v1.2.0"])))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash != expected || !strings.HasPrefix(hash, "h1:") {
+		t.Errorf("Expected hash %s but got %s", expected, hash)
+	}
+
+	if content, _ := ioutil.ReadFile(file); !bytes.Equal(content, zips["v1.2.0"]) {
+		t.Error("Unexpected zip content")
+	}
+
+	// the zip is verified against go.sum
+	sum := GoSum{dummyGoModule + " v1.2.0": hash}
+	if _, err = DownloadGoModuleZip(rm, "go-proxy", dummyGoModule, "v1.2.0", file, sum); err != nil {
+		t.Error(err)
+	}
+
+	tampered := filepath.Join(dir, "tampered.zip")
+	zips["v1.2.0"] = dummyGoModuleZip(t, "v1.2.1")
+	_, err = DownloadGoModuleZip(rm, "go-proxy", dummyGoModule, "v1.2.0", tampered, sum)
+	if !errors.As(err, &ChecksumMismatchError{}) {
+		t.Errorf("Expected a checksum mismatch but got %v", err)
+	}
+
+	if _, err := os.Stat(tampered); !os.IsNotExist(err) {
+		t.Error("Expected a zip which does not match go.sum not to be kept")
+	}
+
+	if _, err := os.Stat(tampered + partialDownloadSuffix); !os.IsNotExist(err) {
+		t.Error("Expected the partial download to be removed")
+	}
+}
@@ -3,8 +3,16 @@ package nexusrm
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"strings"
 )
 
+var groovyStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`)
+
+// groovyString escapes a value to be inserted between the single quotes of a Groovy string literal
+func groovyString(s string) string {
+	return groovyStringEscaper.Replace(s)
+}
+
 func newAnonGroovyScript(content string) Script {
 	h := sha1.New()
 	h.Write([]byte(content))
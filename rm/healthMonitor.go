@@ -0,0 +1,239 @@
+package nexusrm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of the components, besides the status checks, which are tracked by a HealthSnapshot
+const (
+	HealthReadable = "readable"
+	HealthWritable = "writable"
+	HealthReadOnly = "read-only"
+)
+
+// HealthSnapshot combines the status checks, read-only state and database states of an RM instance at a point in time
+type HealthSnapshot struct {
+	Time      time.Time
+	Readable  bool
+	Writable  bool
+	Checks    []HealthCheck
+	ReadOnly  ReadOnlyState
+	Databases map[string]DatabaseState
+	// Errors holds any failure to retrieve part of the snapshot, keyed by the part which failed
+	Errors map[string]error
+}
+
+// GetHealthSnapshot gathers the current health information of the RM instance.
+// Parts of the snapshot which could not be retrieved are recorded in its Errors.
+func GetHealthSnapshot(rm RM) HealthSnapshot {
+	s := HealthSnapshot{
+		Time:     time.Now(),
+		Readable: StatusReadable(rm),
+		Writable: StatusWritable(rm),
+		Errors:   make(map[string]error),
+	}
+
+	var err error
+	if s.Checks, err = GetStatusCheck(rm); err != nil {
+		s.Errors["status"] = err
+	}
+
+	if s.ReadOnly, err = GetReadOnlyState(rm); err != nil {
+		s.Errors[HealthReadOnly] = err
+	}
+
+	// Database checks are only available in some editions
+	if s.Databases, err = CheckAllDatabases(rm); err != nil {
+		s.Errors["databases"] = err
+	}
+
+	return s
+}
+
+type healthComponent struct {
+	healthy bool
+	message string
+}
+
+func (s HealthSnapshot) components() map[string]healthComponent {
+	components := map[string]healthComponent{
+		HealthReadable: {s.Readable, ""},
+		HealthWritable: {s.Writable, ""},
+	}
+
+	if _, failed := s.Errors[HealthReadOnly]; !failed {
+		components[HealthReadOnly] = healthComponent{!s.ReadOnly.Frozen, s.ReadOnly.SummaryReason}
+	}
+
+	for _, c := range s.Checks {
+		components[c.Name] = healthComponent{c.Healthy, c.Message}
+	}
+
+	for name, db := range s.Databases {
+		components["database-"+name] = healthComponent{
+			!db.PageCorruption && db.IndexErrors == 0,
+			fmt.Sprintf("pageCorruption: %v, indexErrors: %d", db.PageCorruption, db.IndexErrors),
+		}
+	}
+
+	return components
+}
+
+// retrieved returns false if the part of the snapshot which reports the named component could not be retrieved
+func (s HealthSnapshot) retrieved(name string) bool {
+	part := "status"
+	switch {
+	case name == HealthReadable || name == HealthWritable:
+		return true
+	case name == HealthReadOnly:
+		part = HealthReadOnly
+	case strings.HasPrefix(name, "database-"):
+		part = "databases"
+	}
+
+	_, failed := s.Errors[part]
+	return !failed
+}
+
+// Healthy returns true if the instance is readable, writable, not frozen and every check and database is healthy
+func (s HealthSnapshot) Healthy() bool {
+	for _, c := range s.components() {
+		if !c.healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Unhealthy returns the names of the components which are not healthy
+func (s HealthSnapshot) Unhealthy() []string {
+	names := make([]string, 0)
+	for name, c := range s.components() {
+		if !c.healthy {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HealthTransition describes a component of an RM instance changing health
+type HealthTransition struct {
+	Name       string
+	WasHealthy bool
+	Healthy    bool
+	Message    string
+	// Removed is set when the component is no longer reported by the instance, which is considered healthy
+	Removed  bool
+	Snapshot HealthSnapshot
+}
+
+// HealthMonitor periodically polls the health of an RM instance
+type HealthMonitor struct {
+	rm       RM
+	interval time.Duration
+
+	mu     sync.RWMutex
+	latest *HealthSnapshot
+}
+
+// NewHealthMonitor creates a monitor which polls the given RM instance at the given interval
+func NewHealthMonitor(rm RM, interval time.Duration) *HealthMonitor {
+	return &HealthMonitor{rm: rm, interval: interval}
+}
+
+// Latest returns the most recent snapshot taken by the monitor, if any
+func (m *HealthMonitor) Latest() (HealthSnapshot, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.latest == nil {
+		return HealthSnapshot{}, false
+	}
+	return *m.latest, true
+}
+
+// Run polls the instance until the context is done and sends a transition whenever a component changes health.
+// Components are assumed healthy before the first poll, so the first snapshot only reports unhealthy ones.
+// A component which is no longer reported, such as a check which was removed, is assumed healthy again, so an
+// unhealthy one sends a Removed transition. If the part of the snapshot reporting it could not be retrieved,
+// the component keeps its previous health instead.
+// The returned channel is closed once the context is done.
+func (m *HealthMonitor) Run(ctx context.Context) <-chan HealthTransition {
+	transitions := make(chan HealthTransition, 1)
+
+	go func() {
+		defer close(transitions)
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		previous := make(map[string]healthComponent)
+		for {
+			snapshot := GetHealthSnapshot(m.rm)
+
+			m.mu.Lock()
+			m.latest = &snapshot
+			m.mu.Unlock()
+
+			current := snapshot.components()
+
+			removed := make(map[string]bool)
+			for name, was := range previous {
+				if _, ok := current[name]; ok {
+					continue
+				}
+				if snapshot.retrieved(name) {
+					removed[name] = true
+				} else {
+					current[name] = was
+				}
+			}
+
+			names := make([]string, 0, len(current)+len(removed))
+			for name := range current {
+				names = append(names, name)
+			}
+			for name := range removed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				c, ok := current[name]
+				if !ok {
+					c = healthComponent{true, "no longer reported"}
+				}
+
+				was, seen := previous[name]
+				if !seen {
+					was.healthy = true
+				}
+
+				if was.healthy == c.healthy {
+					continue
+				}
+
+				select {
+				case transitions <- HealthTransition{name, was.healthy, c.healthy, c.message, removed[name], snapshot}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return transitions
+}
@@ -0,0 +1,189 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func healthTestRM(t *testing.T, blobStoresHealthy func() bool) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path[1:] {
+		case restStatusReadable, restStatusWritable:
+			w.WriteHeader(http.StatusOK)
+		case restStatusCheck:
+			checks := map[string]statusCheckResult{
+				"Blob Stores":              {Healthy: blobStoresHealthy(), Message: "blob stores checked", Time: 1590969600000},
+				"Thread Deadlock Detector": {Healthy: true, Time: 1590969600000},
+			}
+
+			resp, err := json.Marshal(checks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case restReadOnly:
+			fmt.Fprintln(w, `{"systemInitiated":false,"summaryReason":"","frozen":false}`)
+		default:
+			if strings.HasPrefix(r.URL.Path[1:], "service/rest/v1/maintenance") {
+				maintenanceTestFunc(t, w, r)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestGetStatusCheck(t *testing.T) {
+	rm, mock := healthTestRM(t, func() bool { return false })
+	defer mock.Close()
+
+	checks, err := GetStatusCheck(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []HealthCheck{
+		{Name: "Blob Stores", Healthy: false, Message: "blob stores checked", Time: time.Unix(1590969600, 0)},
+		{Name: "Thread Deadlock Detector", Healthy: true, Time: time.Unix(1590969600, 0)},
+	}
+	if !reflect.DeepEqual(checks, expected) {
+		t.Errorf("Expected %v but got %v", expected, checks)
+	}
+}
+
+func TestGetHealthSnapshot(t *testing.T) {
+	rm, mock := healthTestRM(t, func() bool { return true })
+	defer mock.Close()
+
+	snapshot := GetHealthSnapshot(rm)
+
+	if !snapshot.Readable || !snapshot.Writable || len(snapshot.Checks) != 2 {
+		t.Errorf("Unexpected snapshot: %v", snapshot)
+	}
+
+	if len(snapshot.Errors) != 0 {
+		t.Errorf("Unexpected errors: %v", snapshot.Errors)
+	}
+
+	// the dummy database states report corruption in every database
+	if snapshot.Healthy() {
+		t.Error("Expected snapshot with corrupted databases to be unhealthy")
+	}
+
+	expected := []string{"database-" + AccessLogDB, "database-" + ComponentDB, "database-" + ConfigDB, "database-" + SecurityDB}
+	if unhealthy := snapshot.Unhealthy(); !reflect.DeepEqual(unhealthy, expected) {
+		t.Errorf("Expected %v to be unhealthy but got %v", expected, unhealthy)
+	}
+}
+
+func TestHealthMonitor(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	rm, mock := healthTestRM(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		return polls != 2
+	})
+	defer mock.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	monitor := NewHealthMonitor(rm, time.Millisecond)
+	transitions := monitor.Run(ctx)
+
+	var blobStores []HealthTransition
+	for tr := range transitions {
+		if tr.Name == "Blob Stores" {
+			blobStores = append(blobStores, tr)
+		}
+		if len(blobStores) == 2 {
+			cancel()
+		}
+	}
+
+	if len(blobStores) != 2 {
+		t.Fatalf("Expected two blob store transitions but got %v", blobStores)
+	}
+
+	if !blobStores[0].WasHealthy || blobStores[0].Healthy || !blobStores[1].Healthy {
+		t.Errorf("Expected blob stores to become unhealthy then healthy: %v", blobStores)
+	}
+
+	if _, ok := monitor.Latest(); !ok {
+		t.Error("Expected monitor to have a snapshot")
+	}
+}
+
+func TestHealthMonitorRemovedCheck(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path[1:] {
+		case restStatusReadable, restStatusWritable:
+			w.WriteHeader(http.StatusOK)
+		case restStatusCheck:
+			mu.Lock()
+			polls++
+			poll := polls
+			mu.Unlock()
+
+			checks := map[string]statusCheckResult{"Thread Deadlock Detector": {Healthy: true, Time: 1590969600000}}
+			switch poll {
+			case 1:
+				checks["Blob Stores"] = statusCheckResult{Healthy: false, Message: "blob stores checked", Time: 1590969600000}
+			case 2:
+				// the check keeps its health while the checks cannot be retrieved
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			resp, err := json.Marshal(checks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case restReadOnly:
+			fmt.Fprintln(w, `{"systemInitiated":false,"summaryReason":"","frozen":false}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var blobStores []HealthTransition
+	for tr := range NewHealthMonitor(rm, time.Millisecond).Run(ctx) {
+		if tr.Name == "Blob Stores" {
+			blobStores = append(blobStores, tr)
+		}
+		if len(blobStores) == 2 {
+			cancel()
+		}
+	}
+
+	if len(blobStores) != 2 {
+		t.Fatalf("Expected two blob store transitions but got %v", blobStores)
+	}
+
+	if blobStores[0].Healthy || blobStores[0].Removed || !blobStores[1].Healthy || !blobStores[1].Removed {
+		t.Errorf("Expected blob stores to become unhealthy then removed: %v", blobStores)
+	}
+
+	if checks := blobStores[1].Snapshot.Checks; len(checks) != 1 {
+		t.Errorf("Expected the removal to be reported once the checks are retrieved again: %v", checks)
+	}
+}
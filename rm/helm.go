@@ -0,0 +1,337 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/overag3/gonexus/versions"
+)
+
+const helmIndexFile = "index.yaml"
+
+// HelmMaintainer is a maintainer of a chart
+type HelmMaintainer struct {
+	Name  string
+	Email string
+	URL   string
+}
+
+// HelmDependency is a chart which a chart depends on
+type HelmDependency struct {
+	Name       string
+	Version    string
+	Repository string
+	Condition  string
+	Alias      string
+}
+
+// HelmChart is the metadata of a version of a chart, from its Chart.yaml file or from an entry of the index of a
+// helm repository, which adds the URLs the chart can be downloaded from, its digest and its creation time
+type HelmChart struct {
+	APIVersion   string
+	Name         string
+	Version      string
+	AppVersion   string
+	KubeVersion  string
+	Description  string
+	Type         string
+	Home         string
+	Icon         string
+	Deprecated   bool
+	Keywords     []string
+	Sources      []string
+	Maintainers  []HelmMaintainer
+	Dependencies []HelmDependency
+	Annotations  map[string]string
+
+	URLs    []string
+	Digest  string
+	Created time.Time
+}
+
+// HelmIndex is the index.yaml file of a helm repository, which lists the versions of each chart
+type HelmIndex struct {
+	APIVersion string
+	Generated  time.Time
+	Entries    map[string][]HelmChart
+}
+
+// Versions returns the versions of a chart listed by the index, ordered as semantic versions
+func (i HelmIndex) Versions(chart string) []string {
+	vs := make([]string, 0, len(i.Entries[chart]))
+	for _, c := range i.Entries[chart] {
+		vs = append(vs, c.Version)
+	}
+	versions.Sort(versions.SemVer, vs)
+	return vs
+}
+
+func yamlString(node map[string]interface{}, key string) string {
+	s, _ := node[key].(string)
+	return s
+}
+
+func yamlStrings(node map[string]interface{}, key string) []string {
+	seq, _ := node[key].([]interface{})
+	if len(seq) == 0 {
+		return nil
+	}
+
+	strs := make([]string, 0, len(seq))
+	for _, item := range seq {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func yamlMappings(node map[string]interface{}, key string) []map[string]interface{} {
+	seq, _ := node[key].([]interface{})
+	if len(seq) == 0 {
+		return nil
+	}
+
+	mappings := make([]map[string]interface{}, 0, len(seq))
+	for _, item := range seq {
+		if m, ok := item.(map[string]interface{}); ok {
+			mappings = append(mappings, m)
+		}
+	}
+	return mappings
+}
+
+func yamlTime(node map[string]interface{}, key string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, yamlString(node, key))
+	return t
+}
+
+func decodeHelmChart(node map[string]interface{}) HelmChart {
+	c := HelmChart{
+		APIVersion:  yamlString(node, "apiVersion"),
+		Name:        yamlString(node, "name"),
+		Version:     yamlString(node, "version"),
+		AppVersion:  yamlString(node, "appVersion"),
+		KubeVersion: yamlString(node, "kubeVersion"),
+		Description: yamlString(node, "description"),
+		Type:        yamlString(node, "type"),
+		Home:        yamlString(node, "home"),
+		Icon:        yamlString(node, "icon"),
+		Deprecated:  yamlString(node, "deprecated") == "true",
+		Keywords:    yamlStrings(node, "keywords"),
+		Sources:     yamlStrings(node, "sources"),
+		URLs:        yamlStrings(node, "urls"),
+		Digest:      yamlString(node, "digest"),
+		Created:     yamlTime(node, "created"),
+	}
+
+	for _, m := range yamlMappings(node, "maintainers") {
+		c.Maintainers = append(c.Maintainers, HelmMaintainer{Name: yamlString(m, "name"), Email: yamlString(m, "email"), URL: yamlString(m, "url")})
+	}
+
+	for _, d := range yamlMappings(node, "dependencies") {
+		c.Dependencies = append(c.Dependencies, HelmDependency{
+			Name:       yamlString(d, "name"),
+			Version:    yamlString(d, "version"),
+			Repository: yamlString(d, "repository"),
+			Condition:  yamlString(d, "condition"),
+			Alias:      yamlString(d, "alias"),
+		})
+	}
+
+	if annotations, ok := node["annotations"].(map[string]interface{}); ok {
+		c.Annotations = make(map[string]string)
+		for k := range annotations {
+			c.Annotations[k] = yamlString(annotations, k)
+		}
+	}
+
+	return c
+}
+
+// ParseHelmIndex parses the index.yaml file of a helm repository
+func ParseHelmIndex(r io.Reader) (HelmIndex, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return HelmIndex{}, fmt.Errorf("could not parse helm index: %v", err)
+	}
+
+	doc, err := parseYAML(string(buf))
+	if err != nil {
+		return HelmIndex{}, fmt.Errorf("could not parse helm index: %v", err)
+	}
+
+	node, ok := doc.(map[string]interface{})
+	if !ok {
+		return HelmIndex{}, errors.New("could not parse helm index: not a mapping")
+	}
+
+	index := HelmIndex{
+		APIVersion: yamlString(node, "apiVersion"),
+		Generated:  yamlTime(node, "generated"),
+		Entries:    make(map[string][]HelmChart),
+	}
+
+	entries, _ := node["entries"].(map[string]interface{})
+	for name := range entries {
+		charts := make([]HelmChart, 0)
+		for _, m := range yamlMappings(entries, name) {
+			c := decodeHelmChart(m)
+			if c.Name == "" {
+				c.Name = name
+			}
+			charts = append(charts, c)
+		}
+		index.Entries[name] = charts
+	}
+
+	return index, nil
+}
+
+// ParseHelmChart parses the Chart.yaml file of a chart
+func ParseHelmChart(r io.Reader) (HelmChart, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return HelmChart{}, fmt.Errorf("could not parse Chart.yaml: %v", err)
+	}
+
+	doc, err := parseYAML(string(buf))
+	if err != nil {
+		return HelmChart{}, fmt.Errorf("could not parse Chart.yaml: %v", err)
+	}
+
+	node, ok := doc.(map[string]interface{})
+	if !ok {
+		return HelmChart{}, errors.New("could not parse Chart.yaml: not a mapping")
+	}
+
+	c := decodeHelmChart(node)
+	if c.Name == "" || c.Version == "" {
+		return c, errors.New("could not parse Chart.yaml: no name or version")
+	}
+
+	return c, nil
+}
+
+// ReadHelmChart returns the metadata of a chart archive from the Chart.yaml file of the chart,
+// which is in the top-level directory of the archive
+func ReadHelmChart(archive io.Reader) (HelmChart, error) {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return HelmChart{}, fmt.Errorf("could not read chart archive: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return HelmChart{}, errors.New("chart archive has no Chart.yaml")
+		}
+		if err != nil {
+			return HelmChart{}, fmt.Errorf("could not read chart archive: %v", err)
+		}
+
+		// charts of the dependencies are in the charts directory and have their own Chart.yaml
+		dir, file := path.Split(strings.TrimPrefix(hdr.Name, "./"))
+		if file == "Chart.yaml" && strings.Count(dir, "/") == 1 {
+			return ParseHelmChart(tr)
+		}
+	}
+}
+
+// GetHelmIndex retrieves and parses the index.yaml file of a helm repository
+func GetHelmIndex(rm RM, repo string) (HelmIndex, error) {
+	req, err := rm.NewRequest(http.MethodGet, fmt.Sprintf("repository/%s/%s", repo, helmIndexFile), nil)
+	if err != nil {
+		return HelmIndex{}, fmt.Errorf("could not retrieve index of '%s': %v", repo, err)
+	}
+
+	body, _, err := rm.Do(req)
+	if err != nil {
+		return HelmIndex{}, fmt.Errorf("could not retrieve index of '%s': %v", repo, err)
+	}
+
+	return ParseHelmIndex(bytes.NewReader(body))
+}
+
+// UploadHelmChart uploads a chart archive to a helm hosted repository, at the path named after the name
+// and version in the Chart.yaml file of the archive. Returns the metadata of the chart.
+func UploadHelmChart(rm RM, repo string, archive io.Reader) (HelmChart, error) {
+	buf, err := ioutil.ReadAll(archive)
+	if err != nil {
+		return HelmChart{}, fmt.Errorf("could not upload chart to '%s': %v", repo, err)
+	}
+
+	chart, err := ReadHelmChart(bytes.NewReader(buf))
+	if err != nil {
+		return HelmChart{}, fmt.Errorf("could not upload chart to '%s': %v", repo, err)
+	}
+
+	if err := UploadAssetToPath(rm, repo, fmt.Sprintf("%s-%s.tgz", chart.Name, chart.Version), bytes.NewReader(buf)); err != nil {
+		return chart, fmt.Errorf("could not upload chart %s %s: %v", chart.Name, chart.Version, err)
+	}
+
+	return chart, nil
+}
+
+// UnreferencedHelmCharts returns the components of a helm repository whose chart version is not referenced by
+// any entry of the index of the repository, either by its name and version or by the URL of one of its assets.
+// Such charts cannot be installed with the helm client and are usually leftovers which can be deleted.
+func UnreferencedHelmCharts(rm RM, repo string) ([]RepositoryItem, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find unreferenced charts of '%s': %v", repo, err)
+	}
+
+	index, err := GetHelmIndex(rm, repo)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	components, err := GetComponents(rm, repo)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	referenced := make(map[string]bool)
+	files := make(map[string]bool)
+	for name, charts := range index.Entries {
+		for _, c := range charts {
+			referenced[name+"@"+c.Version] = true
+			for _, u := range c.URLs {
+				files[path.Base(u)] = true
+			}
+		}
+	}
+
+	unreferenced := make([]RepositoryItem, 0)
+	for _, c := range components {
+		if referenced[c.Name+"@"+c.Version] {
+			continue
+		}
+
+		found := false
+		for _, a := range c.Assets {
+			found = found || files[path.Base(a.Path)]
+		}
+		if !found {
+			unreferenced = append(unreferenced, c)
+		}
+	}
+
+	sort.SliceStable(unreferenced, func(i, j int) bool {
+		return componentKey(unreferenced[i]) < componentKey(unreferenced[j])
+	})
+
+	return unreferenced, nil
+}
@@ -0,0 +1,213 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const dummyHelmIndex = `apiVersion: v1
+entries:
+  dummy:
+  - apiVersion: v2
+    appVersion: "1.16"
+    created: "2020-06-01T12:00:00.123456789Z"
+    description: A dummy chart
+    digest: 4e8ab1b4b9b8bc6cd1a3e1b8c6b3d13a4d1a86c9cbeb4cbfbd4c4d0c10e2a8aa
+    maintainers:
+    - email: dummy@example.com
+      name: Dummy
+    dependencies:
+    - name: redis
+      repository: https://charts.example.com
+      version: ~10.5.0
+    keywords: [dummy, example]
+    name: dummy
+    urls:
+    - dummy/dummy-1.10.0.tgz
+    version: 1.10.0
+  - apiVersion: v2
+    name: dummy
+    deprecated: true
+    urls:
+    - dummy/dummy-1.2.0.tgz
+    version: 1.2.0
+  other:
+  - name: other
+    annotations: {category: Database, licenses: Apache-2.0}
+    description: Another chart whose description is long enough to be wrapped by
+      the emitter of the index
+    home: "https://example.com/charts/\
+      other"
+    urls:
+    - other-renamed.tgz
+    version: 0.1.0
+generated: "2020-06-02T12:00:00Z"
+`
+
+func dummyHelmChart(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseHelmIndex(t *testing.T) {
+	index, err := ParseHelmIndex(strings.NewReader(dummyHelmIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if index.APIVersion != "v1" || !index.Generated.Equal(time.Date(2020, 6, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected index %v", index)
+	}
+
+	if len(index.Entries) != 2 || len(index.Entries["dummy"]) != 2 {
+		t.Fatalf("Unexpected entries %v", index.Entries)
+	}
+
+	chart := index.Entries["dummy"][0]
+	if chart.Version != "1.10.0" || chart.AppVersion != "1.16" || chart.Description != "A dummy chart" || chart.Deprecated {
+		t.Errorf("Unexpected chart %v", chart)
+	}
+
+	if !chart.Created.Equal(time.Date(2020, 6, 1, 12, 0, 0, 123456789, time.UTC)) {
+		t.Errorf("Unexpected creation time %v", chart.Created)
+	}
+
+	if !reflect.DeepEqual(chart.Maintainers, []HelmMaintainer{{Name: "Dummy", Email: "dummy@example.com"}}) {
+		t.Errorf("Unexpected maintainers %v", chart.Maintainers)
+	}
+
+	if !reflect.DeepEqual(chart.Dependencies, []HelmDependency{{Name: "redis", Version: "~10.5.0", Repository: "https://charts.example.com"}}) {
+		t.Errorf("Unexpected dependencies %v", chart.Dependencies)
+	}
+
+	if !reflect.DeepEqual(chart.Keywords, []string{"dummy", "example"}) || !reflect.DeepEqual(chart.URLs, []string{"dummy/dummy-1.10.0.tgz"}) {
+		t.Errorf("Unexpected keywords %v or URLs %v", chart.Keywords, chart.URLs)
+	}
+
+	if !index.Entries["dummy"][1].Deprecated {
+		t.Error("Expected chart to be deprecated")
+	}
+
+	other := index.Entries["other"][0]
+	if other.Description != "Another chart whose description is long enough to be wrapped by the emitter of the index" {
+		t.Errorf("Unexpected wrapped description %q", other.Description)
+	}
+
+	if other.Home != "https://example.com/charts/other" {
+		t.Errorf("Unexpected wrapped home %q", other.Home)
+	}
+
+	if !reflect.DeepEqual(other.Annotations, map[string]string{"category": "Database", "licenses": "Apache-2.0"}) {
+		t.Errorf("Unexpected annotations %v", other.Annotations)
+	}
+
+	if vs := index.Versions("dummy"); !reflect.DeepEqual(vs, []string{"1.2.0", "1.10.0"}) {
+		t.Errorf("Unexpected versions %v", vs)
+	}
+}
+
+func TestReadHelmChart(t *testing.T) {
+	archive := dummyHelmChart(t, map[string]string{
+		"dummy/charts/redis/Chart.yaml": "name: redis\nversion: 10.5.7\n",
+		"dummy/values.yaml":             "replicas: 1\n",
+		"dummy/Chart.yaml":              "apiVersion: v2\nname: dummy\nversion: 1.10.0\ntype: application\ndescription: >\n  A dummy\n  chart\n",
+	})
+
+	chart, err := ReadHelmChart(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chart.Name != "dummy" || chart.Version != "1.10.0" || chart.Type != "application" || chart.Description != "A dummy chart\n" {
+		t.Errorf("Unexpected chart %v", chart)
+	}
+
+	if _, err = ReadHelmChart(bytes.NewReader(dummyHelmChart(t, map[string]string{"dummy/values.yaml": ""}))); err == nil {
+		t.Error("Expected an archive without Chart.yaml to be rejected")
+	}
+
+	if _, err = ReadHelmChart(bytes.NewReader(dummyHelmChart(t, map[string]string{"dummy/Chart.yaml": "name: dummy\n"}))); err == nil {
+		t.Error("Expected a chart without version to be rejected")
+	}
+}
+
+func TestUploadHelmChart(t *testing.T) {
+	f := newFakeRepositoryManager(t, inventoryRepository("helm-hosted", "helm", "hosted", "default"))
+	defer f.Close()
+
+	archive := dummyHelmChart(t, map[string]string{"dummy/Chart.yaml": "name: dummy\nversion: 1.10.0\n"})
+
+	chart, err := UploadHelmChart(f.rm, "helm-hosted", bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chart.Name != "dummy" || chart.Version != "1.10.0" {
+		t.Errorf("Unexpected chart %v", chart)
+	}
+
+	if !reflect.DeepEqual(f.uploads, []string{"helm-hosted/dummy-1.10.0.tgz"}) {
+		t.Errorf("Unexpected uploads %v", f.uploads)
+	}
+
+	if !bytes.Equal(f.content["helm-hosted/dummy-1.10.0.tgz"], archive) {
+		t.Error("Unexpected uploaded content")
+	}
+
+	if _, err = UploadHelmChart(f.rm, "helm-hosted", strings.NewReader("not an archive")); err == nil {
+		t.Error("Expected an invalid archive to be rejected")
+	}
+}
+
+func TestUnreferencedHelmCharts(t *testing.T) {
+	f := newFakeRepositoryManager(t, inventoryRepository("helm-hosted", "helm", "hosted", "default"))
+	defer f.Close()
+
+	f.content["helm-hosted/index.yaml"] = []byte(dummyHelmIndex)
+	f.addAsset("helm-hosted", "", "dummy", "1.10.0", "dummy/dummy-1.10.0.tgz", []byte("1.10.0"))
+	f.addAsset("helm-hosted", "", "dummy", "1.2.0", "dummy/dummy-1.2.0.tgz", []byte("1.2.0"))
+	f.addAsset("helm-hosted", "", "dummy", "1.1.0", "dummy/dummy-1.1.0.tgz", []byte("1.1.0"))
+	f.addAsset("helm-hosted", "", "other", "0.2.0", "other-renamed.tgz", []byte("0.2.0"))
+	f.addAsset("helm-hosted", "", "gone", "0.1.0", "gone-0.1.0.tgz", []byte("0.1.0"))
+
+	unreferenced, err := UnreferencedHelmCharts(f.rm, "helm-hosted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make([]string, 0)
+	for _, c := range unreferenced {
+		actual = append(actual, componentKey(c))
+	}
+
+	if !reflect.DeepEqual(actual, []string{"dummy:1.1.0", "gone:0.1.0"}) {
+		t.Errorf("Unexpected unreferenced charts %v", actual)
+	}
+
+	if _, err = UnreferencedHelmCharts(f.rm, "missing"); err == nil {
+		t.Error("Expected a repository without index to fail")
+	}
+}
@@ -0,0 +1,213 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	nexus "github.com/overag3/gonexus"
+)
+
+// Issues an integrity audit can find with an asset
+const (
+	IntegrityChecksumMismatch = "checksum-mismatch"
+	IntegritySizeMismatch     = "size-mismatch"
+	IntegrityMissing          = "missing"
+	IntegrityEmpty            = "empty"
+	IntegrityError            = "error"
+)
+
+// IntegrityResult is the outcome of auditing an asset. The issue is empty if the asset is intact.
+type IntegrityResult struct {
+	AssetID string `json:"id"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Issue   string `json:"issue,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// IntegrityAuditOptions configures an integrity audit
+type IntegrityAuditOptions struct {
+	// ProgressFile records every audited asset so an interrupted audit can resume where it stopped.
+	// Assets which could not be audited because of an error, such as a server or network error,
+	// are not recorded so that they are audited again when resuming. An empty path disables resuming.
+	ProgressFile string
+	// Parallel is the number of assets to audit at the same time, at least one
+	Parallel int
+	// OnResult, if set, is called with the result of each asset as it is audited
+	OnResult func(IntegrityResult)
+}
+
+// IntegrityAuditReport summarizes an integrity audit, including the assets audited before it resumed
+type IntegrityAuditReport struct {
+	Repository string
+	Audited    int
+	Resumed    int
+	Bytes      int64
+	Issues     []IntegrityResult
+}
+
+// AuditRepositoryIntegrity downloads every asset of the repository, recomputes its checksums and compares
+// them with those RM reports. Assets which RM cannot serve, which are empty or whose content does not
+// match are reported as issues. When a progress file is given, assets audited by a previous run
+// are skipped and their issues included in the report, except those which failed with an error. If the context is done, the audit stops and
+// returns what was audited so far along with the error of the context.
+func AuditRepositoryIntegrity(ctx context.Context, rm RM, repo string, options IntegrityAuditOptions) (IntegrityAuditReport, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not audit integrity of repository '%s': %v", repo, err)
+	}
+
+	report := IntegrityAuditReport{Repository: repo, Issues: make([]IntegrityResult, 0)}
+
+	audited := make(map[string]bool)
+	var progress *progressLog
+	if options.ProgressFile != "" {
+		var err error
+		progress, err = openProgressLog(options.ProgressFile, func(line []byte) {
+			var r IntegrityResult
+			if json.Unmarshal(line, &r) != nil || r.AssetID == "" || r.Issue == IntegrityError || audited[r.AssetID] {
+				return
+			}
+			audited[r.AssetID] = true
+			report.add(r)
+		})
+		if err != nil {
+			return report, doError(err)
+		}
+		defer progress.Close()
+
+		report.Resumed = len(audited)
+	}
+
+	assets, err := GetAssets(rm, repo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	parallel := options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var mu sync.Mutex
+	var writeErr error
+	record := func(r IntegrityResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		report.add(r)
+
+		// an error is not a finding about the asset, which has to be audited again
+		if progress != nil && writeErr == nil && r.Issue != IntegrityError {
+			writeErr = progress.record(r)
+		}
+
+		if options.OnResult != nil {
+			options.OnResult(r)
+		}
+	}
+
+	queue := make(chan RepositoryItemAsset)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for asset := range queue {
+				result := auditAsset(ctx, rm, asset)
+				// an audit interrupted by the context is not a result
+				if ctx.Err() != nil && result.Issue == IntegrityError {
+					continue
+				}
+				record(result)
+			}
+		}()
+	}
+
+	for _, asset := range assets {
+		if audited[asset.ID] {
+			continue
+		}
+
+		select {
+		case queue <- asset:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, doError(err)
+	}
+
+	if writeErr != nil {
+		return report, doError(fmt.Errorf("could not record progress: %v", writeErr))
+	}
+
+	return report, nil
+}
+
+func (r *IntegrityAuditReport) add(result IntegrityResult) {
+	r.Audited++
+	r.Bytes += result.Size
+	if result.Issue != "" {
+		r.Issues = append(r.Issues, result)
+	}
+}
+
+// auditAsset downloads the asset and verifies its content
+func auditAsset(ctx context.Context, rm RM, asset RepositoryItemAsset) IntegrityResult {
+	result := IntegrityResult{AssetID: asset.ID, Path: asset.Path}
+
+	fail := func(issue string, detail error) IntegrityResult {
+		result.Issue = issue
+		result.Detail = detail.Error()
+		return result
+	}
+
+	req, err := rm.NewRequest(http.MethodGet, assetEndpoint(rm, asset), nil)
+	if err != nil {
+		return fail(IntegrityError, err)
+	}
+
+	resp, err := nexus.Stream(rm, req.WithContext(ctx))
+	if err != nil {
+		return fail(IntegrityError, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return fail(IntegrityMissing, errors.New(resp.Status))
+	default:
+		return fail(IntegrityError, errors.New(resp.Status))
+	}
+
+	verifier := newChecksumVerifier(asset.Checksum)
+	if result.Size, err = io.Copy(verifier, resp.Body); err != nil {
+		return fail(IntegrityError, err)
+	}
+
+	// an empty blob is legitimate for an empty asset, whose recorded checksums are those of empty content
+	switch {
+	case result.Size == 0 && (asset.FileSize > 0 || verifier.verify() != nil):
+		return fail(IntegrityEmpty, errors.New("blob is empty"))
+	case asset.FileSize > 0 && result.Size != asset.FileSize:
+		return fail(IntegritySizeMismatch, fmt.Errorf("expected %d bytes but got %d", asset.FileSize, result.Size))
+	}
+
+	if err = verifier.verify(); err != nil {
+		return fail(IntegrityChecksumMismatch, err)
+	}
+
+	return result
+}
@@ -0,0 +1,236 @@
+package nexusrm
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func integrityTestRM(t *testing.T, downloads map[string]int) (rm RM, content map[string][]byte, assets []RepositoryItemAsset, close func()) {
+	content = map[string][]byte{
+		"good.jar":      []byte("good content"),
+		"corrupt.jar":   []byte("corrupted content"),
+		"empty.jar":     {},
+		"truncated.jar": []byte("trunc"),
+		"blank.txt":     {},
+		"zeroed.jar":    {},
+	}
+
+	sum := func(b []byte) string {
+		s := sha1.Sum(b)
+		return hex.EncodeToString(s[:])
+	}
+
+	assets = []RepositoryItemAsset{
+		{ID: "good", Path: "good.jar", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: sum(content["good.jar"])}},
+		{ID: "corrupt", Path: "corrupt.jar", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: sum([]byte("original content"))}},
+		{ID: "empty", Path: "empty.jar", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: sum([]byte("not empty"))}},
+		{ID: "truncated", Path: "truncated.jar", Repository: "audited", FileSize: 9, Checksum: repositoryItemAssetsChecksum{Sha1: sum([]byte("truncated"))}},
+		{ID: "missing", Path: "missing.jar", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: sum([]byte("missing"))}},
+		{ID: "blank", Path: "blank.txt", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: sum(nil)}},
+		{ID: "zeroed", Path: "zeroed.jar", Repository: "audited", FileSize: 6, Checksum: repositoryItemAssetsChecksum{Sha1: sum(nil)}},
+	}
+
+	var mu sync.Mutex
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path[1:] == restAssets {
+			if err := json.NewEncoder(w).Encode(listAssetsResponse{Items: assets}); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/repository/audited/")
+
+		mu.Lock()
+		downloads[path]++
+		mu.Unlock()
+
+		c, ok := content[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(c)
+	})
+
+	return rm, content, assets, mock.Close
+}
+
+func TestAuditRepositoryIntegrity(t *testing.T) {
+	downloads := make(map[string]int)
+	rm, _, assets, close := integrityTestRM(t, downloads)
+	defer close()
+
+	var results []IntegrityResult
+	report, err := AuditRepositoryIntegrity(context.Background(), rm, "audited", IntegrityAuditOptions{
+		Parallel: 2,
+		OnResult: func(r IntegrityResult) { results = append(results, r) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Audited != len(assets) || len(results) != len(assets) {
+		t.Errorf("Expected %d assets to be audited: %v", len(assets), report)
+	}
+
+	issues := make(map[string]string)
+	for _, i := range report.Issues {
+		issues[i.AssetID] = i.Issue
+	}
+
+	expected := map[string]string{
+		"corrupt":   IntegrityChecksumMismatch,
+		"empty":     IntegrityEmpty,
+		"truncated": IntegritySizeMismatch,
+		"missing":   IntegrityMissing,
+		"zeroed":    IntegrityEmpty,
+	}
+	for id, issue := range expected {
+		if issues[id] != issue {
+			t.Errorf("Expected %s to have issue %s but got '%s'", id, issue, issues[id])
+		}
+	}
+
+	if len(issues) != len(expected) {
+		t.Errorf("Unexpected issues: %v", report.Issues)
+	}
+}
+
+func TestAuditRepositoryIntegrityResumes(t *testing.T) {
+	downloads := make(map[string]int)
+	rm, _, assets, close := integrityTestRM(t, downloads)
+	defer close()
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a previous run audited two assets and was killed while recording a third
+	progress := filepath.Join(dir, "progress.jsonl")
+	previous := `{"id":"good","path":"good.jar","size":12}
+{"id":"missing","path":"missing.jar","size":0,"issue":"missing","detail":"404 Not Found"}
+{"id":"empt`
+	if err = ioutil.WriteFile(progress, []byte(previous), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := IntegrityAuditOptions{ProgressFile: progress}
+	report, err := AuditRepositoryIntegrity(context.Background(), rm, "audited", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Resumed != 2 || report.Audited != len(assets) || len(report.Issues) != 5 {
+		t.Errorf("Unexpected report: %v", report)
+	}
+
+	if downloads["good.jar"] != 0 || downloads["missing.jar"] != 0 || downloads["empty.jar"] != 1 {
+		t.Errorf("Expected only assets which were not audited to be downloaded: %v", downloads)
+	}
+
+	// a complete audit is not repeated
+	report, err = AuditRepositoryIntegrity(context.Background(), rm, "audited", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Resumed != len(assets) || len(report.Issues) != 5 || downloads["empty.jar"] != 1 {
+		t.Errorf("Expected audit to be complete: %v, %v", report, downloads)
+	}
+
+	ids := make([]string, 0)
+	recorded, err := openProgressLog(progress, func(line []byte) {
+		var r IntegrityResult
+		if err := json.Unmarshal(line, &r); err != nil {
+			t.Error(err)
+		}
+		ids = append(ids, r.AssetID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded.Close()
+
+	sort.Strings(ids)
+	if strings.Join(ids, ",") != "blank,corrupt,empty,good,missing,truncated,zeroed" {
+		t.Errorf("Unexpected progress: %v", ids)
+	}
+}
+
+func TestAuditRepositoryIntegrityRetriesErrors(t *testing.T) {
+	content := []byte("flaky content")
+	s := sha1.Sum(content)
+	assets := []RepositoryItemAsset{
+		{ID: "flaky", Path: "flaky.jar", Repository: "audited", Checksum: repositoryItemAssetsChecksum{Sha1: hex.EncodeToString(s[:])}},
+	}
+
+	downloads := 0
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path[1:] == restAssets {
+			if err := json.NewEncoder(w).Encode(listAssetsResponse{Items: assets}); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+
+		downloads++
+		if downloads == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(content)
+	})
+	defer mock.Close()
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	options := IntegrityAuditOptions{ProgressFile: filepath.Join(dir, "progress.jsonl")}
+	report, err := AuditRepositoryIntegrity(context.Background(), rm, "audited", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 1 || report.Issues[0].Issue != IntegrityError {
+		t.Errorf("Expected the server error to be reported: %v", report)
+	}
+
+	// the asset which failed is audited again when resuming
+	report, err = AuditRepositoryIntegrity(context.Background(), rm, "audited", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Resumed != 0 || report.Audited != 1 || len(report.Issues) != 0 || downloads != 2 {
+		t.Errorf("Expected the asset to be audited again: %v, %d downloads", report, downloads)
+	}
+}
+
+func TestAuditRepositoryIntegrityCanceled(t *testing.T) {
+	downloads := make(map[string]int)
+	rm, _, _, close := integrityTestRM(t, downloads)
+	defer close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := AuditRepositoryIntegrity(ctx, rm, "audited", IntegrityAuditOptions{}); err == nil {
+		t.Error("Expected error auditing with a canceled context")
+	}
+}
@@ -0,0 +1,315 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+const (
+	restLdap            = "service/rest/v1/security/ldap"
+	restLdapChangeOrder = "service/rest/v1/security/ldap/change-order"
+
+	extDirectLdapAction = "ldap_LdapServer"
+)
+
+// Enumerates the authentication schemes used to bind to an LDAP server
+const (
+	LdapAuthNone      = "NONE"
+	LdapAuthSimple    = "SIMPLE"
+	LdapAuthDigestMD5 = "DIGEST_MD5"
+	LdapAuthCramMD5   = "CRAM_MD5"
+)
+
+// Enumerates the ways groups can be mapped from an LDAP server
+const (
+	LdapGroupTypeStatic  = "static"
+	LdapGroupTypeDynamic = "dynamic"
+)
+
+// LdapServer encapsulates the configuration of an LDAP server connection and its user and group mapping
+type LdapServer struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Order int    `json:"order,omitempty"`
+
+	// Connection
+	Protocol                    string `json:"protocol"`
+	UseTrustStore               bool   `json:"useTrustStore"`
+	Host                        string `json:"host"`
+	Port                        int    `json:"port"`
+	SearchBase                  string `json:"searchBase"`
+	AuthScheme                  string `json:"authScheme"`
+	AuthRealm                   string `json:"authRealm,omitempty"`
+	AuthUsername                string `json:"authUsername,omitempty"`
+	AuthPassword                string `json:"authPassword,omitempty"`
+	ConnectionTimeoutSeconds    int    `json:"connectionTimeoutSeconds"`
+	ConnectionRetryDelaySeconds int    `json:"connectionRetryDelaySeconds"`
+	MaxIncidentsCount           int    `json:"maxIncidentsCount"`
+
+	// User mapping
+	UserBaseDn                string `json:"userBaseDn,omitempty"`
+	UserSubtree               bool   `json:"userSubtree"`
+	UserObjectClass           string `json:"userObjectClass"`
+	UserLdapFilter            string `json:"userLdapFilter,omitempty"`
+	UserIDAttribute           string `json:"userIdAttribute"`
+	UserRealNameAttribute     string `json:"userRealNameAttribute"`
+	UserEmailAddressAttribute string `json:"userEmailAddressAttribute"`
+	UserPasswordAttribute     string `json:"userPasswordAttribute,omitempty"`
+
+	// Group mapping
+	LdapGroupsAsRoles     bool   `json:"ldapGroupsAsRoles"`
+	GroupType             string `json:"groupType,omitempty"`
+	GroupBaseDn           string `json:"groupBaseDn,omitempty"`
+	GroupSubtree          bool   `json:"groupSubtree"`
+	GroupObjectClass      string `json:"groupObjectClass,omitempty"`
+	GroupIDAttribute      string `json:"groupIdAttribute,omitempty"`
+	GroupMemberAttribute  string `json:"groupMemberAttribute,omitempty"`
+	GroupMemberFormat     string `json:"groupMemberFormat,omitempty"`
+	UserMemberOfAttribute string `json:"userMemberOfAttribute,omitempty"`
+}
+
+// LdapMappedUser is a user, and its group memberships, as RM would map it from an LDAP server
+type LdapMappedUser struct {
+	Username   string   `json:"username"`
+	RealName   string   `json:"realName"`
+	Email      string   `json:"email"`
+	Membership []string `json:"membership"`
+}
+
+// ldapServerXO is the representation of an LDAP server used by the Ext.Direct API
+type ldapServerXO struct {
+	ID                        string `json:"id,omitempty"`
+	Name                      string `json:"name"`
+	Protocol                  string `json:"protocol"`
+	UseTrustStore             bool   `json:"useTrustStore"`
+	Host                      string `json:"host"`
+	Port                      int    `json:"port"`
+	SearchBase                string `json:"searchBase"`
+	AuthScheme                string `json:"authScheme"`
+	AuthRealm                 string `json:"authRealm,omitempty"`
+	AuthUsername              string `json:"authUsername,omitempty"`
+	AuthPassword              string `json:"authPassword,omitempty"`
+	ConnectionTimeout         int    `json:"connectionTimeout"`
+	ConnectionRetryDelay      int    `json:"connectionRetryDelay"`
+	MaxIncidentsCount         int    `json:"maxIncidentsCount"`
+	UserBaseDn                string `json:"userBaseDn,omitempty"`
+	UserSubtree               bool   `json:"userSubtree"`
+	UserObjectClass           string `json:"userObjectClass"`
+	UserLdapFilter            string `json:"userLdapFilter,omitempty"`
+	UserIDAttribute           string `json:"userIdAttribute"`
+	UserRealNameAttribute     string `json:"userRealNameAttribute"`
+	UserEmailAddressAttribute string `json:"userEmailAddressAttribute"`
+	UserPasswordAttribute     string `json:"userPasswordAttribute,omitempty"`
+	LdapGroupsAsRoles         bool   `json:"ldapGroupsAsRoles"`
+	GroupType                 string `json:"groupType,omitempty"`
+	GroupBaseDn               string `json:"groupBaseDn,omitempty"`
+	GroupSubtree              bool   `json:"groupSubtree"`
+	GroupObjectClass          string `json:"groupObjectClass,omitempty"`
+	GroupIDAttribute          string `json:"groupIdAttribute,omitempty"`
+	GroupMemberAttribute      string `json:"groupMemberAttribute,omitempty"`
+	GroupMemberFormat         string `json:"groupMemberFormat,omitempty"`
+	UserMemberOfAttribute     string `json:"userMemberOfAttribute,omitempty"`
+}
+
+func (s LdapServer) xo() ldapServerXO {
+	return ldapServerXO{
+		ID:                        s.ID,
+		Name:                      s.Name,
+		Protocol:                  s.Protocol,
+		UseTrustStore:             s.UseTrustStore,
+		Host:                      s.Host,
+		Port:                      s.Port,
+		SearchBase:                s.SearchBase,
+		AuthScheme:                s.AuthScheme,
+		AuthRealm:                 s.AuthRealm,
+		AuthUsername:              s.AuthUsername,
+		AuthPassword:              s.AuthPassword,
+		ConnectionTimeout:         s.ConnectionTimeoutSeconds,
+		ConnectionRetryDelay:      s.ConnectionRetryDelaySeconds,
+		MaxIncidentsCount:         s.MaxIncidentsCount,
+		UserBaseDn:                s.UserBaseDn,
+		UserSubtree:               s.UserSubtree,
+		UserObjectClass:           s.UserObjectClass,
+		UserLdapFilter:            s.UserLdapFilter,
+		UserIDAttribute:           s.UserIDAttribute,
+		UserRealNameAttribute:     s.UserRealNameAttribute,
+		UserEmailAddressAttribute: s.UserEmailAddressAttribute,
+		UserPasswordAttribute:     s.UserPasswordAttribute,
+		LdapGroupsAsRoles:         s.LdapGroupsAsRoles,
+		GroupType:                 s.GroupType,
+		GroupBaseDn:               s.GroupBaseDn,
+		GroupSubtree:              s.GroupSubtree,
+		GroupObjectClass:          s.GroupObjectClass,
+		GroupIDAttribute:          s.GroupIDAttribute,
+		GroupMemberAttribute:      s.GroupMemberAttribute,
+		GroupMemberFormat:         s.GroupMemberFormat,
+		UserMemberOfAttribute:     s.UserMemberOfAttribute,
+	}
+}
+
+// GetLdapServers returns the configured LDAP servers in the order in which they are consulted
+func GetLdapServers(rm RM) ([]LdapServer, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list LDAP servers: %v", err)
+	}
+
+	body, resp, err := rm.Get(restLdap)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	servers := make([]LdapServer, 0)
+	if err := json.Unmarshal(body, &servers); err != nil {
+		return nil, doError(err)
+	}
+
+	sort.SliceStable(servers, func(i, j int) bool { return servers[i].Order < servers[j].Order })
+
+	return servers, nil
+}
+
+// GetLdapServerByName returns the configuration of the named LDAP server
+func GetLdapServerByName(rm RM, name string) (LdapServer, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find LDAP server '%s': %v", name, err)
+	}
+
+	var server LdapServer
+
+	url := fmt.Sprintf("%s/%s", restLdap, name)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return server, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &server); err != nil {
+		return server, doError(err)
+	}
+
+	return server, nil
+}
+
+// CreateLdapServer adds a new LDAP server configuration
+func CreateLdapServer(rm RM, server LdapServer) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not create LDAP server '%s': %v", server.Name, err)
+	}
+
+	buf, err := json.Marshal(server)
+	if err != nil {
+		return doError(err)
+	}
+
+	if _, resp, err := rm.Post(restLdap, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusCreated) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// UpdateLdapServer replaces the configuration of the named LDAP server
+func UpdateLdapServer(rm RM, name string, server LdapServer) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not update LDAP server '%s': %v", name, err)
+	}
+
+	buf, err := json.Marshal(server)
+	if err != nil {
+		return doError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s", restLdap, name)
+	if _, resp, err := rm.Put(url, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DeleteLdapServerByName removes the named LDAP server configuration
+func DeleteLdapServerByName(rm RM, name string) error {
+	url := fmt.Sprintf("%s/%s", restLdap, name)
+
+	if resp, err := rm.Del(url); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("LDAP server not deleted '%s': %v", name, err)
+	}
+
+	return nil
+}
+
+// SetLdapServersOrder changes the order in which the named LDAP servers are consulted
+func SetLdapServersOrder(rm RM, names []string) error {
+	buf, err := json.Marshal(names)
+	if err != nil {
+		return fmt.Errorf("could not marshal LDAP server order: %v", err)
+	}
+
+	if _, resp, err := rm.Post(restLdapChangeOrder, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("LDAP server order not changed: %v", err)
+	}
+
+	return nil
+}
+
+// VerifyLdapConnection checks that RM can connect and bind to the LDAP server with the given settings
+func VerifyLdapConnection(rm RM, server LdapServer) error {
+	if _, err := extDirect(rm, extDirectLdapAction, "verifyConnection", server.xo()); err != nil {
+		return fmt.Errorf("could not verify connection to LDAP server '%s': %v", server.Name, err)
+	}
+
+	return nil
+}
+
+// VerifyLdapUserMapping returns the users, and their group memberships, which RM would map with the given settings
+func VerifyLdapUserMapping(rm RM, server LdapServer) ([]LdapMappedUser, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not verify user mapping of LDAP server '%s': %v", server.Name, err)
+	}
+
+	data, err := extDirect(rm, extDirectLdapAction, "verifyUserMapping", server.xo())
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	users := make([]LdapMappedUser, 0)
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, doError(err)
+	}
+
+	return users, nil
+}
+
+// VerifyLdapGroupMapping returns the groups which RM would map with the given settings along with their members
+func VerifyLdapGroupMapping(rm RM, server LdapServer) (map[string][]string, error) {
+	users, err := VerifyLdapUserMapping(rm, server)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]string)
+	for _, u := range users {
+		for _, g := range u.Membership {
+			groups[g] = append(groups[g], u.Username)
+		}
+	}
+
+	return groups, nil
+}
+
+// VerifyLdapLogin checks that the given credentials can log in with the given settings
+func VerifyLdapLogin(rm RM, server LdapServer, username, password string) error {
+	_, err := extDirect(rm, extDirectLdapAction, "verifyLogin",
+		server.xo(),
+		base64.StdEncoding.EncodeToString([]byte(username)),
+		base64.StdEncoding.EncodeToString([]byte(password)),
+	)
+	if err != nil {
+		return fmt.Errorf("could not verify login of '%s' to LDAP server '%s': %v", username, server.Name, err)
+	}
+
+	return nil
+}
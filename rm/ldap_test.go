@@ -0,0 +1,321 @@
+package nexusrm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dummyLdapServers = []LdapServer{
+	{
+		ID: "ldap1", Name: "corp", Order: 1, Protocol: "ldaps", Host: "ldap.corp.example", Port: 636,
+		SearchBase: "dc=corp,dc=example", AuthScheme: LdapAuthSimple, AuthUsername: "cn=nexus", ConnectionTimeoutSeconds: 30,
+		ConnectionRetryDelaySeconds: 300, MaxIncidentsCount: 3, UserBaseDn: "ou=people", UserObjectClass: "inetOrgPerson",
+		UserIDAttribute: "uid", UserRealNameAttribute: "cn", UserEmailAddressAttribute: "mail",
+		LdapGroupsAsRoles: true, GroupType: LdapGroupTypeStatic, GroupBaseDn: "ou=groups", GroupObjectClass: "groupOfNames",
+		GroupIDAttribute: "cn", GroupMemberAttribute: "member", GroupMemberFormat: "uid=${username},ou=people,dc=corp,dc=example",
+	},
+	{
+		ID: "ldap2", Name: "partners", Order: 0, Protocol: "ldap", Host: "ldap.partners.example", Port: 389,
+		SearchBase: "dc=partners,dc=example", AuthScheme: LdapAuthNone, ConnectionTimeoutSeconds: 30,
+		ConnectionRetryDelaySeconds: 300, MaxIncidentsCount: 3, UserObjectClass: "inetOrgPerson",
+		UserIDAttribute: "uid", UserRealNameAttribute: "cn", UserEmailAddressAttribute: "mail",
+		LdapGroupsAsRoles: true, GroupType: LdapGroupTypeDynamic, UserMemberOfAttribute: "memberOf",
+	},
+}
+
+var dummyLdapUsers = []LdapMappedUser{
+	{Username: "alice", RealName: "Alice", Email: "alice@corp.example", Membership: []string{"devs", "admins"}},
+	{Username: "bob", RealName: "Bob", Email: "bob@corp.example", Membership: []string{"devs"}},
+}
+
+func ldapTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	getServerByName := func(name string) (int, bool) {
+		for i, s := range dummyLdapServers {
+			if s.Name == name {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restLdap), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		resp, err := json.Marshal(dummyLdapServers)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet:
+		if i, ok := getServerByName(name); ok {
+			resp, err := json.Marshal(dummyLdapServers[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost && r.URL.Path[1:] == restLdapChangeOrder:
+		var names []string
+		if err := json.Unmarshal(body, &names); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for order, n := range names {
+			i, ok := getServerByName(n)
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			dummyLdapServers[i].Order = order
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost:
+		var server LdapServer
+		if err := json.Unmarshal(body, &server); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		server.Order = len(dummyLdapServers)
+		dummyLdapServers = append(dummyLdapServers, server)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		i, ok := getServerByName(name)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var server LdapServer
+		if err := json.Unmarshal(body, &server); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyLdapServers[i] = server
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if i, ok := getServerByName(name); ok {
+			dummyLdapServers = append(dummyLdapServers[:i], dummyLdapServers[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func ldapExtDirectTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Action string            `json:"action"`
+		Method string            `json:"method"`
+		Data   []json.RawMessage `json:"data"`
+		TID    int               `json:"tid"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Action != extDirectLdapAction || len(req.Data) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var server ldapServerXO
+	if err := json.Unmarshal(req.Data[0], &server); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var resp extDirectResponse
+	resp.TID = req.TID
+	resp.Type = "rpc"
+
+	switch req.Method {
+	case "verifyConnection":
+		resp.Result.Success = server.Host == "ldap.corp.example"
+		resp.Result.Message = "Failed to connect to " + server.Host
+	case "verifyUserMapping":
+		data, err := json.Marshal(dummyLdapUsers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Result.Success = true
+		resp.Result.Data = data
+	case "verifyLogin":
+		var username, password string
+		json.Unmarshal(req.Data[1], &username)
+		json.Unmarshal(req.Data[2], &password)
+		resp.Result.Success = username == base64.StdEncoding.EncodeToString([]byte("alice")) &&
+			password == base64.StdEncoding.EncodeToString([]byte("wonderland"))
+		resp.Result.Message = "Invalid credentials"
+	default:
+		resp.Type = "exception"
+		resp.Message = "unknown method"
+	}
+
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Fprintln(w, string(buf))
+}
+
+func ldapTestRM(t *testing.T) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path[1:] == restExtDirect:
+			ldapExtDirectTestFunc(t, w, r)
+		default:
+			ldapTestFunc(t, w, r)
+		}
+	})
+}
+
+func TestGetLdapServers(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	servers, err := GetLdapServers(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(servers) != len(dummyLdapServers) {
+		t.Fatalf("Received %d servers instead of %d", len(servers), len(dummyLdapServers))
+	}
+
+	for i := 1; i < len(servers); i++ {
+		if servers[i-1].Order > servers[i].Order {
+			t.Errorf("Servers not sorted by order: %v", servers)
+		}
+	}
+}
+
+func TestCreateUpdateDeleteLdapServer(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	server := dummyLdapServers[0]
+	server.ID = ""
+	server.Name = "test"
+
+	if err := CreateLdapServer(rm, server); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Host = "ldap2.corp.example"
+	if err := UpdateLdapServer(rm, server.Name, server); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetLdapServerByName(rm, server.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, server) {
+		t.Errorf("Did not receive updated LDAP server: %v", got)
+	}
+
+	if err := DeleteLdapServerByName(rm, server.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetLdapServerByName(rm, server.Name); err == nil {
+		t.Error("LDAP server not deleted")
+	}
+}
+
+func TestSetLdapServersOrder(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	if err := SetLdapServersOrder(rm, []string{"corp", "partners"}); err != nil {
+		t.Fatal(err)
+	}
+
+	servers, err := GetLdapServers(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if servers[0].Name != "corp" || servers[1].Name != "partners" {
+		t.Errorf("LDAP servers not reordered: %v", servers)
+	}
+}
+
+func TestVerifyLdapConnection(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	if err := VerifyLdapConnection(rm, dummyLdapServers[0]); err != nil {
+		t.Error(err)
+	}
+
+	bad := dummyLdapServers[0]
+	bad.Host = "nowhere.example"
+	if err := VerifyLdapConnection(rm, bad); err == nil {
+		t.Error("Expected connection verification to fail")
+	}
+}
+
+func TestVerifyLdapMapping(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	users, err := VerifyLdapUserMapping(rm, dummyLdapServers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(users, dummyLdapUsers) {
+		t.Errorf("Did not receive expected users: %v", users)
+	}
+
+	groups, err := VerifyLdapGroupMapping(rm, dummyLdapServers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{"devs": {"alice", "bob"}, "admins": {"alice"}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected groups %v but got %v", expected, groups)
+	}
+}
+
+func TestVerifyLdapLogin(t *testing.T) {
+	rm, mock := ldapTestRM(t)
+	defer mock.Close()
+
+	if err := VerifyLdapLogin(rm, dummyLdapServers[0], "alice", "wonderland"); err != nil {
+		t.Error(err)
+	}
+
+	if err := VerifyLdapLogin(rm, dummyLdapServers[0], "alice", "oops"); err == nil {
+		t.Error("Expected login verification to fail")
+	}
+}
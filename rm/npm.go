@@ -0,0 +1,430 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	nexus "github.com/overag3/gonexus"
+	"github.com/overag3/gonexus/versions"
+)
+
+const npmLatestTag = "latest"
+
+// NpmDist describes the tarball of a version of an npm package
+type NpmDist struct {
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// NpmPackageVersion is the manifest of a version of an npm package, as listed in its packument
+type NpmPackageVersion struct {
+	ID          string  `json:"_id,omitempty"`
+	Name        string  `json:"name"`
+	Version     string  `json:"version"`
+	Description string  `json:"description,omitempty"`
+	Deprecated  string  `json:"deprecated,omitempty"`
+	Dist        NpmDist `json:"dist"`
+}
+
+// NpmPackument is the document of the npm registry which lists every version of a package
+type NpmPackument struct {
+	ID          string                       `json:"_id"`
+	Rev         string                       `json:"_rev,omitempty"`
+	Name        string                       `json:"name"`
+	Description string                       `json:"description,omitempty"`
+	DistTags    map[string]string            `json:"dist-tags"`
+	Versions    map[string]NpmPackageVersion `json:"versions"`
+	Time        map[string]string            `json:"time,omitempty"`
+}
+
+// NpmPackageName returns the name of an npm package, prefixed by its scope if it has one.
+// The scope can be given with or without its leading @.
+func NpmPackageName(scope, name string) string {
+	if scope = strings.TrimPrefix(scope, "@"); scope == "" {
+		return name
+	}
+	return "@" + scope + "/" + name
+}
+
+// NpmComponentPackage returns the name of the npm package of a component, whose group is its scope
+func NpmComponentPackage(c RepositoryItem) string {
+	return NpmPackageName(c.Group, c.Name)
+}
+
+// npmUnscopedName returns the name of a package without its scope
+func npmUnscopedName(pkg string) string {
+	if strings.HasPrefix(pkg, "@") {
+		return path.Base(pkg)
+	}
+	return pkg
+}
+
+// npmPackageEndpoint returns the endpoint of a package in the repository,
+// whose scoped name is escaped as the npm client does
+func npmPackageEndpoint(repo, pkg string) string {
+	return fmt.Sprintf("repository/%s/%s", repo, strings.Replace(pkg, "/", "%2f", 1))
+}
+
+// npmTarballPath returns the path of the tarball of a version of a package in the repository
+func npmTarballPath(pkg, version string) string {
+	return fmt.Sprintf("%s/-/%s-%s.tgz", pkg, npmUnscopedName(pkg), version)
+}
+
+// npmRequest sends a request to the npm registry of a repository with an optional JSON payload
+// and returns the body of any successful response
+func npmRequest(rm RM, method, endpoint string, payload interface{}) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		buf, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(buf)
+	}
+
+	req, err := rm.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, errors.New(resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// getNpmPackumentDocument returns the packument of a package with every one of its fields,
+// so that it can be updated without losing those which NpmPackument does not describe
+func getNpmPackumentDocument(rm RM, repo, pkg string) (map[string]interface{}, error) {
+	body, err := npmRequest(rm, http.MethodGet, npmPackageEndpoint(repo, pkg), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// putNpmPackumentDocument replaces the packument of a package, at the revision it was read at
+func putNpmPackumentDocument(rm RM, repo, pkg string, doc map[string]interface{}) error {
+	endpoint := npmPackageEndpoint(repo, pkg)
+	if rev, ok := doc["_rev"].(string); ok && rev != "" {
+		endpoint += "/-rev/" + rev
+	}
+
+	_, err := npmRequest(rm, http.MethodPut, endpoint, doc)
+	return err
+}
+
+// GetNpmPackument returns the packument of a package of an npm repository
+func GetNpmPackument(rm RM, repo, pkg string) (NpmPackument, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not retrieve packument of '%s' from '%s': %v", pkg, repo, err)
+	}
+
+	body, err := npmRequest(rm, http.MethodGet, npmPackageEndpoint(repo, pkg), nil)
+	if err != nil {
+		return NpmPackument{}, doError(err)
+	}
+
+	var packument NpmPackument
+	if err := json.Unmarshal(body, &packument); err != nil {
+		return NpmPackument{}, doError(err)
+	}
+
+	return packument, nil
+}
+
+// readNpmManifest returns the package.json file of an npm tarball
+func readNpmManifest(tarball []byte) (map[string]interface{}, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("tarball has no package.json")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the files of the package are in a single top-level directory, usually named package
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if i := strings.Index(name, "/"); i < 0 || name[i+1:] != "package.json" {
+			continue
+		}
+
+		manifest := make(map[string]interface{})
+		dec := json.NewDecoder(tr)
+		dec.UseNumber()
+		if err := dec.Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("could not parse package.json: %v", err)
+		}
+		return manifest, nil
+	}
+}
+
+// NpmPublish publishes an npm package tarball to an npm hosted repository, as npm publish does, with the
+// package.json file of the tarball as the manifest of the version. The version is tagged with the given
+// dist-tags, or with the latest tag if none is given. Returns the manifest of the published version.
+func NpmPublish(rm RM, repo string, tarball io.Reader, distTags ...string) (NpmPackageVersion, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not publish npm package to '%s': %v", repo, err)
+	}
+
+	content, err := ioutil.ReadAll(tarball)
+	if err != nil {
+		return NpmPackageVersion{}, doError(err)
+	}
+
+	manifest, err := readNpmManifest(content)
+	if err != nil {
+		return NpmPackageVersion{}, doError(err)
+	}
+
+	pkg, _ := manifest["name"].(string)
+	version, _ := manifest["version"].(string)
+	if pkg == "" || version == "" {
+		return NpmPackageVersion{}, doError(errors.New("package.json has no name or version"))
+	}
+
+	if len(distTags) == 0 {
+		distTags = []string{npmLatestTag}
+	}
+	tags := make(map[string]string)
+	for _, tag := range distTags {
+		tags[tag] = version
+	}
+
+	shasum := sha1.Sum(content)
+	integrity := sha512.Sum512(content)
+	manifest["_id"] = pkg + "@" + version
+	manifest["dist"] = NpmDist{
+		Tarball:   fmt.Sprintf("%s/repository/%s/%s", rm.Info().Host, repo, npmTarballPath(pkg, version)),
+		Shasum:    hex.EncodeToString(shasum[:]),
+		Integrity: "sha512-" + base64.StdEncoding.EncodeToString(integrity[:]),
+	}
+
+	doc := map[string]interface{}{
+		"_id":         pkg,
+		"name":        pkg,
+		"description": manifest["description"],
+		"dist-tags":   tags,
+		"versions":    map[string]interface{}{version: manifest},
+		"_attachments": map[string]interface{}{
+			fmt.Sprintf("%s-%s.tgz", pkg, version): map[string]interface{}{
+				"content_type": "application/octet-stream",
+				"data":         base64.StdEncoding.EncodeToString(content),
+				"length":       len(content),
+			},
+		},
+	}
+
+	if _, err := npmRequest(rm, http.MethodPut, npmPackageEndpoint(repo, pkg), doc); err != nil {
+		return NpmPackageVersion{}, doError(fmt.Errorf("%s@%s: %v", pkg, version, err))
+	}
+
+	buf, err := json.Marshal(manifest)
+	if err != nil {
+		return NpmPackageVersion{}, doError(err)
+	}
+
+	var published NpmPackageVersion
+	if err := json.Unmarshal(buf, &published); err != nil {
+		return NpmPackageVersion{}, doError(err)
+	}
+
+	return published, nil
+}
+
+func npmDistTagsEndpoint(repo, pkg string) string {
+	return fmt.Sprintf("repository/%s/-/package/%s/dist-tags", repo, strings.Replace(pkg, "/", "%2f", 1))
+}
+
+// GetNpmDistTags returns the dist-tags of a package of an npm repository with the versions they point to
+func GetNpmDistTags(rm RM, repo, pkg string) (map[string]string, error) {
+	body, err := npmRequest(rm, http.MethodGet, npmDistTagsEndpoint(repo, pkg), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve dist-tags of '%s' from '%s': %v", pkg, repo, err)
+	}
+
+	tags := make(map[string]string)
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("could not retrieve dist-tags of '%s' from '%s': %v", pkg, repo, err)
+	}
+
+	return tags, nil
+}
+
+// AddNpmDistTag points a dist-tag of a package of an npm hosted repository to one of its versions
+func AddNpmDistTag(rm RM, repo, pkg, tag, version string) error {
+	if _, err := npmRequest(rm, http.MethodPut, npmDistTagsEndpoint(repo, pkg)+"/"+tag, version); err != nil {
+		return fmt.Errorf("could not tag %s@%s as '%s' in '%s': %v", pkg, version, tag, repo, err)
+	}
+	return nil
+}
+
+// RemoveNpmDistTag removes a dist-tag of a package of an npm hosted repository.
+// As with the npm client, the latest tag cannot be removed.
+func RemoveNpmDistTag(rm RM, repo, pkg, tag string) error {
+	if tag == npmLatestTag {
+		return fmt.Errorf("could not remove dist-tag '%s' of '%s': every package has a latest tag", tag, pkg)
+	}
+
+	if _, err := npmRequest(rm, http.MethodDelete, npmDistTagsEndpoint(repo, pkg)+"/"+tag, nil); err != nil {
+		return fmt.Errorf("could not remove dist-tag '%s' of '%s' in '%s': %v", tag, pkg, repo, err)
+	}
+	return nil
+}
+
+// DeprecateNpmPackage marks the versions of a package of an npm hosted repository which are in the given
+// semver range as deprecated with the given message, as npm deprecate does. An empty range deprecates
+// every version and an empty message removes the deprecation. Returns the versions which were updated.
+func DeprecateNpmPackage(rm RM, repo, pkg, versionRange, message string) ([]string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not deprecate '%s' in '%s': %v", pkg, repo, err)
+	}
+
+	r, err := versions.ParseRange(versions.SemVer, versionRange)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	doc, err := getNpmPackumentDocument(rm, repo, pkg)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	vs, _ := doc["versions"].(map[string]interface{})
+
+	updated := make([]string, 0)
+	for version, v := range vs {
+		manifest, ok := v.(map[string]interface{})
+		if !ok || (versionRange != "" && !r.Contains(version)) {
+			continue
+		}
+
+		if message == "" {
+			delete(manifest, "deprecated")
+		} else {
+			manifest["deprecated"] = message
+		}
+		updated = append(updated, version)
+	}
+
+	if len(updated) == 0 {
+		return nil, doError(fmt.Errorf("no version in range '%s'", versionRange))
+	}
+	versions.Sort(versions.SemVer, updated)
+
+	if err := putNpmPackumentDocument(rm, repo, pkg, doc); err != nil {
+		return nil, doError(err)
+	}
+
+	return updated, nil
+}
+
+// UnpublishNpmPackage removes a version of a package from an npm hosted repository, as npm unpublish does,
+// by removing it from the packument and then deleting its tarball. Dist-tags which pointed to the version are
+// removed, and the latest tag is moved to the highest remaining version. An empty version unpublishes the
+// whole package, as does unpublishing its only version.
+func UnpublishNpmPackage(rm RM, repo, pkg, version string) error {
+	doError := func(err error) error {
+		if version == "" {
+			return fmt.Errorf("could not unpublish '%s' from '%s': %v", pkg, repo, err)
+		}
+		return fmt.Errorf("could not unpublish %s@%s from '%s': %v", pkg, version, repo, err)
+	}
+
+	doc, err := getNpmPackumentDocument(rm, repo, pkg)
+	if err != nil {
+		return doError(err)
+	}
+	rev, _ := doc["_rev"].(string)
+
+	vs, _ := doc["versions"].(map[string]interface{})
+	if version != "" {
+		if _, ok := vs[version]; !ok {
+			return doError(errors.New("version not found"))
+		}
+	}
+
+	if version == "" || len(vs) == 1 {
+		endpoint := npmPackageEndpoint(repo, pkg)
+		if rev != "" {
+			endpoint += "/-rev/" + rev
+		}
+		if _, err := npmRequest(rm, http.MethodDelete, endpoint, nil); err != nil {
+			return doError(err)
+		}
+		return nil
+	}
+
+	delete(vs, version)
+	if t, ok := doc["time"].(map[string]interface{}); ok {
+		delete(t, version)
+	}
+
+	if tags, ok := doc["dist-tags"].(map[string]interface{}); ok {
+		for tag, v := range tags {
+			if v == version {
+				delete(tags, tag)
+			}
+		}
+
+		if _, ok := tags[npmLatestTag]; !ok {
+			remaining := make([]string, 0, len(vs))
+			for v := range vs {
+				remaining = append(remaining, v)
+			}
+			tags[npmLatestTag], _ = versions.Latest(versions.SemVer, remaining)
+		}
+	}
+
+	if err := putNpmPackumentDocument(rm, repo, pkg, doc); err != nil {
+		return doError(err)
+	}
+
+	endpoint := fmt.Sprintf("repository/%s/%s", repo, npmTarballPath(pkg, version))
+	if rev != "" {
+		endpoint += "/-rev/" + rev
+	}
+	if _, err := npmRequest(rm, http.MethodDelete, endpoint, nil); err != nil {
+		return doError(err)
+	}
+
+	return nil
+}
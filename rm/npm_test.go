@@ -0,0 +1,368 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeNpmRegistry serves the packuments of the npm-hosted repository
+type fakeNpmRegistry struct {
+	mu         sync.Mutex
+	packuments map[string]map[string]interface{}
+	tarballs   map[string]bool
+	revs       int
+}
+
+func newFakeNpmRegistry(t *testing.T) (*fakeNpmRegistry, RM, func()) {
+	registry := &fakeNpmRegistry{packuments: make(map[string]map[string]interface{}), tarballs: make(map[string]bool)}
+
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+
+		// the npm client escapes the slash of scoped package names
+		p := strings.Replace(strings.TrimPrefix(r.URL.EscapedPath(), "/repository/npm-hosted/"), "%2f", "/", -1)
+
+		status := registry.serve(t, r, p, w)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+		}
+	})
+
+	return registry, rm, mock.Close
+}
+
+func (f *fakeNpmRegistry) nextRev() string {
+	f.revs++
+	return fmt.Sprintf("%d-dummy", f.revs)
+}
+
+func (f *fakeNpmRegistry) serve(t *testing.T, r *http.Request, p string, w http.ResponseWriter) int {
+	var payload interface{}
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if strings.HasPrefix(p, "-/package/") {
+		parts := strings.Split(strings.TrimPrefix(p, "-/package/"), "/dist-tags")
+		doc, ok := f.packuments[parts[0]]
+		if !ok {
+			return http.StatusNotFound
+		}
+		tags := doc["dist-tags"].(map[string]interface{})
+
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(tags)
+		case http.MethodPut:
+			tags[strings.TrimPrefix(parts[1], "/")] = payload
+		case http.MethodDelete:
+			delete(tags, strings.TrimPrefix(parts[1], "/"))
+		}
+		return http.StatusOK
+	}
+
+	rev := ""
+	if i := strings.Index(p, "/-rev/"); i >= 0 {
+		p, rev = p[:i], p[i+len("/-rev/"):]
+	}
+
+	if i := strings.Index(p, "/-/"); i >= 0 {
+		if r.Method != http.MethodDelete || !f.tarballs[p] {
+			return http.StatusNotFound
+		}
+		delete(f.tarballs, p)
+		return http.StatusOK
+	}
+
+	doc, exists := f.packuments[p]
+	if exists && rev != "" && doc["_rev"] != rev {
+		return http.StatusConflict
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			return http.StatusNotFound
+		}
+		json.NewEncoder(w).Encode(doc)
+	case http.MethodPut:
+		published := payload.(map[string]interface{})
+		if rev != "" {
+			// an update of the whole packument
+			published["_rev"] = f.nextRev()
+			f.packuments[p] = published
+			return http.StatusOK
+		}
+
+		if !exists {
+			doc = map[string]interface{}{"_id": p, "name": p, "dist-tags": map[string]interface{}{}, "versions": map[string]interface{}{}}
+			f.packuments[p] = doc
+		}
+		for v, manifest := range published["versions"].(map[string]interface{}) {
+			if _, ok := doc["versions"].(map[string]interface{})[v]; ok {
+				return http.StatusBadRequest
+			}
+			doc["versions"].(map[string]interface{})[v] = manifest
+			f.tarballs[npmTarballPath(p, v)] = true
+		}
+		for tag, v := range published["dist-tags"].(map[string]interface{}) {
+			doc["dist-tags"].(map[string]interface{})[tag] = v
+		}
+		if len(published["_attachments"].(map[string]interface{})) != 1 {
+			return http.StatusBadRequest
+		}
+		doc["_rev"] = f.nextRev()
+	case http.MethodDelete:
+		if !exists {
+			return http.StatusNotFound
+		}
+		delete(f.packuments, p)
+	}
+
+	return http.StatusOK
+}
+
+func dummyNpmTarball(t *testing.T, name, version string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	manifest := fmt.Sprintf(`{"name":%q,"version":%q,"description":"dummy package","license":"MIT","dependencies":{"left-pad":"^1.0.0"}}`, name, version)
+	for file, content := range map[string]string{"package/package.json": manifest, "package/index.js": "module.exports = 1\n"} {
+		if err := tw.WriteHeader(&tar.Header{Name: file, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+func TestNpmPackageName(t *testing.T) {
+	tests := []struct {
+		scope, name, expected string
+	}{
+		{"", "left-pad", "left-pad"},
+		{"angular", "core", "@angular/core"},
+		{"@angular", "core", "@angular/core"},
+	}
+
+	for _, test := range tests {
+		if actual := NpmPackageName(test.scope, test.name); actual != test.expected {
+			t.Errorf("Expected %s but got %s", test.expected, actual)
+		}
+	}
+
+	if actual := NpmComponentPackage(RepositoryItem{Group: "angular", Name: "core"}); actual != "@angular/core" {
+		t.Errorf("Unexpected package name %s", actual)
+	}
+
+	if query := NewQueryBuilder().NpmScope("@angular").Build(); query != "&npm.scope=angular" {
+		t.Errorf("Unexpected query %s", query)
+	}
+}
+
+func TestNpmPublish(t *testing.T) {
+	registry, rm, done := newFakeNpmRegistry(t)
+	defer done()
+
+	for _, pkg := range []string{"dummy", "@scope/dummy"} {
+		published, err := NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, pkg, "1.0.0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if published.ID != pkg+"@1.0.0" || published.Dist.Shasum == "" || !strings.HasPrefix(published.Dist.Integrity, "sha512-") {
+			t.Errorf("Unexpected published version: %v", published)
+		}
+
+		if !strings.HasSuffix(published.Dist.Tarball, "/repository/npm-hosted/"+pkg+"/-/dummy-1.0.0.tgz") {
+			t.Errorf("Unexpected tarball URL %s", published.Dist.Tarball)
+		}
+
+		if _, err = NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, pkg, "2.0.0-beta.1"), "next", "beta"); err != nil {
+			t.Fatal(err)
+		}
+
+		packument, err := GetNpmPackument(rm, "npm-hosted", pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedTags := map[string]string{"latest": "1.0.0", "next": "2.0.0-beta.1", "beta": "2.0.0-beta.1"}
+		if !reflect.DeepEqual(packument.DistTags, expectedTags) {
+			t.Errorf("Expected dist-tags %v but got %v", expectedTags, packument.DistTags)
+		}
+
+		if packument.Name != pkg || len(packument.Versions) != 2 || packument.Versions["1.0.0"].Description != "dummy package" {
+			t.Errorf("Unexpected packument: %v", packument)
+		}
+	}
+
+	// the fields of package.json are published with the version
+	manifest := registry.packuments["@scope/dummy"]["versions"].(map[string]interface{})["1.0.0"].(map[string]interface{})
+	if manifest["license"] != "MIT" || manifest["dependencies"] == nil {
+		t.Errorf("Unexpected manifest %v", manifest)
+	}
+
+	if _, err := NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, "dummy", "1.0.0")); err == nil {
+		t.Error("Expected republishing a version to fail")
+	}
+
+	if _, err := NpmPublish(rm, "npm-hosted", strings.NewReader("not a tarball")); err == nil {
+		t.Error("Expected an invalid tarball to be rejected")
+	}
+}
+
+func TestNpmDistTags(t *testing.T) {
+	_, rm, done := newFakeNpmRegistry(t)
+	defer done()
+
+	pkg := "@scope/dummy"
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		if _, err := NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, pkg, v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := AddNpmDistTag(rm, "npm-hosted", pkg, "stable", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := GetNpmDistTags(rm, "npm-hosted", pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"latest": "1.1.0", "stable": "1.0.0"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected dist-tags %v but got %v", expected, tags)
+	}
+
+	if err := RemoveNpmDistTag(rm, "npm-hosted", pkg, "stable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveNpmDistTag(rm, "npm-hosted", pkg, "latest"); err == nil {
+		t.Error("Expected the latest tag not to be removed")
+	}
+
+	if tags, _ = GetNpmDistTags(rm, "npm-hosted", pkg); !reflect.DeepEqual(tags, map[string]string{"latest": "1.1.0"}) {
+		t.Errorf("Unexpected dist-tags %v", tags)
+	}
+}
+
+func TestDeprecateNpmPackage(t *testing.T) {
+	_, rm, done := newFakeNpmRegistry(t)
+	defer done()
+
+	pkg := "@scope/dummy"
+	for _, v := range []string{"1.0.0", "1.2.0", "2.0.0", "2.1.0-rc.1"} {
+		if _, err := NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, pkg, v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deprecated, err := DeprecateNpmPackage(rm, "npm-hosted", pkg, "^1.0.0", "use 2.x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(deprecated, []string{"1.0.0", "1.2.0"}) {
+		t.Errorf("Unexpected deprecated versions %v", deprecated)
+	}
+
+	packument, err := GetNpmPackument(rm, "npm-hosted", pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for v, manifest := range packument.Versions {
+		if expected := map[bool]string{true: "use 2.x"}[strings.HasPrefix(v, "1.")]; manifest.Deprecated != expected {
+			t.Errorf("Expected %s to be deprecated with '%s' but got '%s'", v, expected, manifest.Deprecated)
+		}
+	}
+
+	if deprecated, err = DeprecateNpmPackage(rm, "npm-hosted", pkg, "", ""); err != nil || len(deprecated) != 4 {
+		t.Fatalf("Unexpected undeprecation of %v: %v", deprecated, err)
+	}
+
+	packument, _ = GetNpmPackument(rm, "npm-hosted", pkg)
+	for v, manifest := range packument.Versions {
+		if manifest.Deprecated != "" {
+			t.Errorf("Expected %s not to be deprecated", v)
+		}
+	}
+
+	if _, err = DeprecateNpmPackage(rm, "npm-hosted", pkg, "^3.0.0", "gone"); err == nil {
+		t.Error("Expected a range without versions to fail")
+	}
+}
+
+func TestUnpublishNpmPackage(t *testing.T) {
+	registry, rm, done := newFakeNpmRegistry(t)
+	defer done()
+
+	pkg := "@scope/dummy"
+	for _, v := range []string{"1.0.0", "1.1.0"} {
+		if _, err := NpmPublish(rm, "npm-hosted", dummyNpmTarball(t, pkg, v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddNpmDistTag(rm, "npm-hosted", pkg, "stable", "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnpublishNpmPackage(rm, "npm-hosted", pkg, "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	packument, err := GetNpmPackument(rm, "npm-hosted", pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := packument.Versions["1.1.0"]; ok || len(packument.Versions) != 1 {
+		t.Errorf("Unexpected versions %v", packument.Versions)
+	}
+
+	if !reflect.DeepEqual(packument.DistTags, map[string]string{"latest": "1.0.0"}) {
+		t.Errorf("Unexpected dist-tags %v", packument.DistTags)
+	}
+
+	if registry.tarballs["@scope/dummy/-/dummy-1.1.0.tgz"] || !registry.tarballs["@scope/dummy/-/dummy-1.0.0.tgz"] {
+		t.Errorf("Unexpected tarballs %v", registry.tarballs)
+	}
+
+	if err := UnpublishNpmPackage(rm, "npm-hosted", pkg, "3.0.0"); err == nil {
+		t.Error("Expected unpublishing a missing version to fail")
+	}
+
+	if err := UnpublishNpmPackage(rm, "npm-hosted", pkg, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetNpmPackument(rm, "npm-hosted", pkg); err == nil {
+		t.Error("Expected the package to be unpublished")
+	}
+}
@@ -0,0 +1,147 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	restPrivileges       = "service/rest/v1/security/privileges"
+	restPrivilegesByType = "service/rest/v1/security/privileges/%s"
+)
+
+// Enumerates the types of privileges which can be managed through the API
+const (
+	PrivilegeApplication       = "application"
+	PrivilegeRepositoryView    = "repository-view"
+	PrivilegeRepositoryAdmin   = "repository-admin"
+	PrivilegeRepositoryContent = "repository-content-selector"
+	PrivilegeScript            = "script"
+	PrivilegeWildcard          = "wildcard"
+)
+
+// Privilege encapsulates a Repository Manager privilege of any type.
+// Only the fields relevant to the privilege's Type are expected to be set.
+type Privilege struct {
+	Type            string   `json:"type"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	ReadOnly        bool     `json:"readOnly,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+	Domain          string   `json:"domain,omitempty"`
+	Actions         []string `json:"actions,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	Repository      string   `json:"repository,omitempty"`
+	ContentSelector string   `json:"contentSelector,omitempty"`
+	ScriptName      string   `json:"scriptName,omitempty"`
+}
+
+func (p Privilege) validate() error {
+	switch p.Type {
+	case PrivilegeApplication, PrivilegeRepositoryView, PrivilegeRepositoryAdmin, PrivilegeRepositoryContent, PrivilegeScript, PrivilegeWildcard:
+		return nil
+	case "":
+		return fmt.Errorf("privilege type is required")
+	default:
+		return fmt.Errorf("unsupported privilege type '%s'", p.Type)
+	}
+}
+
+// GetPrivileges returns all of the privileges in the RM instance
+func GetPrivileges(rm RM) ([]Privilege, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list privileges: %v", err)
+	}
+
+	body, resp, err := rm.Get(restPrivileges)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	privileges := make([]Privilege, 0)
+	if err := json.Unmarshal(body, &privileges); err != nil {
+		return nil, doError(err)
+	}
+
+	return privileges, nil
+}
+
+// GetPrivilegeByName returns the named privilege
+func GetPrivilegeByName(rm RM, name string) (Privilege, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find privilege '%s': %v", name, err)
+	}
+
+	var privilege Privilege
+
+	url := fmt.Sprintf("%s/%s", restPrivileges, name)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return privilege, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &privilege); err != nil {
+		return privilege, doError(err)
+	}
+
+	return privilege, nil
+}
+
+// CreatePrivilege creates a new privilege of the type indicated by the privilege
+func CreatePrivilege(rm RM, privilege Privilege) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not create privilege '%s': %v", privilege.Name, err)
+	}
+
+	if err := privilege.validate(); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(privilege)
+	if err != nil {
+		return doError(err)
+	}
+
+	url := fmt.Sprintf(restPrivilegesByType, privilege.Type)
+	if _, resp, err := rm.Post(url, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusCreated) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// UpdatePrivilege replaces the privilege which has the same name as the given privilege
+func UpdatePrivilege(rm RM, privilege Privilege) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not update privilege '%s': %v", privilege.Name, err)
+	}
+
+	if err := privilege.validate(); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(privilege)
+	if err != nil {
+		return doError(err)
+	}
+
+	url := fmt.Sprintf(restPrivilegesByType+"/%s", privilege.Type, privilege.Name)
+	if _, resp, err := rm.Put(url, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DeletePrivilegeByName removes the named privilege
+func DeletePrivilegeByName(rm RM, name string) error {
+	url := fmt.Sprintf("%s/%s", restPrivileges, name)
+
+	if resp, err := rm.Del(url); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("privilege not deleted '%s': %v", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dummyPrivileges = []Privilege{
+	{Type: PrivilegeWildcard, Name: "nx-all", Description: "All permissions", ReadOnly: true, Pattern: "nexus:*"},
+	{Type: PrivilegeRepositoryView, Name: "nx-repository-view-*-*-read", Description: "Read all", ReadOnly: true, Format: "*", Repository: "*", Actions: []string{"READ"}},
+	{Type: PrivilegeRepositoryView, Name: "nx-repository-view-maven2-*-edit", Description: "Edit maven", ReadOnly: true, Format: "maven2", Repository: "*", Actions: []string{"EDIT"}},
+	{Type: PrivilegeScript, Name: "nx-script-*-run", Description: "Run scripts", ReadOnly: true, ScriptName: "*", Actions: []string{"RUN"}},
+}
+
+func privilegesTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	getPrivilegeByName := func(name string) (int, bool) {
+		for i, p := range dummyPrivileges {
+			if p.Name == name {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	readPrivilege := func() (p Privilege, ok bool) {
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		return p, json.Unmarshal(body, &p) == nil
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restPrivileges), "/")
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		resp, err := json.Marshal(dummyPrivileges)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet:
+		if i, ok := getPrivilegeByName(path); ok {
+			resp, err := json.Marshal(dummyPrivileges[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost:
+		p, ok := readPrivilege()
+		if !ok || p.Type != path {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyPrivileges = append(dummyPrivileges, p)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		parts := strings.SplitN(path, "/", 2)
+		i, ok := getPrivilegeByName(parts[len(parts)-1])
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		p, ok := readPrivilege()
+		if !ok || p.Type != parts[0] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyPrivileges[i] = p
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if i, ok := getPrivilegeByName(path); ok {
+			dummyPrivileges = append(dummyPrivileges[:i], dummyPrivileges[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestGetPrivileges(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	privileges, err := GetPrivileges(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(privileges, dummyPrivileges) {
+		t.Errorf("Did not receive expected privileges: %v", privileges)
+	}
+}
+
+func TestCreateUpdateDeletePrivilege(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	privileges := []Privilege{
+		{Type: PrivilegeApplication, Name: "test-app", Domain: "users", Actions: []string{"READ"}},
+		{Type: PrivilegeRepositoryAdmin, Name: "test-admin", Format: "npm", Repository: "npm-hosted", Actions: []string{"BROWSE"}},
+		{Type: PrivilegeRepositoryContent, Name: "test-csel", Format: "maven2", Repository: "*", ContentSelector: "maven-acme", Actions: []string{"READ"}},
+		{Type: PrivilegeWildcard, Name: "test-wildcard", Pattern: "nexus:tasks:*"},
+	}
+
+	for _, p := range privileges {
+		if err := CreatePrivilege(rm, p); err != nil {
+			t.Fatal(err)
+		}
+
+		p.Description = "updated"
+		if err := UpdatePrivilege(rm, p); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := GetPrivilegeByName(rm, p.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("Did not receive updated privilege: %v", got)
+		}
+
+		if err := DeletePrivilegeByName(rm, p.Name); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := GetPrivilegeByName(rm, p.Name); err == nil {
+			t.Errorf("Privilege %s not deleted", p.Name)
+		}
+	}
+
+	if err := CreatePrivilege(rm, Privilege{Name: "untyped"}); err == nil {
+		t.Error("Expected privilege without a type to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package nexusrm
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// progressLog records the completed steps of a long-running job as JSON lines so that the job can resume
+type progressLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// openProgressLog opens the log at the given path, creating it if needed, and passes each previously
+// recorded line to the given function. A truncated last line, as left by a job which was killed while
+// recording, is skipped and terminated so that new records are not appended to it.
+func openProgressLog(path string, previous func(line []byte)) (*progressLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); json.Valid(line) {
+			previous(line)
+		}
+	}
+	if err = scanner.Err(); err == nil {
+		err = terminateLastLine(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &progressLog{f: f}, nil
+}
+
+// record appends the given value to the log
+func (l *progressLog) record(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.f.Write(append(line, '\n'))
+	return err
+}
+
+func (l *progressLog) Close() error {
+	return l.f.Close()
+}
+
+// terminateLastLine ends the file with a newline, so that records are not appended to a truncated one
+func terminateLastLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err = f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] != '\n' {
+		_, err = f.Write([]byte{'\n'})
+	}
+
+	return err
+}
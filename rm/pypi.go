@@ -0,0 +1,489 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	nexus "github.com/overag3/gonexus"
+	"github.com/overag3/gonexus/versions"
+)
+
+// Content types of the simple repository API
+const (
+	pypiSimpleJSON = "application/vnd.pypi.simple.v1+json"
+	pypiSimpleHTML = "application/vnd.pypi.simple.v1+html"
+)
+
+// Types of the distributions of the legacy upload API
+const (
+	PypiWheel = "bdist_wheel"
+	PypiSdist = "sdist"
+)
+
+var (
+	pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+	pypiAnchor         = regexp.MustCompile(`(?is)<a\s([^>]*)>(.*?)</a>`)
+	pypiAttribute      = regexp.MustCompile(`(?is)([a-z][a-z0-9-]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+)
+
+// PypiDistribution is a file of a project listed by the simple repository API
+type PypiDistribution struct {
+	Filename string
+	URL      string
+	// Hashes of the file by algorithm, such as sha256
+	Hashes         map[string]string
+	RequiresPython string
+	Yanked         bool
+	YankedReason   string
+}
+
+// Version returns the version of the project which the file is a distribution of, from its name
+func (d PypiDistribution) Version() string {
+	_, version, _ := parsePypiFilename(d.Filename)
+	return version
+}
+
+// PackageType returns whether the file is a wheel or a source distribution
+func (d PypiDistribution) PackageType() string {
+	_, _, packageType := parsePypiFilename(d.Filename)
+	return packageType
+}
+
+// PypiProject is a project of the simple repository API with all its files
+type PypiProject struct {
+	Name  string
+	Files []PypiDistribution
+}
+
+// Versions returns the versions the project has files for, ordered as PEP 440 orders them
+func (p PypiProject) Versions() []string {
+	seen := make(map[string]bool)
+	vs := make([]string, 0)
+	for _, f := range p.Files {
+		if v := f.Version(); v != "" && !seen[v] {
+			seen[v] = true
+			vs = append(vs, v)
+		}
+	}
+	versions.Sort(versions.PEP440, vs)
+	return vs
+}
+
+// PypiNormalizeName returns the name of a project as normalized by PEP 503
+func PypiNormalizeName(name string) string {
+	return strings.ToLower(pypiNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// parsePypiFilename returns the project name, version and type of a distribution from its file name
+func parsePypiFilename(filename string) (name, version, packageType string) {
+	if strings.HasSuffix(filename, ".whl") {
+		// {name}-{version}(-{build})?-{python}-{abi}-{platform}.whl
+		parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-")
+		if len(parts) < 5 {
+			return "", "", ""
+		}
+		return parts[0], parts[1], PypiWheel
+	}
+
+	base := filename
+	for _, ext := range []string{".tar.gz", ".tar.bz2", ".tgz", ".zip"} {
+		if strings.HasSuffix(filename, ext) {
+			base = strings.TrimSuffix(filename, ext)
+		}
+	}
+	if base == filename {
+		return "", "", ""
+	}
+
+	// names can contain dashes but versions start with a digit
+	for i := len(base) - 2; i > 0; i-- {
+		if base[i] == '-' && base[i+1] >= '0' && base[i+1] <= '9' {
+			return base[:i], base[i+1:], PypiSdist
+		}
+	}
+	return "", "", ""
+}
+
+func pypiSimpleEndpoint(repo, project string) string {
+	if project == "" {
+		return fmt.Sprintf("repository/%s/simple/", repo)
+	}
+	return fmt.Sprintf("repository/%s/simple/%s/", repo, PypiNormalizeName(project))
+}
+
+// getPypiSimple requests a page of the simple repository API, preferring its JSON form (PEP 691)
+// over its HTML form (PEP 503), and returns the page with its content type and URL
+func getPypiSimple(rm RM, endpoint string) ([]byte, string, *url.URL, error) {
+	req, err := rm.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	req.Header.Set("Accept", pypiSimpleJSON+", "+pypiSimpleHTML+";q=0.2, text/html;q=0.01")
+
+	body, resp, err := rm.Do(req)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return body, contentType, resp.Request.URL, nil
+}
+
+// parsePypiAnchors returns the anchors of an HTML page of the simple repository API,
+// with the text of each anchor and its attributes by name
+func parsePypiAnchors(page []byte) (texts []string, attributes []map[string]string) {
+	for _, anchor := range pypiAnchor.FindAllSubmatch(page, -1) {
+		attrs := make(map[string]string)
+		for _, attr := range pypiAttribute.FindAllSubmatch(anchor[1], -1) {
+			value := string(attr[2]) + string(attr[3]) + string(attr[4])
+			attrs[strings.ToLower(string(attr[1]))] = html.UnescapeString(value)
+		}
+
+		texts = append(texts, strings.TrimSpace(html.UnescapeString(string(anchor[2]))))
+		attributes = append(attributes, attrs)
+	}
+	return
+}
+
+// GetPypiProjects returns the names of the projects listed by the simple index of a pypi repository
+func GetPypiProjects(rm RM, repo string) ([]string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list projects of '%s': %v", repo, err)
+	}
+
+	body, contentType, _, err := getPypiSimple(rm, pypiSimpleEndpoint(repo, ""))
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	projects := make([]string, 0)
+	if contentType == pypiSimpleJSON {
+		var index struct {
+			Projects []struct {
+				Name string `json:"name"`
+			} `json:"projects"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return nil, doError(err)
+		}
+
+		for _, p := range index.Projects {
+			projects = append(projects, p.Name)
+		}
+		return projects, nil
+	}
+
+	texts, _ := parsePypiAnchors(body)
+	return append(projects, texts...), nil
+}
+
+// GetPypiProject returns the files of a project of a pypi repository, as listed by its simple index.
+// The URLs of the files are absolute.
+func GetPypiProject(rm RM, repo, project string) (PypiProject, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not retrieve project '%s' from '%s': %v", project, repo, err)
+	}
+
+	body, contentType, pageURL, err := getPypiSimple(rm, pypiSimpleEndpoint(repo, project))
+	if err != nil {
+		return PypiProject{}, doError(err)
+	}
+
+	resolve := func(href string) string {
+		ref, err := url.Parse(href)
+		if err != nil || pageURL == nil {
+			return href
+		}
+		return pageURL.ResolveReference(ref).String()
+	}
+
+	p := PypiProject{Name: project, Files: make([]PypiDistribution, 0)}
+
+	if contentType == pypiSimpleJSON {
+		var detail struct {
+			Name  string `json:"name"`
+			Files []struct {
+				Filename       string            `json:"filename"`
+				URL            string            `json:"url"`
+				Hashes         map[string]string `json:"hashes"`
+				RequiresPython string            `json:"requires-python"`
+				Yanked         interface{}       `json:"yanked"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal(body, &detail); err != nil {
+			return PypiProject{}, doError(err)
+		}
+
+		if detail.Name != "" {
+			p.Name = detail.Name
+		}
+		for _, f := range detail.Files {
+			d := PypiDistribution{Filename: f.Filename, URL: resolve(f.URL), Hashes: f.Hashes, RequiresPython: f.RequiresPython}
+			// yanked is either a boolean or the reason the file was yanked
+			switch yanked := f.Yanked.(type) {
+			case bool:
+				d.Yanked = yanked
+			case string:
+				d.Yanked, d.YankedReason = true, yanked
+			}
+			p.Files = append(p.Files, d)
+		}
+		return p, nil
+	}
+
+	texts, attributes := parsePypiAnchors(body)
+	for i, attrs := range attributes {
+		d := PypiDistribution{Filename: texts[i], Hashes: make(map[string]string), RequiresPython: attrs["data-requires-python"]}
+
+		href := attrs["href"]
+		if j := strings.Index(href, "#"); j >= 0 {
+			if kv := strings.SplitN(href[j+1:], "=", 2); len(kv) == 2 {
+				d.Hashes[kv[0]] = kv[1]
+			}
+			href = href[:j]
+		}
+		d.URL = resolve(href)
+
+		if d.Filename == "" {
+			d.Filename = path.Base(href)
+		}
+
+		if reason, ok := attrs["data-yanked"]; ok {
+			d.Yanked, d.YankedReason = true, reason
+		}
+
+		p.Files = append(p.Files, d)
+	}
+
+	return p, nil
+}
+
+// PypiMetadata is the core metadata of a distribution, from the METADATA file of a wheel
+// or the PKG-INFO file of a source distribution
+type PypiMetadata struct {
+	MetadataVersion string
+	Name            string
+	Version         string
+	Summary         string
+	Description     string
+	RequiresPython  string
+	// Fields holds every field of the metadata by name, such as Classifier or Requires-Dist
+	Fields map[string][]string
+}
+
+// ParsePypiMetadata parses a METADATA or PKG-INFO file. The description can follow the fields as the body of the file.
+func ParsePypiMetadata(r io.Reader) (PypiMetadata, error) {
+	tp := textproto.NewReader(bufio.NewReader(r))
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && !(err == io.EOF && len(header) > 0) {
+		return PypiMetadata{}, fmt.Errorf("could not parse metadata: %v", err)
+	}
+
+	body, err := ioutil.ReadAll(tp.R)
+	if err != nil {
+		return PypiMetadata{}, fmt.Errorf("could not parse metadata: %v", err)
+	}
+
+	m := PypiMetadata{
+		MetadataVersion: header.Get("Metadata-Version"),
+		Name:            header.Get("Name"),
+		Version:         header.Get("Version"),
+		Summary:         header.Get("Summary"),
+		Description:     header.Get("Description"),
+		RequiresPython:  header.Get("Requires-Python"),
+		Fields:          header,
+	}
+	if d := strings.TrimSpace(string(body)); d != "" {
+		m.Description = d
+	}
+
+	if m.Name == "" || m.Version == "" {
+		return m, errors.New("could not parse metadata: no name or version")
+	}
+
+	return m, nil
+}
+
+// ReadPypiMetadata returns the metadata of a wheel or of a zip or tar.gz source distribution
+func ReadPypiMetadata(filename string, content []byte) (PypiMetadata, error) {
+	isMetadata := func(name string) bool {
+		// METADATA is in the .dist-info directory of wheels and PKG-INFO in the top-level directory of sdists
+		dir, file := path.Split(name)
+		if strings.HasSuffix(filename, ".whl") {
+			return file == "METADATA" && strings.HasSuffix(strings.TrimSuffix(dir, "/"), ".dist-info") && strings.Count(dir, "/") == 1
+		}
+		return file == "PKG-INFO" && strings.Count(dir, "/") == 1
+	}
+
+	switch {
+	case strings.HasSuffix(filename, ".whl"), strings.HasSuffix(filename, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return PypiMetadata{}, err
+		}
+
+		for _, f := range zr.File {
+			if !isMetadata(f.Name) {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return PypiMetadata{}, err
+			}
+			defer rc.Close()
+
+			return ParsePypiMetadata(rc)
+		}
+	case strings.HasSuffix(filename, ".tar.gz"), strings.HasSuffix(filename, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return PypiMetadata{}, err
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return PypiMetadata{}, err
+			}
+
+			if isMetadata(strings.TrimPrefix(hdr.Name, "./")) {
+				return ParsePypiMetadata(tr)
+			}
+		}
+	default:
+		return PypiMetadata{}, fmt.Errorf("'%s' is not a wheel or source distribution", filename)
+	}
+
+	return PypiMetadata{}, fmt.Errorf("'%s' has no metadata", filename)
+}
+
+// pypiUploadFields maps metadata fields to the fields of the legacy upload API, which are named
+// after them in lower case with underscores, except for the fields which can be repeated
+var pypiUploadFields = map[string]string{
+	"Classifier":    "classifiers",
+	"Project-Url":   "project_urls",
+	"Home-Page":     "home_page",
+	"Download-Url":  "download_url",
+	"Requires-Dist": "requires_dist",
+	"Provides-Dist": "provides_dist",
+}
+
+// UploadPypiDistribution uploads a wheel or source distribution to a pypi hosted repository with the
+// legacy upload API used by twine, along with the metadata read from the distribution and its digests.
+// Returns the metadata of the distribution.
+func UploadPypiDistribution(rm RM, repo, filename string, content io.Reader) (PypiMetadata, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not upload '%s' to '%s': %v", filename, repo, err)
+	}
+
+	buf, err := ioutil.ReadAll(content)
+	if err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+
+	metadata, err := ReadPypiMetadata(filename, buf)
+	if err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+
+	filetype, pyversion := PypiSdist, "source"
+	if _, _, packageType := parsePypiFilename(filename); packageType == PypiWheel {
+		// the python tag is the third field from the end of the name of a wheel
+		parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-")
+		filetype, pyversion = PypiWheel, parts[len(parts)-3]
+	}
+
+	md5sum := md5.Sum(buf)
+	sha256sum := sha256.Sum256(buf)
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	fields := [][2]string{
+		{":action", "file_upload"},
+		{"protocol_version", "1"},
+		{"filetype", filetype},
+		{"pyversion", pyversion},
+		{"md5_digest", hex.EncodeToString(md5sum[:])},
+		{"sha256_digest", hex.EncodeToString(sha256sum[:])},
+		{"description", metadata.Description},
+	}
+
+	for field, values := range metadata.Fields {
+		if field == "Description" {
+			continue
+		}
+
+		name, ok := pypiUploadFields[field]
+		if !ok {
+			name = strings.Replace(strings.ToLower(field), "-", "_", -1)
+		}
+
+		for _, v := range values {
+			fields = append(fields, [2]string{name, v})
+		}
+	}
+
+	for _, f := range fields {
+		if err = w.WriteField(f[0], f[1]); err != nil {
+			return PypiMetadata{}, doError(err)
+		}
+	}
+
+	fw, err := w.CreateFormFile("content", path.Base(filename))
+	if err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+	if _, err = fw.Write(buf); err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+
+	if err = w.Close(); err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+
+	req, err := rm.NewRequest(http.MethodPost, fmt.Sprintf("repository/%s/", repo), &b)
+	if err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return PypiMetadata{}, doError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return PypiMetadata{}, doError(errors.New(resp.Status))
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,323 @@
+package nexusrm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dummyPypiMetadata = `Metadata-Version: 2.1
+Name: Dummy_Project
+Version: 1.0.0
+Summary: A dummy project
+Home-page: https://example.com/dummy
+Author-email: dummy@example.com
+Requires-Python: >=3.6
+Classifier: Programming Language :: Python :: 3
+Classifier: License :: OSI Approved :: MIT License
+Requires-Dist: requests (>=2.0)
+Description-Content-Type: text/markdown
+
+# Dummy
+
+The dummy project.
+`
+
+const dummyPypiSimpleIndex = `<!DOCTYPE html>
+<html lang="en">
+<head><title>Simple Index</title></head>
+<body>
+<a href="dummy-project/">dummy-project</a><br/>
+<a href="other/">other</a><br/>
+</body>
+</html>`
+
+const dummyPypiSimpleProject = `<html>
+<head><title>Links for dummy-project</title></head>
+<body>
+<h1>Links for dummy-project</h1>
+<a href="../../packages/dummy-project/1.0.0/Dummy_Project-1.0.0-py3-none-any.whl#sha256=abc123" data-requires-python="&gt;=3.6" rel="internal">Dummy_Project-1.0.0-py3-none-any.whl</a><br/>
+<a href="../../packages/dummy-project/1.0.0/dummy-project-1.0.0.tar.gz#sha256=def456" rel="internal">dummy-project-1.0.0.tar.gz</a><br/>
+<a href="../../packages/dummy-project/0.9rc1/dummy-project-0.9rc1.tar.gz#md5=0011" data-yanked="broken">dummy-project-0.9rc1.tar.gz</a><br/>
+<a href="../../packages/dummy-project/0.10/dummy-project-0.10.zip" data-yanked>dummy-project-0.10.zip</a><br/>
+</body>
+</html>`
+
+const dummyPypiSimpleProjectJSON = `{
+  "meta": {"api-version": "1.0"},
+  "name": "dummy-project",
+  "files": [
+    {"filename": "Dummy_Project-1.0.0-py3-none-any.whl", "url": "https://files.example.com/Dummy_Project-1.0.0-py3-none-any.whl", "hashes": {"sha256": "abc123"}, "requires-python": ">=3.6"},
+    {"filename": "dummy-project-0.9.tar.gz", "url": "../../packages/dummy-project-0.9.tar.gz", "hashes": {}, "yanked": "broken"},
+    {"filename": "dummy-project-0.8.tar.gz", "url": "../../packages/dummy-project-0.8.tar.gz", "hashes": {}, "yanked": false}
+  ]
+}`
+
+func dummyPypiWheel(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range map[string]string{
+		"dummy_project/__init__.py":                   "",
+		"dummy_project-1.0.0.dist-info/METADATA":      dummyPypiMetadata,
+		"dummy_project-1.0.0.dist-info/WHEEL":         "Wheel-Version: 1.0\n",
+		"dummy_project-1.0.0.dist-info/RECORD":        "",
+		"dummy_project-1.0.0.dist-info/top_level.txt": "dummy_project\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func dummyPypiSdist(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range map[string]string{
+		"dummy-project-1.0.0/setup.py": "",
+		"dummy-project-1.0.0/PKG-INFO": dummyPypiMetadata,
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPypiNormalizeName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Dummy_Project": "dummy-project",
+		"dummy.project": "dummy-project",
+		"Dummy-._Proj":  "dummy-proj",
+		"requests":      "requests",
+	} {
+		if actual := PypiNormalizeName(name); actual != expected {
+			t.Errorf("Expected %s to be normalized to %s but got %s", name, expected, actual)
+		}
+	}
+}
+
+func TestGetPypiProjects(t *testing.T) {
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repository/pypi-hosted/simple/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, dummyPypiSimpleIndex)
+	})
+	defer mock.Close()
+
+	projects, err := GetPypiProjects(rm, "pypi-hosted")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(projects, []string{"dummy-project", "other"}) {
+		t.Errorf("Unexpected projects %v", projects)
+	}
+}
+
+func TestGetPypiProject(t *testing.T) {
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repository/pypi-hosted/simple/dummy-project/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Accept"), pypiSimpleJSON) {
+			t.Errorf("Expected the JSON API to be preferred but got %s", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, dummyPypiSimpleProject)
+	})
+	defer mock.Close()
+
+	project, err := GetPypiProject(rm, "pypi-hosted", "Dummy_Project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(project.Files) != 4 {
+		t.Fatalf("Unexpected files %v", project.Files)
+	}
+
+	wheel := project.Files[0]
+	expected := PypiDistribution{
+		Filename:       "Dummy_Project-1.0.0-py3-none-any.whl",
+		URL:            mock.URL + "/repository/pypi-hosted/packages/dummy-project/1.0.0/Dummy_Project-1.0.0-py3-none-any.whl",
+		Hashes:         map[string]string{"sha256": "abc123"},
+		RequiresPython: ">=3.6",
+	}
+	if !reflect.DeepEqual(wheel, expected) {
+		t.Errorf("Expected %v but got %v", expected, wheel)
+	}
+
+	if wheel.Version() != "1.0.0" || wheel.PackageType() != PypiWheel || project.Files[1].PackageType() != PypiSdist {
+		t.Errorf("Unexpected distributions %v", project.Files)
+	}
+
+	if !project.Files[2].Yanked || project.Files[2].YankedReason != "broken" || !project.Files[3].Yanked || project.Files[1].Yanked {
+		t.Errorf("Unexpected yanked files %v", project.Files)
+	}
+
+	if !reflect.DeepEqual(project.Versions(), []string{"0.9rc1", "0.10", "1.0.0"}) {
+		t.Errorf("Unexpected versions %v", project.Versions())
+	}
+}
+
+func TestGetPypiProjectJSON(t *testing.T) {
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", pypiSimpleJSON)
+		fmt.Fprint(w, dummyPypiSimpleProjectJSON)
+	})
+	defer mock.Close()
+
+	project, err := GetPypiProject(rm, "pypi-proxy", "dummy-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(project.Files) != 3 || project.Files[0].Hashes["sha256"] != "abc123" || project.Files[0].RequiresPython != ">=3.6" {
+		t.Fatalf("Unexpected files %v", project.Files)
+	}
+
+	if project.Files[1].URL != mock.URL+"/repository/pypi-proxy/packages/dummy-project-0.9.tar.gz" {
+		t.Errorf("Unexpected URL %s", project.Files[1].URL)
+	}
+
+	if !project.Files[1].Yanked || project.Files[1].YankedReason != "broken" || project.Files[2].Yanked {
+		t.Errorf("Unexpected yanked files %v", project.Files)
+	}
+
+	if !reflect.DeepEqual(project.Versions(), []string{"0.8", "0.9", "1.0.0"}) {
+		t.Errorf("Unexpected versions %v", project.Versions())
+	}
+}
+
+func TestParsePypiMetadata(t *testing.T) {
+	m, err := ParsePypiMetadata(strings.NewReader(dummyPypiMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.MetadataVersion != "2.1" || m.Name != "Dummy_Project" || m.Version != "1.0.0" || m.RequiresPython != ">=3.6" {
+		t.Errorf("Unexpected metadata %v", m)
+	}
+
+	if m.Description != "# Dummy\n\nThe dummy project." {
+		t.Errorf("Unexpected description %q", m.Description)
+	}
+
+	if len(m.Fields["Classifier"]) != 2 {
+		t.Errorf("Unexpected classifiers %v", m.Fields["Classifier"])
+	}
+
+	if _, err := ParsePypiMetadata(strings.NewReader("Metadata-Version: 2.1\nSummary: nothing\n")); err == nil {
+		t.Error("Expected metadata without a name to be rejected")
+	}
+}
+
+func TestUploadPypiDistribution(t *testing.T) {
+	uploads := make([]map[string][]string, 0)
+	files := make(map[string][]byte)
+
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repository/pypi-hosted/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+
+		f, hdr, err := r.FormFile("content")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		files[hdr.Filename], _ = ioutil.ReadAll(f)
+		uploads = append(uploads, r.MultipartForm.Value)
+	})
+	defer mock.Close()
+
+	wheel, sdist := dummyPypiWheel(t), dummyPypiSdist(t)
+
+	m, err := UploadPypiDistribution(rm, "pypi-hosted", "Dummy_Project-1.0.0-py3-none-any.whl", bytes.NewReader(wheel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Dummy_Project" || m.Version != "1.0.0" {
+		t.Errorf("Unexpected metadata %v", m)
+	}
+
+	if _, err = UploadPypiDistribution(rm, "pypi-hosted", "dummy-project-1.0.0.tar.gz", bytes.NewReader(sdist)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uploads) != 2 || !bytes.Equal(files["Dummy_Project-1.0.0-py3-none-any.whl"], wheel) || !bytes.Equal(files["dummy-project-1.0.0.tar.gz"], sdist) {
+		t.Fatalf("Unexpected uploads of %d files", len(files))
+	}
+
+	expected := map[string]string{
+		":action":                  "file_upload",
+		"protocol_version":         "1",
+		"metadata_version":         "2.1",
+		"name":                     "Dummy_Project",
+		"version":                  "1.0.0",
+		"filetype":                 PypiWheel,
+		"pyversion":                "py3",
+		"summary":                  "A dummy project",
+		"home_page":                "https://example.com/dummy",
+		"author_email":             "dummy@example.com",
+		"requires_python":          ">=3.6",
+		"description_content_type": "text/markdown",
+		"description":              "# Dummy\n\nThe dummy project.",
+	}
+	for field, value := range expected {
+		if actual := uploads[0][field]; len(actual) != 1 || actual[0] != value {
+			t.Errorf("Expected field %s to be %s but got %v", field, value, actual)
+		}
+	}
+
+	if len(uploads[0]["classifiers"]) != 2 || len(uploads[0]["requires_dist"]) != 1 || len(uploads[0]["sha256_digest"][0]) != 64 {
+		t.Errorf("Unexpected upload %v", uploads[0])
+	}
+
+	if uploads[1]["filetype"][0] != PypiSdist || uploads[1]["pyversion"][0] != "source" {
+		t.Errorf("Unexpected sdist upload %v", uploads[1])
+	}
+
+	if _, err = UploadPypiDistribution(rm, "pypi-hosted", "dummy-project-1.0.0.tar.gz", bytes.NewReader(wheel)); err == nil {
+		t.Error("Expected an invalid sdist to be rejected")
+	}
+}
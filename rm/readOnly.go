@@ -2,7 +2,9 @@ package nexusrm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -58,10 +60,15 @@ func GetReadOnlyState(rm RM) (state ReadOnlyState, err error) {
 // ReadOnlyEnable enables read-only mode for the RM instance
 func ReadOnlyEnable(rm RM) (state ReadOnlyState, err error) {
 	body, resp, err := rm.Post(restReadOnlyFreeze, nil)
-	if err != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+	if err != nil && (resp == nil || (resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound)) {
 		return
 	}
 
+	// RM does not always describe the new state in its response
+	if len(body) == 0 {
+		return GetReadOnlyState(rm)
+	}
+
 	err = json.Unmarshal(body, &state)
 
 	return
@@ -75,11 +82,63 @@ func ReadOnlyRelease(rm RM, force bool) (state ReadOnlyState, err error) {
 	}
 
 	body, resp, err := rm.Post(endpoint, nil)
-	if err != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+	if err != nil && (resp == nil || (resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound)) {
 		return
 	}
 
+	if len(body) == 0 {
+		return GetReadOnlyState(rm)
+	}
+
 	err = json.Unmarshal(body, &state)
 
 	return
 }
+
+// WithReadOnly freezes the RM instance, verifies it is read-only and runs the given function with the context.
+// The instance is released again once the function returns or panics, using a forced release if requested.
+// The function is expected to return when the context is done; the instance is not released before it has,
+// so that it stays frozen for as long as the work it was frozen for. An instance which was already frozen is
+// left frozen.
+func WithReadOnly(ctx context.Context, rm RM, force bool, fn func(ctx context.Context) error) (err error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not run in read-only mode: %v", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return doError(err)
+	}
+
+	before, err := GetReadOnlyState(rm)
+	if err != nil {
+		return doError(err)
+	}
+
+	if !before.Frozen {
+		// release even if freezing reported an error, as the instance may have been frozen regardless
+		defer func() {
+			if _, releaseErr := ReadOnlyRelease(rm, force); releaseErr != nil {
+				if err == nil {
+					err = fmt.Errorf("could not release read-only mode: %v", releaseErr)
+				} else {
+					err = fmt.Errorf("%v (could not release read-only mode: %v)", err, releaseErr)
+				}
+			}
+		}()
+
+		if _, err := ReadOnlyEnable(rm); err != nil {
+			return doError(err)
+		}
+
+		state, err := GetReadOnlyState(rm)
+		if err != nil {
+			return doError(err)
+		}
+
+		if !state.Frozen {
+			return doError(errors.New("instance was not frozen"))
+		}
+	}
+
+	return fn(ctx)
+}
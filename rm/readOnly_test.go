@@ -0,0 +1,183 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type readOnlyTestServer struct {
+	sync.Mutex
+	state     ReadOnlyState
+	stubborn  bool
+	endpoints []string
+}
+
+func (s *readOnlyTestServer) frozen() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.state.Frozen
+}
+
+func readOnlyTestRM(t *testing.T, server *readOnlyTestServer) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		server.Lock()
+		defer server.Unlock()
+
+		endpoint := r.URL.Path[1:]
+		switch {
+		case r.Method == http.MethodGet && endpoint == restReadOnly:
+			resp, err := json.Marshal(server.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodPost && endpoint == restReadOnlyFreeze:
+			server.endpoints = append(server.endpoints, endpoint)
+			if server.state.Frozen {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			server.state.Frozen = !server.stubborn
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && (endpoint == restReadOnlyRelease || endpoint == restReadOnlyForceRelease):
+			server.endpoints = append(server.endpoints, endpoint)
+			if !server.state.Frozen {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			server.state.Frozen = false
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestWithReadOnly(t *testing.T) {
+	server := new(readOnlyTestServer)
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	ran := false
+	err := WithReadOnly(context.Background(), rm, true, func(ctx context.Context) error {
+		ran = true
+		if !server.frozen() {
+			t.Error("Instance not frozen while running")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran {
+		t.Error("Function was not run")
+	}
+
+	if server.frozen() {
+		t.Error("Instance was not released")
+	}
+
+	if last := server.endpoints[len(server.endpoints)-1]; last != restReadOnlyForceRelease {
+		t.Errorf("Expected forced release but got %s", last)
+	}
+}
+
+func TestWithReadOnlyError(t *testing.T) {
+	server := new(readOnlyTestServer)
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	expected := errors.New("backup failed")
+	if err := WithReadOnly(context.Background(), rm, false, func(ctx context.Context) error { return expected }); err != expected {
+		t.Errorf("Expected error %v but got %v", expected, err)
+	}
+
+	if server.frozen() {
+		t.Error("Instance was not released")
+	}
+}
+
+func TestWithReadOnlyPanic(t *testing.T) {
+	server := new(readOnlyTestServer)
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	defer func() {
+		if p := recover(); p != "backup crashed" {
+			t.Errorf("Expected panic to be propagated but got %v", p)
+		}
+
+		if server.frozen() {
+			t.Error("Instance was not released")
+		}
+	}()
+
+	WithReadOnly(context.Background(), rm, false, func(ctx context.Context) error { panic("backup crashed") })
+}
+
+func TestWithReadOnlyCanceled(t *testing.T) {
+	server := new(readOnlyTestServer)
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := WithReadOnly(ctx, rm, false, func(ctx context.Context) error {
+		<-ctx.Done()
+		// the instance stays frozen until the function has returned
+		time.Sleep(20 * time.Millisecond)
+		if !server.frozen() {
+			t.Error("Instance released before the function returned")
+		}
+		return ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the error of the function but got %v", err)
+	}
+
+	if server.frozen() {
+		t.Error("Instance was not released")
+	}
+}
+
+func TestWithReadOnlyAlreadyFrozen(t *testing.T) {
+	server := &readOnlyTestServer{state: ReadOnlyState{Frozen: true}}
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	if err := WithReadOnly(context.Background(), rm, false, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.frozen() {
+		t.Error("Instance which was already frozen was released")
+	}
+
+	if len(server.endpoints) != 0 {
+		t.Errorf("Expected read-only state to be left alone but called %v", server.endpoints)
+	}
+}
+
+func TestWithReadOnlyNotFrozen(t *testing.T) {
+	server := &readOnlyTestServer{stubborn: true}
+	rm, mock := readOnlyTestRM(t, server)
+	defer mock.Close()
+
+	err := WithReadOnly(context.Background(), rm, false, func(ctx context.Context) error {
+		t.Error("Function run although instance was not frozen")
+		return nil
+	})
+	if err == nil {
+		t.Error("Expected error when instance could not be frozen")
+	}
+}
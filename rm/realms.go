@@ -0,0 +1,71 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	restRealmsAvailable = "service/rest/v1/security/realms/available"
+	restRealmsActive    = "service/rest/v1/security/realms/active"
+)
+
+// Realm describes a security realm which is available in the RM instance
+type Realm struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetAvailableRealms returns all of the security realms which can be activated
+func GetAvailableRealms(rm RM) ([]Realm, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list available realms: %v", err)
+	}
+
+	body, resp, err := rm.Get(restRealmsAvailable)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	realms := make([]Realm, 0)
+	if err := json.Unmarshal(body, &realms); err != nil {
+		return nil, doError(err)
+	}
+
+	return realms, nil
+}
+
+// GetActiveRealms returns the ids of the active security realms in the order in which they are consulted
+func GetActiveRealms(rm RM) ([]string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list active realms: %v", err)
+	}
+
+	body, resp, err := rm.Get(restRealmsActive)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	realms := make([]string, 0)
+	if err := json.Unmarshal(body, &realms); err != nil {
+		return nil, doError(err)
+	}
+
+	return realms, nil
+}
+
+// SetActiveRealms activates the security realms with the given ids in the given order
+func SetActiveRealms(rm RM, realmIDs []string) error {
+	buf, err := json.Marshal(realmIDs)
+	if err != nil {
+		return fmt.Errorf("could not marshal realms: %v", err)
+	}
+
+	if _, resp, err := rm.Put(restRealmsActive, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("active realms not set: %v", err)
+	}
+
+	return nil
+}
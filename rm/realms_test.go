@@ -0,0 +1,92 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var dummyAvailableRealms = []Realm{
+	{ID: "NexusAuthenticatingRealm", Name: "Local Authenticating Realm"},
+	{ID: "NexusAuthorizingRealm", Name: "Local Authorizing Realm"},
+	{ID: "LdapRealm", Name: "LDAP Realm"},
+	{ID: "NpmToken", Name: "npm Bearer Token Realm"},
+}
+
+var dummyActiveRealms = []string{"NexusAuthenticatingRealm", "NexusAuthorizingRealm"}
+
+func realmsTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path[1:] == restRealmsAvailable:
+		resp, err := json.Marshal(dummyAvailableRealms)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet && r.URL.Path[1:] == restRealmsActive:
+		resp, err := json.Marshal(dummyActiveRealms)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodPut && r.URL.Path[1:] == restRealmsActive:
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := json.Unmarshal(body, &dummyActiveRealms); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func realmsTestRM(t *testing.T) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, realmsTestFunc)
+}
+
+func TestGetAvailableRealms(t *testing.T) {
+	rm, mock := realmsTestRM(t)
+	defer mock.Close()
+
+	realms, err := GetAvailableRealms(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(realms, dummyAvailableRealms) {
+		t.Errorf("Did not receive expected realms: %v", realms)
+	}
+}
+
+func TestSetActiveRealms(t *testing.T) {
+	rm, mock := realmsTestRM(t)
+	defer mock.Close()
+
+	expected := []string{"NexusAuthenticatingRealm", "LdapRealm", "NexusAuthorizingRealm", "NpmToken"}
+	if err := SetActiveRealms(rm, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	realms, err := GetActiveRealms(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(realms, expected) {
+		t.Errorf("Expected active realms %v but got %v", expected, realms)
+	}
+}
@@ -0,0 +1,340 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+)
+
+// Outcomes of replicating a component
+const (
+	ReplicationCopied  = "copied"
+	ReplicationSkipped = "skipped"
+	ReplicationFailed  = "failed"
+)
+
+// ReplicationResult is the outcome of replicating a component
+type ReplicationResult struct {
+	Component string `json:"component"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// ReplicationOptions configures a replication
+type ReplicationOptions struct {
+	// ProgressFile records every replicated component so an interrupted replication can resume where it stopped.
+	// An empty path disables resuming, in which case components which exist in the target are skipped by checksum.
+	ProgressFile string
+	// Parallel is the number of components to replicate at the same time, at least one
+	Parallel int
+	// OnResult, if set, is called with the result of each component as it is replicated
+	OnResult func(ReplicationResult)
+}
+
+// ReplicationReport counts the components replicated by a run, and lists those which failed
+type ReplicationReport struct {
+	Copied   int
+	Skipped  int
+	Failed   int
+	Resumed  int // components replicated by a previous run
+	Failures []ReplicationResult
+}
+
+// componentKey identifies a component within a repository
+func componentKey(c RepositoryItem) string {
+	if c.Group == "" {
+		return fmt.Sprintf("%s:%s", c.Name, c.Version)
+	}
+	return fmt.Sprintf("%s:%s:%s", c.Group, c.Name, c.Version)
+}
+
+// isChecksumSidecar returns true if the path has the extension of a checksum file, regardless of format
+func isChecksumSidecar(p string) bool {
+	switch path.Ext(p) {
+	case ".sha1", ".md5", ".sha256", ".sha512":
+		return true
+	}
+	return false
+}
+
+// isGeneratedChecksum returns true for the checksum files which RM generates next to the assets of maven2
+// repositories. In other formats, such as raw and yum, checksum files are content which was uploaded.
+func isGeneratedChecksum(format repositoryFormat, p string) bool {
+	return format == Maven && isChecksumSidecar(p)
+}
+
+// withDownloadedAsset downloads and verifies the asset into a temporary file, which is passed to the function
+func withDownloadedAsset(rm RM, asset RepositoryItemAsset, fn func(io.Reader) error) error {
+	f, err := ioutil.TempFile("", "nexusrm-asset-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err = DownloadAsset(rm, asset, f); err != nil {
+		return err
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return fn(f)
+}
+
+// uploadComponentAsset uploads an asset to the target repository the way its format supports:
+// directly to its path, or as a component through the components API
+func uploadComponentAsset(rm RM, repo string, format repositoryFormat, asset RepositoryItemAsset, content io.Reader) error {
+	var component UploadComponentWriter
+	switch format {
+	case Maven, Raw, Yum, Helm:
+		return UploadAssetToPath(rm, repo, asset.Path, content)
+	case Npm:
+		component = UploadComponentNpm{File: content}
+	case Pypi:
+		component = UploadComponentPyPi{File: content}
+	case Nuget:
+		component = UploadComponentNuget{File: content}
+	case Rubygems:
+		component = UploadComponentRubyGems{File: content}
+	case Apt:
+		component = UploadComponentApt{File: content}
+	default:
+		return fmt.Errorf("uploading %s components is not supported", asset.Format)
+	}
+
+	return UploadComponent(rm, repo, component)
+}
+
+// tagEnsurer creates tags in an RM instance once
+type tagEnsurer struct {
+	mu      sync.Mutex
+	rm      RM
+	ensured map[string]bool
+}
+
+func (e *tagEnsurer) ensure(tag string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ensured[tag] {
+		return nil
+	}
+
+	if _, err := GetTag(e.rm, tag); err != nil {
+		if _, err := AddTag(e.rm, tag, nil); err != nil {
+			return err
+		}
+	}
+
+	e.ensured[tag] = true
+	return nil
+}
+
+// tagComponent associates the given tags with the component in the given repository
+func tagComponent(rm RM, tags *tagEnsurer, repo string, c RepositoryItem, names []string) error {
+	for _, tag := range names {
+		if err := tags.ensure(tag); err != nil {
+			return err
+		}
+
+		query := NewQueryBuilder().Repository(repo).Name(c.Name)
+		if c.Group != "" {
+			query.Group(c.Group)
+		}
+		if c.Version != "" {
+			query.Version(c.Version)
+		}
+
+		if err := AssociateTag(rm, tag, *query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplicateRepository copies the components of a repository in one RM instance to a repository of the same
+// format in another, along with their tags. Assets which already exist at the same path in the target with
+// the same checksum are not copied again, so running a replication again copies only what changed.
+// Maven2, raw, yum and helm assets are uploaded directly to their paths; npm, pypi, nuget, rubygems and apt
+// components are uploaded through the components API. If the context is done, the replication stops and
+// returns what was replicated so far along with the error of the context.
+func ReplicateRepository(ctx context.Context, source RM, sourceRepo string, target RM, targetRepo string, options ReplicationOptions) (ReplicationReport, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not replicate repository '%s' to '%s': %v", sourceRepo, targetRepo, err)
+	}
+
+	report := ReplicationReport{Failures: make([]ReplicationResult, 0)}
+
+	sourceRepository, err := GetRepositoryByName(source, sourceRepo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	targetRepository, err := GetRepositoryByName(target, targetRepo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	if sourceRepository.Format != targetRepository.Format {
+		return report, doError(fmt.Errorf("cannot replicate %s content to a %s repository", sourceRepository.Format, targetRepository.Format))
+	}
+	format := parseRepositoryFormat(targetRepository.Format)
+
+	replicated := make(map[string]bool)
+	var progress *progressLog
+	if options.ProgressFile != "" {
+		progress, err = openProgressLog(options.ProgressFile, func(line []byte) {
+			var r ReplicationResult
+			if json.Unmarshal(line, &r) == nil && r.Status != ReplicationFailed {
+				replicated[r.Component] = true
+			}
+		})
+		if err != nil {
+			return report, doError(err)
+		}
+		defer progress.Close()
+	}
+
+	sourceComponents, err := GetComponents(source, sourceRepo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	targetAssets, err := GetAssets(target, targetRepo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	existing := make(map[string]string)
+	for _, a := range targetAssets {
+		existing[a.Path] = a.Checksum.Sha1
+	}
+
+	targetComponents, err := GetComponents(target, targetRepo)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	targetTags := make(map[string]map[string]bool)
+	for _, c := range targetComponents {
+		targetTags[componentKey(c)] = make(map[string]bool)
+		for _, tag := range c.Tags {
+			targetTags[componentKey(c)][tag] = true
+		}
+	}
+
+	tags := &tagEnsurer{rm: target, ensured: make(map[string]bool)}
+
+	replicate := func(c RepositoryItem) ReplicationResult {
+		result := ReplicationResult{Component: componentKey(c), Status: ReplicationSkipped}
+
+		for _, asset := range c.Assets {
+			if isGeneratedChecksum(format, asset.Path) || (asset.Checksum.Sha1 != "" && existing[asset.Path] == asset.Checksum.Sha1) {
+				continue
+			}
+
+			err := withDownloadedAsset(source, asset, func(content io.Reader) error {
+				return uploadComponentAsset(target, targetRepo, format, asset, content)
+			})
+			if err != nil {
+				result.Status, result.Detail = ReplicationFailed, err.Error()
+				return result
+			}
+			result.Status = ReplicationCopied
+		}
+
+		missingTags := make([]string, 0)
+		for _, tag := range c.Tags {
+			if !targetTags[result.Component][tag] {
+				missingTags = append(missingTags, tag)
+			}
+		}
+
+		if err := tagComponent(target, tags, targetRepo, c, missingTags); err != nil {
+			result.Status, result.Detail = ReplicationFailed, fmt.Sprintf("could not tag component: %v", err)
+		}
+
+		return result
+	}
+
+	parallel := options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var mu sync.Mutex
+	var writeErr error
+	record := func(r ReplicationResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Status {
+		case ReplicationCopied:
+			report.Copied++
+		case ReplicationSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+			report.Failures = append(report.Failures, r)
+		}
+
+		if progress != nil && writeErr == nil {
+			writeErr = progress.record(r)
+		}
+
+		if options.OnResult != nil {
+			options.OnResult(r)
+		}
+	}
+
+	queue := make(chan RepositoryItem)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range queue {
+				result := replicate(c)
+				// a replication interrupted by the context is not a result
+				if ctx.Err() != nil && result.Status == ReplicationFailed {
+					continue
+				}
+				record(result)
+			}
+		}()
+	}
+
+	for _, c := range sourceComponents {
+		if replicated[componentKey(c)] {
+			report.Resumed++
+			continue
+		}
+
+		select {
+		case queue <- c:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, doError(err)
+	}
+
+	if writeErr != nil {
+		return report, doError(fmt.Errorf("could not record progress: %v", writeErr))
+	}
+
+	return report, nil
+}
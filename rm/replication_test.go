@@ -0,0 +1,148 @@
+package nexusrm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func replicationTestRMs(t *testing.T) (source, target *fakeRepositoryManager) {
+	source = newFakeRepositoryManager(t,
+		inventoryRepository("releases", "maven2", "hosted", "default"),
+		inventoryRepository("npm-releases", "npm", "hosted", "default"),
+		inventoryRepository("raw-releases", "raw", "hosted", "default"),
+	)
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.jar", []byte("app jar"), "build-1")
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.jar.sha1", []byte("sidecar"))
+	source.addAsset("releases", "org.test", "app", "1.0", "org/test/app/1.0/app-1.0.pom", []byte("app pom"))
+	source.addAsset("releases", "org.test", "lib", "2.0", "org/test/lib/2.0/lib-2.0.jar", []byte("lib jar"), "release")
+	source.addAsset("npm-releases", "", "left-pad", "1.3.0", "left-pad/-/left-pad-1.3.0.tgz", []byte("left-pad tarball"))
+	source.addAsset("raw-releases", "/files", "app.tar.gz", "", "files/app.tar.gz", []byte("app tarball"))
+	source.addAsset("raw-releases", "/files", "app.tar.gz.sha256", "", "files/app.tar.gz.sha256", []byte("uploaded checksum"))
+	source.tags["build-1"] = true
+	source.tags["release"] = true
+
+	target = newFakeRepositoryManager(t,
+		inventoryRepository("mirror", "maven2", "hosted", "default"),
+		inventoryRepository("npm-mirror", "npm", "hosted", "default"),
+		inventoryRepository("raw-mirror", "raw", "hosted", "default"),
+	)
+	target.addAsset("mirror", "org.test", "lib", "2.0", "org/test/lib/2.0/lib-2.0.jar", []byte("lib jar"))
+	target.uploadCoordinates[fakeChecksum([]byte("left-pad tarball")).Sha1] = RepositoryItem{
+		Name: "left-pad", Version: "1.3.0", Assets: []RepositoryItemAsset{{Path: "left-pad/-/left-pad-1.3.0.tgz"}},
+	}
+
+	return
+}
+
+func TestReplicateRepository(t *testing.T) {
+	source, target := replicationTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	report, err := ReplicateRepository(context.Background(), source.rm, "releases", target.rm, "mirror", ReplicationOptions{Parallel: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Copied != 1 || report.Skipped != 1 || report.Failed != 0 {
+		t.Errorf("Unexpected report: %v", report)
+	}
+
+	sort.Strings(target.uploads)
+	expectedUploads := []string{"mirror/org/test/app/1.0/app-1.0.jar", "mirror/org/test/app/1.0/app-1.0.pom"}
+	if !reflect.DeepEqual(target.uploads, expectedUploads) {
+		t.Errorf("Expected uploads %v but got %v", expectedUploads, target.uploads)
+	}
+
+	tags := make(map[string][]string)
+	for _, c := range target.components {
+		tags[c.Name] = c.Tags
+	}
+	if !reflect.DeepEqual(tags["app"], []string{"build-1"}) || !reflect.DeepEqual(tags["lib"], []string{"release"}) {
+		t.Errorf("Tags not preserved: %v", tags)
+	}
+
+	// nothing changed, so nothing is copied again
+	target.uploads = nil
+	if report, err = ReplicateRepository(context.Background(), source.rm, "releases", target.rm, "mirror", ReplicationOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Copied != 0 || report.Skipped != 2 || len(target.uploads) != 0 {
+		t.Errorf("Expected incremental replication to skip everything: %v, %v", report, target.uploads)
+	}
+}
+
+func TestReplicateRepositoryComponentUpload(t *testing.T) {
+	source, target := replicationTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	report, err := ReplicateRepository(context.Background(), source.rm, "npm-releases", target.rm, "npm-mirror", ReplicationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Copied != 1 || !reflect.DeepEqual(target.uploads, []string{"npm-mirror/left-pad/-/left-pad-1.3.0.tgz"}) {
+		t.Errorf("Unexpected replication: %v, %v", report, target.uploads)
+	}
+}
+
+func TestReplicateRepositoryChecksumFiles(t *testing.T) {
+	source, target := replicationTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	// only maven2 checksum files are generated by RM, in other formats they are uploaded content
+	report, err := ReplicateRepository(context.Background(), source.rm, "raw-releases", target.rm, "raw-mirror", ReplicationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(target.uploads)
+	expectedUploads := []string{"raw-mirror/files/app.tar.gz", "raw-mirror/files/app.tar.gz.sha256"}
+	if report.Copied != 2 || !reflect.DeepEqual(target.uploads, expectedUploads) {
+		t.Errorf("Expected uploads %v but got %v (%v)", expectedUploads, target.uploads, report)
+	}
+}
+
+func TestReplicateRepositoryResumes(t *testing.T) {
+	source, target := replicationTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	dir, err := ioutil.TempDir("", "replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	progress := filepath.Join(dir, "progress.jsonl")
+	if err = ioutil.WriteFile(progress, []byte(`{"component":"org.test:app:1.0","status":"copied"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ReplicateRepository(context.Background(), source.rm, "releases", target.rm, "mirror", ReplicationOptions{ProgressFile: progress})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Resumed != 1 || report.Skipped != 1 || report.Copied != 0 || len(target.uploads) != 0 {
+		t.Errorf("Expected replicated component to be resumed: %v, %v", report, target.uploads)
+	}
+}
+
+func TestReplicateRepositoryFormatMismatch(t *testing.T) {
+	source, target := replicationTestRMs(t)
+	defer source.Close()
+	defer target.Close()
+
+	if _, err := ReplicateRepository(context.Background(), source.rm, "releases", target.rm, "npm-mirror", ReplicationOptions{}); err == nil {
+		t.Error("Expected error replicating to a repository of another format")
+	}
+}
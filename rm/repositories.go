@@ -9,6 +9,7 @@ import (
 
 const (
 	restRepositories               = "service/rest/v1/repositories"
+	restRepositorySettings         = "service/rest/v1/repositorySettings"
 	restRepositoriesHostedApt      = "service/rest/v1/repositories/apt/hosted"
 	restRepositoriesHostedBower    = "service/rest/v1/repositories/bower/hosted"
 	restRepositoriesHostedDocker   = "service/rest/v1/repositories/docker/hosted"
@@ -91,6 +92,48 @@ const (
 	Yum
 )
 
+func parseRepositoryFormat(format string) repositoryFormat {
+	switch format {
+	case "apt":
+		return Apt
+	case "bower":
+		return Bower
+	case "cocoapods":
+		return Cocoapods
+	case "conan":
+		return Conan
+	case "conda":
+		return Conda
+	case "docker":
+		return Docker
+	case "gitlfs":
+		return GitLfs
+	case "go":
+		return Golang
+	case "helm":
+		return Helm
+	case "maven2":
+		return Maven
+	case "npm":
+		return Npm
+	case "nuget":
+		return Nuget
+	case "p2":
+		return P2
+	case "pypi":
+		return Pypi
+	case "r":
+		return R
+	case "raw":
+		return Raw
+	case "rubygems":
+		return Rubygems
+	case "yum":
+		return Yum
+	}
+	return Unknown
+}
+
 // Repository collects the information returned by RM about a repository
 type Repository struct {
 	Name       string `json:"name"`
@@ -161,6 +204,69 @@ type RepositoryRawHosted struct {
 	Raw     AttributesRaw           `json:"raw"`
 }
 
+// AttributesProxy configures the remote of a proxy repository and how long its content is cached
+type AttributesProxy struct {
+	RemoteURL      string `json:"remoteUrl"`
+	ContentMaxAge  int    `json:"contentMaxAge"`  // minutes, -1 to cache forever
+	MetadataMaxAge int    `json:"metadataMaxAge"` // minutes, -1 to cache forever
+}
+
+// AttributesNegativeCache configures the caching of the paths not found on the remote of a proxy repository
+type AttributesNegativeCache struct {
+	Enabled    bool `json:"enabled"`
+	TimeToLive int  `json:"timeToLive"` // minutes
+}
+
+// AttributesHTTPClient configures whether a proxy repository stops contacting its remote, manually or when it is unreachable
+type AttributesHTTPClient struct {
+	Blocked   bool `json:"blocked"`
+	AutoBlock bool `json:"autoBlock"`
+}
+
+// AttributesMaven configures the versions (RELEASE, SNAPSHOT or MIXED) and layout (STRICT or PERMISSIVE) allowed in a maven repository
+type AttributesMaven struct {
+	VersionPolicy string `json:"versionPolicy"`
+	LayoutPolicy  string `json:"layoutPolicy"`
+}
+
+// RepositoryRawProxy is the configuration of a raw proxy repository
+type RepositoryRawProxy struct {
+	Name          string                  `json:"name"`
+	Online        bool                    `json:"online"`
+	Storage       AttributesStorage       `json:"storage"`
+	Cleanup       AttributesCleanupPolicy `json:"cleanup"`
+	Proxy         AttributesProxy         `json:"proxy"`
+	NegativeCache AttributesNegativeCache `json:"negativeCache"`
+	HTTPClient    AttributesHTTPClient    `json:"httpClient"`
+	RoutingRule   string                  `json:"routingRule,omitempty"`
+	Raw           AttributesRaw           `json:"raw"`
+}
+
+// RepositoryMavenProxy is the configuration of a maven proxy repository
+type RepositoryMavenProxy struct {
+	Name          string                  `json:"name"`
+	Online        bool                    `json:"online"`
+	Storage       AttributesStorage       `json:"storage"`
+	Cleanup       AttributesCleanupPolicy `json:"cleanup"`
+	Proxy         AttributesProxy         `json:"proxy"`
+	NegativeCache AttributesNegativeCache `json:"negativeCache"`
+	HTTPClient    AttributesHTTPClient    `json:"httpClient"`
+	RoutingRule   string                  `json:"routingRule,omitempty"`
+	Maven         AttributesMaven         `json:"maven"`
+}
+
+// RepositoryNpmProxy is the configuration of an npm proxy repository
+type RepositoryNpmProxy struct {
+	Name          string                  `json:"name"`
+	Online        bool                    `json:"online"`
+	Storage       AttributesStorage       `json:"storage"`
+	Cleanup       AttributesCleanupPolicy `json:"cleanup"`
+	Proxy         AttributesProxy         `json:"proxy"`
+	NegativeCache AttributesNegativeCache `json:"negativeCache"`
+	HTTPClient    AttributesHTTPClient    `json:"httpClient"`
+	RoutingRule   string                  `json:"routingRule,omitempty"`
+}
+
 func CreateRepositoryHosted(rm RM, format repositoryFormat, r interface{}) error {
 	buf, err := json.Marshal(r)
 	if err != nil {
@@ -204,50 +310,54 @@ func CreateRepositoryHosted(rm RM, format repositoryFormat, r interface{}) error
 	return nil
 }
 
-func CreateRepositoryProxy(rm RM, format repositoryFormat, r interface{}) error {
-	buf, err := json.Marshal(r)
-	if err != nil {
-		return fmt.Errorf("could not marshal: %v", err)
-	}
-
-	var restEndpointRepository string
+// proxyRepositoryEndpoint returns the REST endpoint managing the proxy repositories of a format
+func proxyRepositoryEndpoint(format repositoryFormat) string {
 	switch format {
 	case Apt:
-		restEndpointRepository = restRepositoriesProxyApt
+		return restRepositoriesProxyApt
 	case Bower:
-		restEndpointRepository = restRepositoriesProxyBower
+		return restRepositoriesProxyBower
 	case Cocoapods:
-		restEndpointRepository = restRepositoriesProxyCocoapods
+		return restRepositoriesProxyCocoapods
 	case Conan:
-		restEndpointRepository = restRepositoriesProxyConan
+		return restRepositoriesProxyConan
 	case Conda:
-		restEndpointRepository = restRepositoriesProxyConda
+		return restRepositoriesProxyConda
 	case Docker:
-		restEndpointRepository = restRepositoriesProxyDocker
+		return restRepositoriesProxyDocker
 	case Golang:
-		restEndpointRepository = restRepositoriesProxyGolang
+		return restRepositoriesProxyGolang
 	case Helm:
-		restEndpointRepository = restRepositoriesProxyHelm
+		return restRepositoriesProxyHelm
 	case Maven:
-		restEndpointRepository = restRepositoriesProxyMaven
+		return restRepositoriesProxyMaven
 	case Npm:
-		restEndpointRepository = restRepositoriesProxyNpm
+		return restRepositoriesProxyNpm
 	case Nuget:
-		restEndpointRepository = restRepositoriesProxyNuget
+		return restRepositoriesProxyNuget
 	case P2:
-		restEndpointRepository = restRepositoriesProxyP2
+		return restRepositoriesProxyP2
 	case Pypi:
-		restEndpointRepository = restRepositoriesProxyPypi
+		return restRepositoriesProxyPypi
 	case R:
-		restEndpointRepository = restRepositoriesProxyR
+		return restRepositoriesProxyR
 	case Raw:
-		restEndpointRepository = restRepositoriesProxyRaw
+		return restRepositoriesProxyRaw
 	case Rubygems:
-		restEndpointRepository = restRepositoriesProxyRubygems
+		return restRepositoriesProxyRubygems
 	case Yum:
-		restEndpointRepository = restRepositoriesProxyYum
+		return restRepositoriesProxyYum
 	}
-	_, resp, err := rm.Post(restEndpointRepository, bytes.NewBuffer(buf))
+	return ""
+}
+
+func CreateRepositoryProxy(rm RM, format repositoryFormat, r interface{}) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %v", err)
+	}
+
+	_, resp, err := rm.Post(proxyRepositoryEndpoint(format), bytes.NewBuffer(buf))
 	if err != nil && resp == nil {
 		return fmt.Errorf("could not create repository: %v", err)
 	}
@@ -255,6 +365,21 @@ func CreateRepositoryProxy(rm RM, format repositoryFormat, r interface{}) error
 	return nil
 }
 
+// UpdateRepositoryProxy replaces the configuration of the named proxy repository
+func UpdateRepositoryProxy(rm RM, format repositoryFormat, name string, r interface{}) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/%s", proxyRepositoryEndpoint(format), name)
+	if _, resp, err := rm.Put(url, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not update repository '%s': %v", name, err)
+	}
+
+	return nil
+}
+
 func DeleteRepositoryByName(rm RM, name string) error {
 	url := fmt.Sprintf("%s/%s", restRepositories, name)
 
@@ -299,3 +424,39 @@ func GetRepositoryByName(rm RM, name string) (repo Repository, err error) {
 
 	return repo, fmt.Errorf("did not find repository '%s': %v", name, err)
 }
+
+// RepositorySettings holds the storage and group configuration of a repository
+type RepositorySettings struct {
+	Name    string `json:"name"`
+	Format  string `json:"format"`
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Online  bool   `json:"online"`
+	Storage struct {
+		BlobStoreName               string `json:"blobStoreName"`
+		StrictContentTypeValidation bool   `json:"strictContentTypeValidation"`
+		WritePolicy                 string `json:"writePolicy,omitempty"`
+	} `json:"storage"`
+	Group struct {
+		MemberNames []string `json:"memberNames,omitempty"`
+	} `json:"group,omitempty"`
+}
+
+// GetRepositorySettings returns the settings of every repository
+func GetRepositorySettings(rm RM) ([]RepositorySettings, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not get repository settings: %v", err)
+	}
+
+	body, resp, err := rm.Get(restRepositorySettings)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	settings := make([]RepositorySettings, 0)
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, doError(err)
+	}
+
+	return settings, nil
+}
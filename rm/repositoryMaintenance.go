@@ -0,0 +1,161 @@
+package nexusrm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+const (
+	restRepositoryRebuildIndex    = "service/rest/v1/repositories/%s/rebuild-index"
+	restRepositoryInvalidateCache = "service/rest/v1/repositories/%s/invalidate-cache"
+)
+
+// RM does not expose metadata rebuilds through REST, so they are submitted as one-off tasks
+// or, for apt which has no such task, done by the facet of the repository which maintains its indexes
+const (
+	groovyRebuildMetadata = `import org.sonatype.nexus.scheduling.TaskScheduler
+
+def scheduler = container.lookup(TaskScheduler.class.name)
+def config = scheduler.createTaskConfigurationInstance('{{.TaskType}}')
+config.setName('Rebuild metadata of {{.Repository}}')
+config.setString('repositoryName', '{{.Repository}}')
+scheduler.submit(config)
+return config.id`
+
+	// the facet is internal to the apt plugin, so it is looked up rather than imported to report its absence
+	groovyRebuildAptMetadata = `def facet
+try {
+    facet = Class.forName('org.sonatype.nexus.repository.apt.internal.hosted.AptHostedFacet', true, getClass().classLoader)
+} catch (ClassNotFoundException e) {
+    return '` + aptFacetMissing + `'
+}
+
+repository.repositoryManager.get('{{.Repository}}').facet(facet).rebuildIndexes()
+return 'rebuilt'`
+
+	aptFacetMissing = "missing AptHostedFacet"
+)
+
+type rebuildMetadata struct {
+	TaskType, Repository string
+}
+
+// RebuildRepositoryIndex rebuilds the search index of the named repository
+func RebuildRepositoryIndex(rm RM, repo string) error {
+	url := fmt.Sprintf(restRepositoryRebuildIndex, repo)
+	if _, resp, err := rm.Post(url, nil); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not rebuild index of repository '%s': %v", repo, err)
+	}
+
+	return nil
+}
+
+// InvalidateRepositoryCache invalidates the caches of the named proxy or group repository
+func InvalidateRepositoryCache(rm RM, repo string) error {
+	url := fmt.Sprintf(restRepositoryInvalidateCache, repo)
+	if _, resp, err := rm.Post(url, nil); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not invalidate cache of repository '%s': %v", repo, err)
+	}
+
+	return nil
+}
+
+// RebuildRepositoryMetadata starts a rebuild of the format-specific metadata of the named repository.
+// Only the maven2, yum and apt formats have metadata which RM can rebuild, and apt only in hosted repositories.
+// RM has no REST endpoint for these rebuilds so they are done with a Groovy script, which requires scripting
+// to be enabled with nexus.scripts.allowCreation=true in nexus.properties, as it is disabled by default
+// since RM 3.21.2. The apt rebuild relies on a class internal to RM, and fails when the class is not found.
+func RebuildRepositoryMetadata(rm RM, repo string) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not rebuild metadata of repository '%s': %v", repo, err)
+	}
+
+	repository, err := GetRepositoryByName(rm, repo)
+	if err != nil {
+		return doError(err)
+	}
+
+	script, taskType := groovyRebuildMetadata, ""
+	switch parseRepositoryFormat(repository.Format) {
+	case Maven:
+		taskType = TaskTypeRebuildMetadata
+	case Yum:
+		taskType = TaskTypeRebuildYumMetadata
+	case Apt:
+		if repository.Type != "hosted" {
+			return doError(fmt.Errorf("metadata rebuild is not supported for %s apt repositories", repository.Type))
+		}
+		script = groovyRebuildAptMetadata
+	default:
+		return doError(fmt.Errorf("metadata rebuild is not supported for the %s format", repository.Format))
+	}
+
+	tmpl, err := template.New("metadata").Parse(script)
+	if err != nil {
+		return doError(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tmpl.Execute(buf, rebuildMetadata{taskType, groovyString(repo)}); err != nil {
+		return doError(err)
+	}
+
+	result, err := ScriptRunOnce(rm, newAnonGroovyScript(buf.String()), nil)
+	if err != nil {
+		return doError(err)
+	}
+
+	if result == aptFacetMissing {
+		return doError(errors.New("this version of RM has no org.sonatype.nexus.repository.apt.internal.hosted.AptHostedFacet class to rebuild apt indexes with"))
+	}
+
+	return nil
+}
+
+// RepositoryFilter selects the repositories on which to perform an operation
+type RepositoryFilter func(Repository) bool
+
+// RepositoriesByFormat selects the repositories of any of the given formats (e.g. "maven2", "npm")
+func RepositoriesByFormat(formats ...string) RepositoryFilter {
+	return func(r Repository) bool {
+		for _, f := range formats {
+			if r.Format == f {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RepositoriesByType selects the repositories of any of the given types (e.g. "hosted", "proxy", "group")
+func RepositoriesByType(types ...string) RepositoryFilter {
+	return func(r Repository) bool {
+		for _, t := range types {
+			if r.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RunOnRepositories performs the given operation, such as RebuildRepositoryIndex, on each repository selected by the filter.
+// A nil filter selects every repository. The error of each operation, if any, is returned keyed by repository name.
+func RunOnRepositories(rm RM, filter RepositoryFilter, op func(rm RM, repo string) error) (map[string]error, error) {
+	repos, err := GetRepositories(rm)
+	if err != nil {
+		return nil, fmt.Errorf("could not get list of repositories: %v", err)
+	}
+
+	results := make(map[string]error)
+	for _, r := range repos {
+		if filter == nil || filter(r) {
+			results[r.Name] = op(rm, r.Name)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,174 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func repositoryMaintenanceTestRM(t *testing.T, calls map[string][]string) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path[1:], restRepositories) &&
+			(path.Base(r.URL.Path) == "rebuild-index" || path.Base(r.URL.Path) == "invalidate-cache"):
+			repo := path.Base(path.Dir(r.URL.Path))
+			op := path.Base(r.URL.Path)
+
+			for _, d := range dummyRepos {
+				if d.Name == repo {
+					if op == "invalidate-cache" && d.Type == "hosted" {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					calls[op] = append(calls[op], repo)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path[1:], restRepositories):
+			repositoriesTestFunc(t, w, r)
+		case strings.HasPrefix(r.URL.Path[1:], restScript):
+			if r.Method == http.MethodPost && r.URL.Path[1:] == restScript {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+				var script Script
+				if err := json.Unmarshal(body, &script); err != nil {
+					t.Fatal(err)
+				}
+				calls["script"] = append(calls["script"], script.Content)
+			}
+			scriptsTestFunc(t, w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestRebuildRepositoryIndex(t *testing.T) {
+	calls := make(map[string][]string)
+	rm, mock := repositoryMaintenanceTestRM(t, calls)
+	defer mock.Close()
+
+	if err := RebuildRepositoryIndex(rm, "repo-maven"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(calls["rebuild-index"], []string{"repo-maven"}) {
+		t.Errorf("Index not rebuilt: %v", calls)
+	}
+
+	if err := RebuildRepositoryIndex(rm, "nope"); err == nil {
+		t.Error("Expected error for unknown repository")
+	}
+}
+
+func TestInvalidateRepositoryCache(t *testing.T) {
+	calls := make(map[string][]string)
+	rm, mock := repositoryMaintenanceTestRM(t, calls)
+	defer mock.Close()
+
+	if err := InvalidateRepositoryCache(rm, "repo-npm"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InvalidateRepositoryCache(rm, "repo-maven"); err == nil {
+		t.Error("Expected error invalidating cache of hosted repository")
+	}
+}
+
+func TestRebuildRepositoryMetadata(t *testing.T) {
+	calls := make(map[string][]string)
+	rm, mock := repositoryMaintenanceTestRM(t, calls)
+	defer mock.Close()
+
+	if err := RebuildRepositoryMetadata(rm, "repo-maven"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls["script"]) != 1 {
+		t.Errorf("Expected metadata rebuild script to be uploaded: %v", calls)
+	}
+
+	defer func(repos []Repository) { dummyRepos = repos }(dummyRepos)
+	dummyRepos = append(dummyRepos,
+		Repository{Name: "repo-apt", Format: "apt", Type: "hosted"},
+		Repository{Name: "repo-apt-proxy", Format: "apt", Type: "proxy"},
+	)
+
+	if err := RebuildRepositoryMetadata(rm, "repo-apt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls["script"]) != 2 {
+		t.Errorf("Expected apt metadata rebuild script to be uploaded: %v", calls)
+	}
+
+	if err := RebuildRepositoryMetadata(rm, "repo-apt-proxy"); err == nil {
+		t.Error("Expected error rebuilding metadata of apt proxy repository")
+	}
+
+	if err := RebuildRepositoryMetadata(rm, "repo-npm"); err == nil {
+		t.Error("Expected error rebuilding metadata of npm repository")
+	}
+}
+
+func TestRebuildRepositoryMetadataEscapesName(t *testing.T) {
+	calls := make(map[string][]string)
+	rm, mock := repositoryMaintenanceTestRM(t, calls)
+	defer mock.Close()
+
+	defer func(repos []Repository) { dummyRepos = repos }(dummyRepos)
+	dummyRepos = append(dummyRepos, Repository{Name: `it's\`, Format: "maven2", Type: "hosted"})
+
+	if err := RebuildRepositoryMetadata(rm, `it's\`); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls["script"]) != 1 || !strings.Contains(calls["script"][0], `config.setString('repositoryName', 'it\'s\\')`) {
+		t.Errorf("Expected the repository name to be escaped in %v", calls["script"])
+	}
+}
+
+func TestRunOnRepositories(t *testing.T) {
+	calls := make(map[string][]string)
+	rm, mock := repositoryMaintenanceTestRM(t, calls)
+	defer mock.Close()
+
+	results, err := RunOnRepositories(rm, RepositoriesByType("hosted", "proxy"), RebuildRepositoryIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for repo, err := range results {
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", repo, err)
+		}
+	}
+
+	rebuilt := calls["rebuild-index"]
+	sort.Strings(rebuilt)
+	if expected := []string{"repo-maven", "repo-npm", "repo-nuget"}; !reflect.DeepEqual(rebuilt, expected) {
+		t.Errorf("Expected %v to be rebuilt but got %v", expected, rebuilt)
+	}
+
+	results, err = RunOnRepositories(rm, RepositoriesByFormat("maven2", "npm"), InvalidateRepositoryCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results["repo-npm"] != nil || results["repo-maven"] == nil {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
@@ -2,6 +2,8 @@ package nexusrm
 
 import (
 	"bytes"
+	"net/url"
+	"strings"
 
 	nexus "github.com/overag3/gonexus"
 )
@@ -39,7 +41,7 @@ func (b *QueryBuilder) buildCriteria(buf *bytes.Buffer) {
 		buf.WriteString("&")
 		buf.WriteString(k)
 		buf.WriteString("=")
-		buf.WriteString(v)
+		buf.WriteString(url.QueryEscape(v))
 	}
 }
 
@@ -157,9 +159,9 @@ func (b *QueryBuilder) MavenClassifier(v string) *QueryBuilder {
 	return b.addCriteria("maven.classifier", v)
 }
 
-// NpmScope allows specifiying the scope of an NPM component to filter by
+// NpmScope allows specifiying the scope of an NPM component to filter by, with or without its leading @
 func (b *QueryBuilder) NpmScope(v string) *QueryBuilder {
-	return b.addCriteria("npm.scope", v)
+	return b.addCriteria("npm.scope", strings.TrimPrefix(v, "@"))
 }
 
 // NugetID allows specifiying the ID/name of a Nuget component to filter by
@@ -0,0 +1,147 @@
+package rmmaven
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const snapshotSuffix = "-SNAPSHOT"
+
+var (
+	timestampedVersion = regexp.MustCompile(`^(.*)-(\d{8}\.\d{6})-(\d+)$`)
+	snapshotTimestamp  = regexp.MustCompile(`^\d{8}\.\d{6}-\d+`)
+)
+
+// Extensions made of several parts, which would otherwise be mistaken for a classifier and an extension
+var compoundExtensions = []string{"tar.gz", "tar.bz2", "tar.xz"}
+
+// Coordinates identify a file of a Maven artifact
+type Coordinates struct {
+	GroupID    string
+	ArtifactID string
+	// Version is the version of the file, which for a SNAPSHOT is either timestamped or ends with -SNAPSHOT
+	Version    string
+	Classifier string
+	Extension  string
+}
+
+// IsSnapshot returns true for SNAPSHOT versions, whether timestamped or not
+func IsSnapshot(version string) bool {
+	return strings.HasSuffix(version, snapshotSuffix) || timestampedVersion.MatchString(version)
+}
+
+// BaseVersion returns the version of the directory holding the files of a version,
+// which is the -SNAPSHOT version of a timestamped SNAPSHOT
+func BaseVersion(version string) string {
+	if m := timestampedVersion.FindStringSubmatch(version); m != nil {
+		return m[1] + snapshotSuffix
+	}
+	return version
+}
+
+// String returns the coordinates in the groupId:artifactId:extension[:classifier]:version form
+func (c Coordinates) String() string {
+	if c.Classifier != "" {
+		return fmt.Sprintf("%s:%s:%s:%s:%s", c.GroupID, c.ArtifactID, c.Extension, c.Classifier, c.Version)
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", c.GroupID, c.ArtifactID, c.Extension, c.Version)
+}
+
+// Filename returns the name of the file identified by the coordinates
+func (c Coordinates) Filename() string {
+	name := c.ArtifactID + "-" + c.Version
+	if c.Classifier != "" {
+		name += "-" + c.Classifier
+	}
+	return name + "." + c.Extension
+}
+
+// ArtifactDir returns the directory of the repository which holds every version of the artifact
+func (c Coordinates) ArtifactDir() string {
+	return path.Join(strings.Replace(c.GroupID, ".", "/", -1), c.ArtifactID)
+}
+
+// VersionDir returns the directory of the repository which holds the files of the version
+func (c Coordinates) VersionDir() string {
+	return path.Join(c.ArtifactDir(), BaseVersion(c.Version))
+}
+
+// Path returns the path of the file within the repository
+func (c Coordinates) Path() string {
+	return path.Join(c.VersionDir(), c.Filename())
+}
+
+// ParseFilename infers the classifier and extension of a file of the given artifact and version from its name.
+// The file of a SNAPSHOT version can also be named after a timestamped version, which is then returned.
+func ParseFilename(artifactID, version, filename string) (fileVersion, classifier, extension string, err error) {
+	prefix := artifactID + "-"
+	if !strings.HasPrefix(filename, prefix) {
+		return "", "", "", fmt.Errorf("file '%s' is not named after artifact '%s'", filename, artifactID)
+	}
+	rest := strings.TrimPrefix(filename, prefix)
+
+	fileVersion = version
+	if strings.HasPrefix(rest, version) {
+		rest = strings.TrimPrefix(rest, version)
+	} else if base := strings.TrimSuffix(version, snapshotSuffix); base != version && strings.HasPrefix(rest, base+"-") {
+		// timestamped SNAPSHOT: base-yyyyMMdd.HHmmss-buildNumber
+		candidate := rest[len(base)+1:]
+		m := snapshotTimestamp.FindString(candidate)
+		if m == "" {
+			return "", "", "", fmt.Errorf("file '%s' is not of version '%s'", filename, version)
+		}
+		fileVersion = base + "-" + m
+		rest = candidate[len(m):]
+	} else {
+		return "", "", "", fmt.Errorf("file '%s' is not of version '%s'", filename, version)
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "."):
+		extension = rest[1:]
+	case strings.HasPrefix(rest, "-"):
+		rest = rest[1:]
+		i := strings.Index(rest, ".")
+		for _, compound := range compoundExtensions {
+			if j := strings.Index(rest, "."+compound); j >= 0 && strings.HasSuffix(rest, "."+compound) {
+				i = j
+			}
+		}
+		if i <= 0 {
+			return "", "", "", fmt.Errorf("file '%s' has no extension", filename)
+		}
+		classifier, extension = rest[:i], rest[i+1:]
+	default:
+		return "", "", "", fmt.Errorf("file '%s' is not of version '%s'", filename, version)
+	}
+
+	if extension == "" {
+		return "", "", "", fmt.Errorf("file '%s' has no extension", filename)
+	}
+
+	return fileVersion, classifier, extension, nil
+}
+
+// ParsePath infers the coordinates of a file from its path within a repository, such as
+// org/example/app/1.0-SNAPSHOT/app-1.0-20200601.120000-1-sources.jar
+func ParsePath(p string) (Coordinates, error) {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	if len(parts) < 4 {
+		return Coordinates{}, fmt.Errorf("path '%s' is not an artifact of the Maven layout", p)
+	}
+
+	c := Coordinates{
+		GroupID:    strings.Join(parts[:len(parts)-3], "."),
+		ArtifactID: parts[len(parts)-3],
+	}
+
+	var err error
+	c.Version, c.Classifier, c.Extension, err = ParseFilename(c.ArtifactID, parts[len(parts)-2], parts[len(parts)-1])
+	if err != nil {
+		return Coordinates{}, fmt.Errorf("path '%s' is not an artifact of the Maven layout: %v", p, err)
+	}
+
+	return c, nil
+}
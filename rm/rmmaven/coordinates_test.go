@@ -0,0 +1,63 @@
+package rmmaven
+
+import "testing"
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Coordinates
+	}{
+		{"org/example/app/1.0/app-1.0.jar", Coordinates{"org.example", "app", "1.0", "", "jar"}},
+		{"org/example/app/1.0/app-1.0.pom", Coordinates{"org.example", "app", "1.0", "", "pom"}},
+		{"org/example/app/1.0/app-1.0-sources.jar", Coordinates{"org.example", "app", "1.0", "sources", "jar"}},
+		{"org/example/app/1.0/app-1.0.jar.sha1", Coordinates{"org.example", "app", "1.0", "", "jar.sha1"}},
+		{"org/example/app/1.0/app-1.0-linux-x86_64.tar.gz", Coordinates{"org.example", "app", "1.0", "linux-x86_64", "tar.gz"}},
+		{"/org/example/app/1.0-SNAPSHOT/app-1.0-SNAPSHOT.jar", Coordinates{"org.example", "app", "1.0-SNAPSHOT", "", "jar"}},
+		{"org/example/app/1.0-SNAPSHOT/app-1.0-20200601.120000-3-tests.jar", Coordinates{"org.example", "app", "1.0-20200601.120000-3", "tests", "jar"}},
+	}
+
+	for _, test := range tests {
+		actual, err := ParsePath(test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+
+		if actual != test.expected {
+			t.Errorf("%s: expected %v but got %v", test.path, test.expected, actual)
+		}
+
+		if actual.Path() != trimSlash(test.path) {
+			t.Errorf("Expected %v to be at %s but got %s", actual, test.path, actual.Path())
+		}
+	}
+
+	for _, invalid := range []string{"app/1.0/app-1.0.jar", "org/example/app/1.0/other-1.0.jar", "org/example/app/1.0/app-2.0.jar", "org/example/app/1.0/app-1.0"} {
+		if _, err := ParsePath(invalid); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func trimSlash(p string) string {
+	if p[0] == '/' {
+		return p[1:]
+	}
+	return p
+}
+
+func TestSnapshotVersions(t *testing.T) {
+	for version, expected := range map[string]string{
+		"1.0":                   "1.0",
+		"1.0-SNAPSHOT":          "1.0-SNAPSHOT",
+		"1.0-20200601.120000-3": "1.0-SNAPSHOT",
+	} {
+		if actual := BaseVersion(version); actual != expected {
+			t.Errorf("Expected base version of %s to be %s but got %s", version, expected, actual)
+		}
+
+		if IsSnapshot(version) != (expected != version || version == "1.0-SNAPSHOT") {
+			t.Errorf("Unexpected snapshot detection of %s", version)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package rmmaven
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	nexus "github.com/overag3/gonexus"
+)
+
+// Checksum files which are deployed next to every file, as mvn deploy does
+var sidecars = []struct {
+	extension string
+	hash      func() hash.Hash
+}{
+	{"sha1", sha1.New},
+	{"md5", md5.New},
+	{"sha256", sha256.New},
+}
+
+// Artifact is a file to deploy
+type Artifact struct {
+	Classifier string
+	Extension  string
+	Content    io.Reader
+}
+
+func repositoryEndpoint(repo, p string) string {
+	return fmt.Sprintf("repository/%s/%s", repo, strings.TrimPrefix(p, "/"))
+}
+
+// get returns the content at the path of the repository, if any
+func get(rm nexus.Client, repo, p string) ([]byte, bool, error) {
+	req, err := rm.NewRequest(http.MethodGet, repositoryEndpoint(repo, p), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		return body, err == nil, err
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, errors.New(resp.Status)
+	}
+}
+
+// put uploads the content to the path of the repository
+func put(rm nexus.Client, repo, p string, content io.Reader) error {
+	req, err := rm.NewRequest(http.MethodPut, repositoryEndpoint(repo, p), content)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := nexus.Stream(rm, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return errors.New(resp.Status)
+	}
+}
+
+// putWithChecksums uploads the content to the path of the repository, followed by its checksum files
+func putWithChecksums(rm nexus.Client, repo, p string, content io.Reader) error {
+	hashes := make([]hash.Hash, len(sidecars))
+	writers := make([]io.Writer, len(sidecars))
+	for i, s := range sidecars {
+		hashes[i] = s.hash()
+		writers[i] = hashes[i]
+	}
+
+	if err := put(rm, repo, p, io.TeeReader(content, io.MultiWriter(writers...))); err != nil {
+		return fmt.Errorf("could not deploy %s: %v", p, err)
+	}
+
+	for i, s := range sidecars {
+		sum := hex.EncodeToString(hashes[i].Sum(nil))
+		if err := put(rm, repo, p+"."+s.extension, strings.NewReader(sum)); err != nil {
+			return fmt.Errorf("could not deploy %s.%s: %v", p, s.extension, err)
+		}
+	}
+
+	return nil
+}
+
+// GetMetadata reads the maven-metadata.xml file in the given directory of the repository, such as the
+// ArtifactDir or VersionDir of some coordinates. Returns false if the directory has no metadata.
+func GetMetadata(rm nexus.Client, repo, dir string) (Metadata, bool, error) {
+	p := path.Join(dir, MetadataFile)
+
+	body, found, err := get(rm, repo, p)
+	if err != nil {
+		return Metadata{}, false, fmt.Errorf("could not read %s: %v", p, err)
+	}
+	if !found {
+		return Metadata{}, false, nil
+	}
+
+	m, err := ParseMetadata(bytes.NewReader(body))
+	return m, err == nil, err
+}
+
+// ResolveSnapshot returns the coordinates of the timestamped file of a SNAPSHOT version, according to the
+// metadata of the version in the repository. Coordinates of other versions are returned as they are.
+func ResolveSnapshot(rm nexus.Client, repo string, c Coordinates) (Coordinates, error) {
+	if !strings.HasSuffix(c.Version, snapshotSuffix) {
+		return c, nil
+	}
+
+	m, found, err := GetMetadata(rm, repo, c.VersionDir())
+	if err != nil {
+		return c, err
+	}
+
+	value, ok := m.ResolveSnapshot(c.Classifier, c.Extension)
+	if !found || !ok {
+		return c, fmt.Errorf("no deployment of %s in repository '%s'", c, repo)
+	}
+
+	c.Version = value
+	return c, nil
+}
+
+// Deploy uploads the files of an artifact version directly to their paths in a maven2 repository, each followed
+// by its .sha1, .md5 and .sha256 checksum files, as mvn deploy does. The files of a SNAPSHOT version are
+// deployed under a new timestamped version, recorded in the metadata of the version. The version is then added
+// to the metadata of the artifact. Returns the coordinates of the deployed files.
+func Deploy(rm nexus.Client, repo, groupID, artifactID, version string, artifacts ...Artifact) ([]Coordinates, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not deploy %s:%s:%s to '%s': %v", groupID, artifactID, version, repo, err)
+	}
+
+	if len(artifacts) == 0 {
+		return nil, doError(errors.New("no artifacts to deploy"))
+	}
+
+	if IsSnapshot(version) && !strings.HasSuffix(version, snapshotSuffix) {
+		return nil, doError(errors.New("SNAPSHOT versions are deployed by their -SNAPSHOT version"))
+	}
+
+	deployed := make([]Coordinates, len(artifacts))
+	for i, a := range artifacts {
+		if a.Extension == "" {
+			return nil, doError(errors.New("every artifact needs an extension"))
+		}
+		deployed[i] = Coordinates{GroupID: groupID, ArtifactID: artifactID, Version: version, Classifier: a.Classifier, Extension: a.Extension}
+	}
+
+	now := time.Now()
+
+	var snapshot Metadata
+	if IsSnapshot(version) {
+		var found bool
+		var err error
+		snapshot, found, err = GetMetadata(rm, repo, deployed[0].VersionDir())
+		if err != nil {
+			return nil, doError(err)
+		}
+		if !found {
+			snapshot = NewSnapshotMetadata(groupID, artifactID, version)
+		}
+
+		timestamped := snapshot.AddSnapshot(now, deployed...)
+		for i := range deployed {
+			deployed[i].Version = timestamped
+		}
+	}
+
+	for i, a := range artifacts {
+		if err := putWithChecksums(rm, repo, deployed[i].Path(), a.Content); err != nil {
+			return nil, doError(err)
+		}
+	}
+
+	if IsSnapshot(version) {
+		buf, err := snapshot.Marshal()
+		if err != nil {
+			return nil, doError(err)
+		}
+		if err = putWithChecksums(rm, repo, path.Join(deployed[0].VersionDir(), MetadataFile), bytes.NewReader(buf)); err != nil {
+			return nil, doError(err)
+		}
+	}
+
+	c := Coordinates{GroupID: groupID, ArtifactID: artifactID}
+	metadata, found, err := GetMetadata(rm, repo, c.ArtifactDir())
+	if err != nil {
+		return nil, doError(err)
+	}
+	if !found {
+		metadata = NewArtifactMetadata(groupID, artifactID)
+	}
+	metadata.AddVersion(version, now)
+
+	buf, err := metadata.Marshal()
+	if err != nil {
+		return nil, doError(err)
+	}
+	if err = putWithChecksums(rm, repo, path.Join(c.ArtifactDir(), MetadataFile), bytes.NewReader(buf)); err != nil {
+		return nil, doError(err)
+	}
+
+	return deployed, nil
+}
@@ -0,0 +1,162 @@
+package rmmaven
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	nexus "github.com/overag3/gonexus"
+)
+
+// fakeRepository stores the content put to the paths of a repository
+type fakeRepository struct {
+	mu      sync.Mutex
+	content map[string][]byte
+}
+
+func newFakeRepository(t *testing.T) (*fakeRepository, nexus.Client, *httptest.Server) {
+	repo := &fakeRepository{content: make(map[string][]byte)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		p := strings.TrimPrefix(r.URL.Path, "/repository/")
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := repo.content[p]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(content)
+		case http.MethodPut:
+			content, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo.content[p] = content
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	client := &nexus.DefaultClient{ServerInfo: nexus.ServerInfo{Host: server.URL, Username: "user", Password: "pass"}}
+
+	return repo, client, server
+}
+
+func (r *fakeRepository) paths() []string {
+	paths := make([]string, 0, len(r.content))
+	for p := range r.content {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestDeployRelease(t *testing.T) {
+	repo, rm, server := newFakeRepository(t)
+	defer server.Close()
+
+	deployed, err := Deploy(rm, "releases", "org.example", "app", "1.0",
+		Artifact{Extension: "jar", Content: strings.NewReader("jar")},
+		Artifact{Extension: "pom", Content: strings.NewReader("<project/>")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deployed) != 2 || deployed[0].Path() != "org/example/app/1.0/app-1.0.jar" {
+		t.Errorf("Unexpected deployment: %v", deployed)
+	}
+
+	expected := []string{
+		"releases/org/example/app/1.0/app-1.0.jar",
+		"releases/org/example/app/1.0/app-1.0.jar.md5",
+		"releases/org/example/app/1.0/app-1.0.jar.sha1",
+		"releases/org/example/app/1.0/app-1.0.jar.sha256",
+		"releases/org/example/app/1.0/app-1.0.pom",
+		"releases/org/example/app/1.0/app-1.0.pom.md5",
+		"releases/org/example/app/1.0/app-1.0.pom.sha1",
+		"releases/org/example/app/1.0/app-1.0.pom.sha256",
+		"releases/org/example/app/maven-metadata.xml",
+		"releases/org/example/app/maven-metadata.xml.md5",
+		"releases/org/example/app/maven-metadata.xml.sha1",
+		"releases/org/example/app/maven-metadata.xml.sha256",
+	}
+	if actual := repo.paths(); strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected paths %v but got %v", expected, actual)
+	}
+
+	sum := sha1.Sum([]byte("jar"))
+	if string(repo.content["releases/org/example/app/1.0/app-1.0.jar.sha1"]) != hex.EncodeToString(sum[:]) {
+		t.Error("Unexpected sha1 checksum")
+	}
+
+	if _, err = Deploy(rm, "releases", "org.example", "app", "1.1", Artifact{Extension: "jar", Content: strings.NewReader("jar")}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, found, err := GetMetadata(rm, "releases", "org/example/app")
+	if err != nil || !found {
+		t.Fatalf("Metadata not found: %v", err)
+	}
+
+	if strings.Join(m.Versioning.Versions, ",") != "1.0,1.1" || m.Versioning.Release != "1.1" {
+		t.Errorf("Unexpected metadata: %v", m.Versioning)
+	}
+}
+
+func TestDeploySnapshot(t *testing.T) {
+	repo, rm, server := newFakeRepository(t)
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := Deploy(rm, "snapshots", "org.example", "app", "1.0-SNAPSHOT",
+			Artifact{Extension: "jar", Content: strings.NewReader("jar")},
+			Artifact{Classifier: "sources", Extension: "jar", Content: strings.NewReader("sources")},
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolved, err := ResolveSnapshot(rm, "snapshots", Coordinates{"org.example", "app", "1.0-SNAPSHOT", "sources", "jar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(resolved.Version, "-2") || BaseVersion(resolved.Version) != "1.0-SNAPSHOT" {
+		t.Errorf("Expected second deployment but got %s", resolved.Version)
+	}
+
+	if !bytes.Equal(repo.content["snapshots/"+resolved.Path()], []byte("sources")) {
+		t.Errorf("Resolved snapshot %s not deployed", resolved.Path())
+	}
+
+	if _, ok := repo.content["snapshots/org/example/app/1.0-SNAPSHOT/maven-metadata.xml.sha256"]; !ok {
+		t.Error("Expected checksums of the version metadata")
+	}
+
+	if _, err = ResolveSnapshot(rm, "snapshots", Coordinates{"org.example", "other", "1.0-SNAPSHOT", "", "jar"}); err == nil {
+		t.Error("Expected undeployed snapshot not to resolve")
+	}
+
+	if _, err = Deploy(rm, "snapshots", "org.example", "app", "1.0-20200601.120000-1", Artifact{Extension: "jar", Content: strings.NewReader("jar")}); err == nil {
+		t.Error("Expected timestamped version to be rejected")
+	}
+
+	for _, version := range []string{"1.0", "1.0-SNAPSHOT"} {
+		if _, err = Deploy(rm, "snapshots", "org.example", "app", version); err == nil {
+			t.Errorf("Expected deployment of %s without artifacts to be rejected", version)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Package rmmaven understands the Maven repository layout of Nexus Repository Manager maven2 repositories.
+It parses and generates maven-metadata.xml, resolves SNAPSHOT versions to their timestamped files, infers
+coordinates from repository paths and deploys artifacts directly to repository paths as mvn deploy does.
+
+	rm, err := nexusrm.New("http://localhost:8081", "username", "password")
+	if err != nil {
+	    panic(err)
+	}
+
+	jar, err := os.Open("app-1.0-SNAPSHOT.jar")
+	if err != nil {
+	    panic(err)
+	}
+	defer jar.Close()
+
+	deployed, err := rmmaven.Deploy(rm, "maven-snapshots", "org.example", "app", "1.0-SNAPSHOT",
+	    rmmaven.Artifact{Extension: "jar", Content: jar})
+
+Every function which talks to RM accepts any nexus.Client, such as a nexusrm.RM.
+*/
+package rmmaven
@@ -0,0 +1,195 @@
+package rmmaven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/overag3/gonexus/versions"
+)
+
+// MetadataFile is the name of the metadata files of the Maven layout
+const MetadataFile = "maven-metadata.xml"
+
+const (
+	metadataModelVersion = "1.1.0"
+	timestampFormat      = "20060102.150405"
+	lastUpdatedFormat    = "20060102150405"
+)
+
+// Metadata is the content of a maven-metadata.xml file. The metadata of an artifact lists its versions,
+// while that of a SNAPSHOT version lists the timestamped files of the version.
+type Metadata struct {
+	XMLName      xml.Name         `xml:"metadata"`
+	ModelVersion string           `xml:"modelVersion,attr,omitempty"`
+	GroupID      string           `xml:"groupId,omitempty"`
+	ArtifactID   string           `xml:"artifactId,omitempty"`
+	Version      string           `xml:"version,omitempty"`
+	Versioning   *Versioning      `xml:"versioning,omitempty"`
+	Plugins      []MetadataPlugin `xml:"plugins>plugin,omitempty"`
+}
+
+// Versioning holds the versions of an artifact or the files of a SNAPSHOT version
+type Versioning struct {
+	Latest           string            `xml:"latest,omitempty"`
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *Snapshot         `xml:"snapshot,omitempty"`
+	Versions         []string          `xml:"versions>version,omitempty"`
+	LastUpdated      string            `xml:"lastUpdated,omitempty"`
+	SnapshotVersions []SnapshotVersion `xml:"snapshotVersions>snapshotVersion,omitempty"`
+}
+
+// Snapshot identifies the latest deployment of a SNAPSHOT version
+type Snapshot struct {
+	Timestamp   string `xml:"timestamp,omitempty"`
+	BuildNumber int    `xml:"buildNumber,omitempty"`
+	LocalCopy   bool   `xml:"localCopy,omitempty"`
+}
+
+// SnapshotVersion is the timestamped version of a file of a SNAPSHOT version
+type SnapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+// MetadataPlugin describes a plugin in the metadata of a group
+type MetadataPlugin struct {
+	Name       string `xml:"name,omitempty"`
+	Prefix     string `xml:"prefix"`
+	ArtifactID string `xml:"artifactId"`
+}
+
+// ParseMetadata reads a maven-metadata.xml file
+func ParseMetadata(r io.Reader) (Metadata, error) {
+	var m Metadata
+	if err := xml.NewDecoder(r).Decode(&m); err != nil {
+		return m, fmt.Errorf("could not parse maven metadata: %v", err)
+	}
+	return m, nil
+}
+
+// Marshal encodes the metadata as a maven-metadata.xml file
+func (m Metadata) Marshal() ([]byte, error) {
+	if m.ModelVersion == "" {
+		m.ModelVersion = metadataModelVersion
+	}
+
+	buf, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode maven metadata: %v", err)
+	}
+
+	return append([]byte(xml.Header), append(buf, '\n')...), nil
+}
+
+// NewArtifactMetadata creates the metadata of an artifact with the given versions
+func NewArtifactMetadata(groupID, artifactID string, vs ...string) Metadata {
+	m := Metadata{GroupID: groupID, ArtifactID: artifactID, Versioning: &Versioning{}}
+	for _, v := range vs {
+		m.AddVersion(v, time.Now())
+	}
+	return m
+}
+
+// AddVersion adds a version to the metadata of an artifact, keeping the versions ordered,
+// and updates the latest and release versions
+func (m *Metadata) AddVersion(version string, updated time.Time) {
+	if m.Versioning == nil {
+		m.Versioning = &Versioning{}
+	}
+	v := m.Versioning
+
+	found := false
+	for _, existing := range v.Versions {
+		if existing == version {
+			found = true
+		}
+	}
+	if !found {
+		v.Versions = append(v.Versions, version)
+	}
+	versions.Sort(versions.Maven, v.Versions)
+
+	v.Latest, v.Release = "", ""
+	for _, existing := range v.Versions {
+		v.Latest = existing
+		if !IsSnapshot(existing) {
+			v.Release = existing
+		}
+	}
+
+	v.LastUpdated = updated.UTC().Format(lastUpdatedFormat)
+}
+
+// NewSnapshotMetadata creates the metadata of a SNAPSHOT version
+func NewSnapshotMetadata(groupID, artifactID, version string) Metadata {
+	return Metadata{GroupID: groupID, ArtifactID: artifactID, Version: BaseVersion(version), Versioning: &Versioning{}}
+}
+
+// AddSnapshot records a deployment of the SNAPSHOT version of the metadata, made of files with the given
+// classifiers and extensions, which replace any previous file with the same classifier and extension.
+// Returns the timestamped version of the files, which is numbered after the latest recorded deployment.
+func (m *Metadata) AddSnapshot(deployed time.Time, files ...Coordinates) string {
+	if m.Versioning == nil {
+		m.Versioning = &Versioning{}
+	}
+	v := m.Versioning
+
+	buildNumber := 1
+	if v.Snapshot != nil {
+		buildNumber = v.Snapshot.BuildNumber + 1
+	}
+
+	timestamp := deployed.UTC().Format(timestampFormat)
+	v.Snapshot = &Snapshot{Timestamp: timestamp, BuildNumber: buildNumber}
+	v.LastUpdated = deployed.UTC().Format(lastUpdatedFormat)
+
+	value := fmt.Sprintf("%s-%s-%d", strings.TrimSuffix(m.Version, snapshotSuffix), timestamp, buildNumber)
+
+	for _, f := range files {
+		sv := SnapshotVersion{Classifier: f.Classifier, Extension: f.Extension, Value: value, Updated: v.LastUpdated}
+
+		replaced := false
+		for i, existing := range v.SnapshotVersions {
+			if existing.Classifier == f.Classifier && existing.Extension == f.Extension {
+				v.SnapshotVersions[i] = sv
+				replaced = true
+			}
+		}
+		if !replaced {
+			v.SnapshotVersions = append(v.SnapshotVersions, sv)
+		}
+	}
+
+	return value
+}
+
+// ResolveSnapshot returns the timestamped version of the file of the SNAPSHOT version with the given classifier
+// and extension. Metadata written by older Maven versions does not list its files, in which case the version
+// of the latest deployment is returned.
+func (m Metadata) ResolveSnapshot(classifier, extension string) (string, bool) {
+	if m.Versioning == nil {
+		return "", false
+	}
+
+	if len(m.Versioning.SnapshotVersions) > 0 {
+		for _, sv := range m.Versioning.SnapshotVersions {
+			if sv.Classifier == classifier && sv.Extension == extension {
+				return sv.Value, true
+			}
+		}
+		return "", false
+	}
+
+	s := m.Versioning.Snapshot
+	if s == nil || s.Timestamp == "" || s.LocalCopy {
+		return "", false
+	}
+
+	return strings.TrimSuffix(m.Version, snapshotSuffix) + "-" + s.Timestamp + "-" + strconv.Itoa(s.BuildNumber), true
+}
@@ -0,0 +1,129 @@
+package rmmaven
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const dummySnapshotMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata modelVersion="1.1.0">
+  <groupId>org.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0-SNAPSHOT</version>
+  <versioning>
+    <snapshot>
+      <timestamp>20200601.120000</timestamp>
+      <buildNumber>3</buildNumber>
+    </snapshot>
+    <lastUpdated>20200601120000</lastUpdated>
+    <snapshotVersions>
+      <snapshotVersion>
+        <extension>jar</extension>
+        <value>1.0-20200601.120000-3</value>
+        <updated>20200601120000</updated>
+      </snapshotVersion>
+      <snapshotVersion>
+        <classifier>sources</classifier>
+        <extension>jar</extension>
+        <value>1.0-20200530.080000-2</value>
+        <updated>20200530080000</updated>
+      </snapshotVersion>
+    </snapshotVersions>
+  </versioning>
+</metadata>`
+
+func TestParseMetadata(t *testing.T) {
+	m, err := ParseMetadata(strings.NewReader(dummySnapshotMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.GroupID != "org.example" || m.ArtifactID != "app" || m.Version != "1.0-SNAPSHOT" {
+		t.Errorf("Unexpected metadata: %v", m)
+	}
+
+	for _, test := range []struct{ classifier, extension, expected string }{
+		{"", "jar", "1.0-20200601.120000-3"},
+		{"sources", "jar", "1.0-20200530.080000-2"},
+	} {
+		if actual, ok := m.ResolveSnapshot(test.classifier, test.extension); !ok || actual != test.expected {
+			t.Errorf("Expected %s but got %s", test.expected, actual)
+		}
+	}
+
+	if _, ok := m.ResolveSnapshot("javadoc", "jar"); ok {
+		t.Error("Expected no javadoc")
+	}
+
+	// older metadata only has the latest deployment
+	m.Versioning.SnapshotVersions = nil
+	if actual, ok := m.ResolveSnapshot("javadoc", "jar"); !ok || actual != "1.0-20200601.120000-3" {
+		t.Errorf("Unexpected resolution from snapshot: %s", actual)
+	}
+
+	buf, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := ParseMetadata(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, m) {
+		t.Errorf("Expected %v but got %v", m, decoded)
+	}
+}
+
+func TestArtifactMetadata(t *testing.T) {
+	m := NewArtifactMetadata("org.example", "app", "1.10", "1.9", "2.0-SNAPSHOT")
+
+	updated := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	m.AddVersion("1.9", updated)
+
+	expected := []string{"1.9", "1.10", "2.0-SNAPSHOT"}
+	if !reflect.DeepEqual(m.Versioning.Versions, expected) {
+		t.Errorf("Expected versions %v but got %v", expected, m.Versioning.Versions)
+	}
+
+	if m.Versioning.Latest != "2.0-SNAPSHOT" || m.Versioning.Release != "1.10" || m.Versioning.LastUpdated != "20200601120000" {
+		t.Errorf("Unexpected versioning: %v", m.Versioning)
+	}
+
+	buf, err := m.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(buf), "<versions>\n      <version>1.9</version>") {
+		t.Errorf("Unexpected encoding: %s", buf)
+	}
+}
+
+func TestAddSnapshot(t *testing.T) {
+	m, err := ParseMetadata(strings.NewReader(dummySnapshotMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := m.AddSnapshot(time.Date(2020, 6, 2, 8, 30, 0, 0, time.UTC),
+		Coordinates{Extension: "jar"}, Coordinates{Extension: "pom"})
+
+	if value != "1.0-20200602.083000-4" {
+		t.Errorf("Unexpected snapshot version %s", value)
+	}
+
+	for _, test := range []struct{ classifier, extension, expected string }{
+		{"", "jar", value},
+		{"", "pom", value},
+		{"sources", "jar", "1.0-20200530.080000-2"},
+	} {
+		if actual, _ := m.ResolveSnapshot(test.classifier, test.extension); actual != test.expected {
+			t.Errorf("Expected %s but got %s", test.expected, actual)
+		}
+	}
+}
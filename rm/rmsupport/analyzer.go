@@ -0,0 +1,120 @@
+package rmsupport
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Locations of the analyzed files, relative to the top-level directory of the zip
+const (
+	fileNexusLog  = "log/nexus.log"
+	fileSysInfo   = "info/sysinfo.json"
+	fileThreads   = "info/threads.txt"
+	fileConfigDB  = "work/db/config/export.json"
+	fileConfigDB2 = "db/config/export.json"
+)
+
+// Analysis contains the information extracted from a support zip.
+// Parts which are not included in the zip are left empty.
+type Analysis struct {
+	Log          LogSummary
+	System       SystemInfo
+	Threads      ThreadDumpSummary
+	Repositories []Repository
+	BlobStores   []BlobStore
+}
+
+// Analyze opens the support zip at the given path and extracts its information
+func Analyze(path string) (Analysis, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("could not open support zip: %v", err)
+	}
+	defer r.Close()
+
+	return analyze(&r.Reader)
+}
+
+// AnalyzeReader extracts the information of a support zip of the given size
+func AnalyzeReader(r io.ReaderAt, size int64) (Analysis, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("could not read support zip: %v", err)
+	}
+
+	return analyze(z)
+}
+
+func analyze(z *zip.Reader) (analysis Analysis, err error) {
+	doError := func(name string, err error) error {
+		return fmt.Errorf("could not analyze %s: %v", name, err)
+	}
+
+	if f := findFile(z, fileNexusLog); f != nil {
+		if err = withFile(f, func(r io.Reader) (err error) {
+			analysis.Log, err = ParseLog(r)
+			return
+		}); err != nil {
+			return analysis, doError(f.Name, err)
+		}
+	}
+
+	if f := findFile(z, fileSysInfo); f != nil {
+		if err = withFile(f, func(r io.Reader) (err error) {
+			analysis.System, err = ParseSysInfo(r)
+			return
+		}); err != nil {
+			return analysis, doError(f.Name, err)
+		}
+	}
+
+	if f := findFile(z, fileThreads); f != nil {
+		if err = withFile(f, func(r io.Reader) (err error) {
+			analysis.Threads, err = ParseThreadDump(r)
+			return
+		}); err != nil {
+			return analysis, doError(f.Name, err)
+		}
+	}
+
+	f := findFile(z, fileConfigDB)
+	if f == nil {
+		f = findFile(z, fileConfigDB2)
+	}
+	if f != nil {
+		if err = withFile(f, func(r io.Reader) (err error) {
+			analysis.Repositories, analysis.BlobStores, err = ParseConfigExport(r)
+			return
+		}); err != nil {
+			return analysis, doError(f.Name, err)
+		}
+	}
+
+	return analysis, nil
+}
+
+// findFile looks for the file with the given path, ignoring the top-level directory of the zip
+func findFile(z *zip.Reader, path string) *zip.File {
+	for _, f := range z.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		if name == path {
+			return f
+		}
+		if i := strings.Index(name, "/"); i >= 0 && name[i+1:] == path {
+			return f
+		}
+	}
+	return nil
+}
+
+func withFile(f *zip.File, fn func(io.Reader) error) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return fn(r)
+}
@@ -0,0 +1,141 @@
+package rmsupport
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const dummySysInfo = `{
+  "system-properties": {"java.version": "1.8.0_252", "java.vendor": "AdoptOpenJDK", "java.vm.name": "OpenJDK 64-Bit Server VM", "os.name": "Linux", "os.version": "5.4.0", "os.arch": "amd64"},
+  "system-runtime": {"availableProcessors": 4, "freeMemory": 100, "totalMemory": 200, "maxMemory": 400, "threads": 120},
+  "system-filestores": {"/dev/sda1": {"description": "/ (/dev/sda1)", "type": "ext4", "name": "/dev/sda1", "totalSpace": 1000, "usableSpace": 10, "readOnly": false}},
+  "nexus-status": {"version": "3.24.0-02", "edition": "OSS"}
+}`
+
+const dummyConfigExport = `{
+  "info": {"name": "config"},
+  "records": [
+    {"@class": "repository", "repository_name": "maven-public", "recipe_name": "maven2-group", "online": true, "attributes": {"storage": {"blobStoreName": "default"}, "group": {"memberNames": ["maven-central", "maven-releases"]}}},
+    {"@class": "repository", "repository_name": "maven-central", "recipe_name": "maven2-proxy", "online": true, "attributes": {"storage": {"blobStoreName": "default"}, "proxy": {"remoteUrl": "https://repo1.maven.org/maven2/"}}},
+    {"@class": "repository_blobstore", "name": "default", "type": "File", "attributes": {"file": {"path": "default"}}},
+    {"@class": "repository_blobstore", "name": "s3", "type": "S3", "attributes": {"s3": {"bucket": "nexus-blobs"}}},
+    {"@class": "selector_selector", "name": "ignored"}
+  ]
+}`
+
+func dummySupportZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyze(t *testing.T) {
+	zipped := dummySupportZip(t, map[string]string{
+		"support-20200601-120000-1/log/nexus.log":              dummyNexusLog,
+		"support-20200601-120000-1/info/sysinfo.json":          dummySysInfo,
+		"support-20200601-120000-1/info/threads.txt":           dummyThreadDump,
+		"support-20200601-120000-1/work/db/config/export.json": dummyConfigExport,
+		"support-20200601-120000-1/log/tasks/allTasks.log":     "",
+	})
+
+	dir, err := ioutil.TempDir("", "rmsupport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "support.zip")
+	if err = ioutil.WriteFile(path, zipped, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := Analyze(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if analysis.Log.Entries != 5 || len(analysis.Threads.Threads) != 4 {
+		t.Errorf("Log or thread dump not analyzed: %v", analysis)
+	}
+
+	expectedSystem := SystemInfo{
+		NexusVersion: "3.24.0-02",
+		NexusEdition: "OSS",
+		JavaVersion:  "1.8.0_252",
+		JavaVendor:   "AdoptOpenJDK",
+		JavaVM:       "OpenJDK 64-Bit Server VM",
+		OSName:       "Linux",
+		OSVersion:    "5.4.0",
+		OSArch:       "amd64",
+		Processors:   4,
+		MaxMemory:    400,
+		TotalMemory:  200,
+		FreeMemory:   100,
+		Threads:      120,
+		FileStores:   []FileStore{{"/dev/sda1", "/ (/dev/sda1)", "ext4", 1000, 10, false}},
+		Properties:   analysis.System.Properties,
+	}
+	if !reflect.DeepEqual(analysis.System, expectedSystem) {
+		t.Errorf("Expected system %v but got %v", expectedSystem, analysis.System)
+	}
+
+	if len(analysis.Repositories) != 2 {
+		t.Fatalf("Expected 2 repositories but got %v", analysis.Repositories)
+	}
+
+	central, public := analysis.Repositories[0], analysis.Repositories[1]
+	if central.Name != "maven-central" || central.Format != "maven2" || central.Type != "proxy" ||
+		central.BlobStore != "default" || central.RemoteURL != "https://repo1.maven.org/maven2/" {
+		t.Errorf("Unexpected proxy repository: %v", central)
+	}
+
+	if public.Type != "group" || !reflect.DeepEqual(public.Members, []string{"maven-central", "maven-releases"}) {
+		t.Errorf("Unexpected group repository: %v", public)
+	}
+
+	if len(analysis.BlobStores) != 2 || analysis.BlobStores[0].Path != "default" || analysis.BlobStores[1].Bucket != "nexus-blobs" {
+		t.Errorf("Unexpected blob stores: %v", analysis.BlobStores)
+	}
+}
+
+func TestAnalyzePartialZip(t *testing.T) {
+	zipped := dummySupportZip(t, map[string]string{
+		"support/log/nexus.log": dummyNexusLog,
+	})
+
+	analysis, err := AnalyzeReader(bytes.NewReader(zipped), int64(len(zipped)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if analysis.Log.Entries != 5 {
+		t.Errorf("Log not analyzed: %v", analysis.Log)
+	}
+
+	if analysis.System.NexusVersion != "" || analysis.Threads.Threads != nil || analysis.Repositories != nil {
+		t.Errorf("Expected missing parts to be empty: %v", analysis)
+	}
+}
+
+func TestAnalyzeInvalidZip(t *testing.T) {
+	if _, err := AnalyzeReader(bytes.NewReader([]byte("nope")), 4); err == nil {
+		t.Error("Expected error analyzing invalid zip")
+	}
+}
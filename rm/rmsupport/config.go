@@ -0,0 +1,115 @@
+package rmsupport
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Classes of the configuration database records which are extracted
+const (
+	classRepository = "repository"
+	classBlobStore  = "repository_blobstore"
+)
+
+// Repository is the configuration of a repository found in a support zip
+type Repository struct {
+	Name       string
+	Recipe     string // e.g. maven2-proxy
+	Format     string
+	Type       string
+	Online     bool
+	BlobStore  string
+	RemoteURL  string   // only set for proxy repositories
+	Members    []string // only set for group repositories
+	Attributes map[string]interface{}
+}
+
+// BlobStore is the configuration of a blob store found in a support zip
+type BlobStore struct {
+	Name       string
+	Type       string
+	Path       string // only set for file blob stores
+	Bucket     string // only set for S3 blob stores
+	Attributes map[string]interface{}
+}
+
+type configRecord struct {
+	Class      string                 `json:"@class"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	RepoName   string                 `json:"repository_name"`
+	Recipe     string                 `json:"recipe_name"`
+	Online     bool                   `json:"online"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// ParseConfigExport reads the repositories and blob stores of a configuration database export
+func ParseConfigExport(r io.Reader) ([]Repository, []BlobStore, error) {
+	var export struct {
+		Records []configRecord `json:"records"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, nil, err
+	}
+
+	repos := make([]Repository, 0)
+	stores := make([]BlobStore, 0)
+	for _, rec := range export.Records {
+		switch rec.Class {
+		case classRepository:
+			repo := Repository{
+				Name:       rec.RepoName,
+				Recipe:     rec.Recipe,
+				Online:     rec.Online,
+				Attributes: rec.Attributes,
+			}
+
+			if i := strings.LastIndex(rec.Recipe, "-"); i >= 0 {
+				repo.Format, repo.Type = rec.Recipe[:i], rec.Recipe[i+1:]
+			}
+
+			repo.BlobStore, _ = attribute(rec.Attributes, "storage", "blobStoreName").(string)
+			repo.RemoteURL, _ = attribute(rec.Attributes, "proxy", "remoteUrl").(string)
+			if members, ok := attribute(rec.Attributes, "group", "memberNames").([]interface{}); ok {
+				for _, m := range members {
+					if name, ok := m.(string); ok {
+						repo.Members = append(repo.Members, name)
+					}
+				}
+			}
+
+			repos = append(repos, repo)
+		case classBlobStore:
+			store := BlobStore{
+				Name:       rec.Name,
+				Type:       rec.Type,
+				Attributes: rec.Attributes,
+			}
+
+			store.Path, _ = attribute(rec.Attributes, "file", "path").(string)
+			store.Bucket, _ = attribute(rec.Attributes, "s3", "bucket").(string)
+
+			stores = append(stores, store)
+		}
+	}
+
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	sort.Slice(stores, func(i, j int) bool { return stores[i].Name < stores[j].Name })
+
+	return repos, stores, nil
+}
+
+// attribute returns the value nested under the given keys, or nil if there is none
+func attribute(attributes map[string]interface{}, keys ...string) interface{} {
+	var value interface{} = attributes
+	for _, k := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[k]
+	}
+	return value
+}
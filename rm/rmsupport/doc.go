@@ -0,0 +1,17 @@
+/*
+Package rmsupport analyzes the support zips generated by Nexus Repository Manager without unpacking them.
+It extracts the log levels by logger from nexus.log, JVM and system information, a summary of the thread dump
+and the configuration of repositories and blob stores.
+
+	analysis, err := rmsupport.Analyze("support-20200601-120000-1.zip")
+	if err != nil {
+	    panic(err)
+	}
+
+	for logger, count := range analysis.Log.Errors {
+	    fmt.Printf("%s: %d errors\n", logger, count)
+	}
+
+Support zips can be obtained with nexusrm.SaveSupportZip.
+*/
+package rmsupport
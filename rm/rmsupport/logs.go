@@ -0,0 +1,102 @@
+package rmsupport
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const logTimeLayout = "2006-01-02 15:04:05,000-0700"
+
+// Matches the nexus.log pattern: date level [thread] node user logger - message.
+// The node is empty, leaving two spaces before the user, unless the instance is part of a cluster.
+var logEntryRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}[+-]\d{4}) +(TRACE|DEBUG|INFO|WARN|ERROR) +\[(.*?)\] +(?:(\S+) +)?(\S+) +(\S+) - `)
+
+// Log levels which are counted
+const (
+	LevelTrace = "TRACE"
+	LevelDebug = "DEBUG"
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+)
+
+// LogSummary counts the entries of nexus.log. Continuation lines, such as stack traces, are part of their entry.
+type LogSummary struct {
+	Entries  int
+	First    time.Time
+	Last     time.Time
+	Levels   map[string]int
+	Errors   map[string]int // error entries by logger
+	Warnings map[string]int // warning entries by logger
+}
+
+// LoggerCount is the number of entries a logger wrote
+type LoggerCount struct {
+	Logger string
+	Count  int
+}
+
+// ParseLog reads the contents of nexus.log and counts its entries
+func ParseLog(r io.Reader) (LogSummary, error) {
+	summary := LogSummary{
+		Levels:   make(map[string]int),
+		Errors:   make(map[string]int),
+		Warnings: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		match := logEntryRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		summary.Entries++
+
+		if t, err := time.Parse(logTimeLayout, match[1]); err == nil {
+			if summary.First.IsZero() || t.Before(summary.First) {
+				summary.First = t
+			}
+			if t.After(summary.Last) {
+				summary.Last = t
+			}
+		}
+
+		level, logger := match[2], match[6]
+		summary.Levels[level]++
+		switch level {
+		case LevelError:
+			summary.Errors[logger]++
+		case LevelWarn:
+			summary.Warnings[logger]++
+		}
+	}
+
+	return summary, scanner.Err()
+}
+
+// TopLoggers returns the loggers with the most entries in the given counts, such as LogSummary.Errors.
+// At most n loggers are returned, or all of them if n is not positive.
+func TopLoggers(counts map[string]int, n int) []LoggerCount {
+	top := make([]LoggerCount, 0, len(counts))
+	for logger, count := range counts {
+		top = append(top, LoggerCount{logger, count})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Logger < top[j].Logger
+	})
+
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
@@ -0,0 +1,54 @@
+package rmsupport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const dummyNexusLog = `2020-06-01 12:00:00,000+0000 INFO  [FelixStartLevel]  *SYSTEM org.sonatype.nexus.pax.logging.NexusLogActivator - start
+2020-06-01 12:00:01,000+0000 WARN  [qtp123-45] 5BD2F5A3-B1E0E9A4-1F2C3D4E-5A6B7C8D-9E0F1A2B admin org.sonatype.nexus.repository.httpclient.internal.HttpClientFacetImpl - Repository status for maven-central changed from AVAILABLE to AUTO_BLOCKED_UNAVAILABLE
+2020-06-01 12:00:02,000+0000 ERROR [qtp123-46]  *UNKNOWN org.sonatype.nexus.repository.storage.StorageFacetImpl - Could not store asset
+java.io.IOException: No space left on device
+	at java.io.FileOutputStream.writeBytes(Native Method)
+2020-06-01 12:00:03,000+0000 ERROR [quartz-3-thread-1] 5BD2F5A3-B1E0E9A4-1F2C3D4E-5A6B7C8D-9E0F1A2B *SYSTEM org.sonatype.nexus.repository.storage.StorageFacetImpl - Could not store asset - retrying later
+2020-06-01 12:00:04,000+0000 ERROR [Check Status [1]]  *SYSTEM org.sonatype.nexus.blobstore.file.FileBlobStore - Blob store is full
+not a log line
+`
+
+func TestParseLog(t *testing.T) {
+	summary, err := ParseLog(strings.NewReader(dummyNexusLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Entries != 5 {
+		t.Errorf("Expected 5 entries but counted %d", summary.Entries)
+	}
+
+	expectedLevels := map[string]int{LevelInfo: 1, LevelWarn: 1, LevelError: 3}
+	if !reflect.DeepEqual(summary.Levels, expectedLevels) {
+		t.Errorf("Expected levels %v but got %v", expectedLevels, summary.Levels)
+	}
+
+	if summary.Warnings["org.sonatype.nexus.repository.httpclient.internal.HttpClientFacetImpl"] != 1 {
+		t.Errorf("Unexpected warnings: %v", summary.Warnings)
+	}
+
+	expectedErrors := []LoggerCount{
+		{"org.sonatype.nexus.repository.storage.StorageFacetImpl", 2},
+		{"org.sonatype.nexus.blobstore.file.FileBlobStore", 1},
+	}
+	if top := TopLoggers(summary.Errors, 0); !reflect.DeepEqual(top, expectedErrors) {
+		t.Errorf("Expected errors %v but got %v", expectedErrors, top)
+	}
+
+	if top := TopLoggers(summary.Errors, 1); len(top) != 1 {
+		t.Errorf("Expected a single logger but got %v", top)
+	}
+
+	if !summary.First.Equal(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)) || !summary.Last.Equal(time.Date(2020, 6, 1, 12, 0, 4, 0, time.UTC)) {
+		t.Errorf("Unexpected time range %v - %v", summary.First, summary.Last)
+	}
+}
@@ -0,0 +1,88 @@
+package rmsupport
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// SystemInfo describes the RM instance, its JVM and the system it runs on
+type SystemInfo struct {
+	NexusVersion string
+	NexusEdition string
+	JavaVersion  string
+	JavaVendor   string
+	JavaVM       string
+	OSName       string
+	OSVersion    string
+	OSArch       string
+	Processors   int
+	MaxMemory    int64
+	TotalMemory  int64
+	FreeMemory   int64
+	Threads      int
+	FileStores   []FileStore
+	Properties   map[string]string // the system properties of the JVM
+}
+
+// FileStore describes a file system available to the RM instance
+type FileStore struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	TotalSpace  int64  `json:"totalSpace"`
+	UsableSpace int64  `json:"usableSpace"`
+	ReadOnly    bool   `json:"readOnly"`
+}
+
+type sysInfo struct {
+	Properties map[string]string `json:"system-properties"`
+	Runtime    struct {
+		AvailableProcessors int   `json:"availableProcessors"`
+		FreeMemory          int64 `json:"freeMemory"`
+		TotalMemory         int64 `json:"totalMemory"`
+		MaxMemory           int64 `json:"maxMemory"`
+		Threads             int   `json:"threads"`
+	} `json:"system-runtime"`
+	FileStores map[string]FileStore `json:"system-filestores"`
+	Status     struct {
+		Version string `json:"version"`
+		Edition string `json:"edition"`
+	} `json:"nexus-status"`
+}
+
+// ParseSysInfo reads the contents of sysinfo.json
+func ParseSysInfo(r io.Reader) (SystemInfo, error) {
+	var raw sysInfo
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return SystemInfo{}, err
+	}
+
+	info := SystemInfo{
+		NexusVersion: raw.Status.Version,
+		NexusEdition: raw.Status.Edition,
+		JavaVersion:  raw.Properties["java.version"],
+		JavaVendor:   raw.Properties["java.vendor"],
+		JavaVM:       raw.Properties["java.vm.name"],
+		OSName:       raw.Properties["os.name"],
+		OSVersion:    raw.Properties["os.version"],
+		OSArch:       raw.Properties["os.arch"],
+		Processors:   raw.Runtime.AvailableProcessors,
+		MaxMemory:    raw.Runtime.MaxMemory,
+		TotalMemory:  raw.Runtime.TotalMemory,
+		FreeMemory:   raw.Runtime.FreeMemory,
+		Threads:      raw.Runtime.Threads,
+		FileStores:   make([]FileStore, 0, len(raw.FileStores)),
+		Properties:   raw.Properties,
+	}
+
+	for name, store := range raw.FileStores {
+		if store.Name == "" {
+			store.Name = name
+		}
+		info.FileStores = append(info.FileStores, store)
+	}
+	sort.Slice(info.FileStores, func(i, j int) bool { return info.FileStores[i].Name < info.FileStores[j].Name })
+
+	return info, nil
+}
@@ -0,0 +1,107 @@
+package rmsupport
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	threadHeaderRegex = regexp.MustCompile(`^"(.*)"(.*)$`)
+	threadStateRegex  = regexp.MustCompile(`(?:state=|java\.lang\.Thread\.State: )([A-Z_]+)`)
+	threadPoolRegex   = regexp.MustCompile(`[-#\s]*\d+$`)
+)
+
+// Thread states as reported by the JVM
+const (
+	ThreadStateNew          = "NEW"
+	ThreadStateRunnable     = "RUNNABLE"
+	ThreadStateBlocked      = "BLOCKED"
+	ThreadStateWaiting      = "WAITING"
+	ThreadStateTimedWaiting = "TIMED_WAITING"
+	ThreadStateTerminated   = "TERMINATED"
+)
+
+// ThreadInfo describes a thread in a thread dump
+type ThreadInfo struct {
+	Name     string
+	State    string
+	TopFrame string // the method the thread was in, if its stack was dumped
+}
+
+// ThreadDumpSummary summarizes a thread dump
+type ThreadDumpSummary struct {
+	Threads []ThreadInfo
+	States  map[string]int // number of threads by state
+	Pools   map[string]int // number of threads by name, ignoring trailing numbers
+}
+
+// InState returns the threads in the given state, such as ThreadStateBlocked
+func (s ThreadDumpSummary) InState(state string) []ThreadInfo {
+	threads := make([]ThreadInfo, 0)
+	for _, t := range s.Threads {
+		if t.State == state {
+			threads = append(threads, t)
+		}
+	}
+	return threads
+}
+
+// ParseThreadDump reads a thread dump, such as threads.txt
+func ParseThreadDump(r io.Reader) (ThreadDumpSummary, error) {
+	summary := ThreadDumpSummary{
+		Threads: make([]ThreadInfo, 0),
+		States:  make(map[string]int),
+		Pools:   make(map[string]int),
+	}
+
+	var current *ThreadInfo
+	done := func() {
+		if current == nil {
+			return
+		}
+		summary.Threads = append(summary.Threads, *current)
+		summary.States[current.State]++
+		summary.Pools[threadPool(current.Name)]++
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := threadHeaderRegex.FindStringSubmatch(line); match != nil {
+			done()
+			current = &ThreadInfo{Name: match[1]}
+			if state := threadStateRegex.FindStringSubmatch(match[2]); state != nil {
+				current.State = state[1]
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case current.State == "" && threadStateRegex.MatchString(trimmed):
+			current.State = threadStateRegex.FindStringSubmatch(trimmed)[1]
+		case current.TopFrame == "" && strings.HasPrefix(trimmed, "at "):
+			current.TopFrame = strings.TrimPrefix(trimmed, "at ")
+		}
+	}
+	done()
+
+	return summary, scanner.Err()
+}
+
+// threadPool derives the name of the pool a thread belongs to, e.g. qtp123-45 belongs to qtp123
+func threadPool(name string) string {
+	if pool := threadPoolRegex.ReplaceAllString(name, ""); pool != "" {
+		return pool
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package rmsupport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dummyThreadDump = `"qtp123-45" id=45 state=RUNNABLE
+    at java.net.SocketInputStream.socketRead0(Native Method)
+    at java.net.SocketInputStream.read(SocketInputStream.java:171)
+
+"qtp123-46" id=46 state=BLOCKED
+    - waiting to lock <0x1234> (a java.lang.Object)
+    at org.sonatype.nexus.repository.storage.StorageTxImpl.commit(StorageTxImpl.java:100)
+
+"quartz-3-thread-1" #60 prio=5 os_prio=0 tid=0x00007f nid=0x1 waiting on condition [0x00007f]
+   java.lang.Thread.State: TIMED_WAITING (parking)
+	at sun.misc.Unsafe.park(Native Method)
+
+"Reference Handler" #2 daemon prio=10 os_prio=0 tid=0x00007f nid=0x2 in Object.wait() [0x00007f]
+   java.lang.Thread.State: WAITING (on object monitor)
+`
+
+func TestParseThreadDump(t *testing.T) {
+	summary, err := ParseThreadDump(strings.NewReader(dummyThreadDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(summary.Threads) != 4 {
+		t.Fatalf("Expected 4 threads but got %d", len(summary.Threads))
+	}
+
+	expectedStates := map[string]int{ThreadStateRunnable: 1, ThreadStateBlocked: 1, ThreadStateTimedWaiting: 1, ThreadStateWaiting: 1}
+	if !reflect.DeepEqual(summary.States, expectedStates) {
+		t.Errorf("Expected states %v but got %v", expectedStates, summary.States)
+	}
+
+	expectedPools := map[string]int{"qtp123": 2, "quartz-3-thread": 1, "Reference Handler": 1}
+	if !reflect.DeepEqual(summary.Pools, expectedPools) {
+		t.Errorf("Expected pools %v but got %v", expectedPools, summary.Pools)
+	}
+
+	expectedBlocked := []ThreadInfo{{"qtp123-46", ThreadStateBlocked, "org.sonatype.nexus.repository.storage.StorageTxImpl.commit(StorageTxImpl.java:100)"}}
+	if blocked := summary.InState(ThreadStateBlocked); !reflect.DeepEqual(blocked, expectedBlocked) {
+		t.Errorf("Expected blocked threads %v but got %v", expectedBlocked, blocked)
+	}
+}
@@ -5,18 +5,67 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 const restRole = "service/rest/v1/security/roles"
 
+// Role encapsulates a Repository Manager security role
 type Role struct {
 	Id          string   `json:"id"`
+	Source      string   `json:"source,omitempty"`
 	Name        string   `json:"name"`
 	Description string   `json:"description"`
 	Privileges  []string `json:"privileges"`
 	Roles       []string `json:"roles"`
 }
 
+// GetRoles returns the roles of the given source, or of all sources if none is given
+func GetRoles(rm RM, source string) ([]Role, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list roles: %v", err)
+	}
+
+	endpoint := restRole
+	if source != "" {
+		endpoint += "?source=" + url.QueryEscape(source)
+	}
+
+	body, resp, err := rm.Get(endpoint)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	roles := make([]Role, 0)
+	if err := json.Unmarshal(body, &roles); err != nil {
+		return nil, doError(err)
+	}
+
+	return roles, nil
+}
+
+// GetRoleById returns the role with the given id
+func GetRoleById(rm RM, id string) (Role, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find role '%s': %v", id, err)
+	}
+
+	var role Role
+
+	url := fmt.Sprintf("%s/%s", restRole, id)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return role, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &role); err != nil {
+		return role, doError(err)
+	}
+
+	return role, nil
+}
+
+// CreateRole creates a new role
 func CreateRole(rm RM, role Role) error {
 	json, err := json.Marshal(role)
 	if err != nil {
@@ -31,6 +80,22 @@ func CreateRole(rm RM, role Role) error {
 	return nil
 }
 
+// UpdateRole replaces the role which has the same id as the given role
+func UpdateRole(rm RM, role Role) error {
+	buf, err := json.Marshal(role)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s", restRole, role.Id)
+	if _, resp, err := rm.Put(url, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("role not updated '%s': %v", role.Id, err)
+	}
+
+	return nil
+}
+
+// DeleteRoleById removes the role with the given id
 func DeleteRoleById(rm RM, id string) error {
 	url := fmt.Sprintf("%s/%s", restRole, id)
 
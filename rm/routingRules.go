@@ -0,0 +1,239 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const restRoutingRules = "service/rest/v1/routing-rules"
+
+// Routing rule modes
+const (
+	RoutingRuleAllow = "ALLOW"
+	RoutingRuleBlock = "BLOCK"
+)
+
+// RoutingRule encapsulates a rule which allows or blocks requests to a repository based on the request path
+type RoutingRule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Mode        string   `json:"mode"`
+	Matchers    []string `json:"matchers"`
+}
+
+func (r RoutingRule) compile() ([]*regexp.Regexp, error) {
+	if r.Mode != RoutingRuleAllow && r.Mode != RoutingRuleBlock {
+		return nil, fmt.Errorf("mode must be %s or %s, not '%s'", RoutingRuleAllow, RoutingRuleBlock, r.Mode)
+	}
+
+	if len(r.Matchers) == 0 {
+		return nil, fmt.Errorf("at least one matcher is required")
+	}
+
+	matchers := make([]*regexp.Regexp, len(r.Matchers))
+	for i, m := range r.Matchers {
+		// RM requires the matcher to match the entire request path
+		re, err := regexp.Compile("^(?:" + m + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher '%s': %v", m, err)
+		}
+		matchers[i] = re
+	}
+
+	return matchers, nil
+}
+
+// Validate returns an error if the mode or any of the matchers of the rule are invalid
+func (r RoutingRule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+// Blocks returns true if a request for the given path would be blocked by the rule
+func (r RoutingRule) Blocks(path string) (bool, error) {
+	matchers, err := r.compile()
+	if err != nil {
+		return false, fmt.Errorf("invalid routing rule '%s': %v", r.Name, err)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var matched bool
+	for _, m := range matchers {
+		if m.MatchString(path) {
+			matched = true
+			break
+		}
+	}
+
+	if r.Mode == RoutingRuleAllow {
+		return !matched, nil
+	}
+	return matched, nil
+}
+
+// GetRoutingRules returns all of the routing rules configured in the RM instance
+func GetRoutingRules(rm RM) ([]RoutingRule, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list routing rules: %v", err)
+	}
+
+	body, resp, err := rm.Get(restRoutingRules)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	rules := make([]RoutingRule, 0)
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, doError(err)
+	}
+
+	return rules, nil
+}
+
+// GetRoutingRuleByName returns the named routing rule
+func GetRoutingRuleByName(rm RM, name string) (RoutingRule, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not find routing rule '%s': %v", name, err)
+	}
+
+	var rule RoutingRule
+
+	url := fmt.Sprintf("%s/%s", restRoutingRules, name)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return rule, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &rule); err != nil {
+		return rule, doError(err)
+	}
+
+	return rule, nil
+}
+
+// CreateRoutingRule creates a new routing rule after validating it
+func CreateRoutingRule(rm RM, rule RoutingRule) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not create routing rule '%s': %v", rule.Name, err)
+	}
+
+	if err := rule.Validate(); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(rule)
+	if err != nil {
+		return doError(err)
+	}
+
+	_, resp, err := rm.Post(restRoutingRules, bytes.NewBuffer(buf))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// UpdateRoutingRule replaces the named routing rule
+func UpdateRoutingRule(rm RM, name string, rule RoutingRule) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not update routing rule '%s': %v", name, err)
+	}
+
+	if err := rule.Validate(); err != nil {
+		return doError(err)
+	}
+
+	buf, err := json.Marshal(rule)
+	if err != nil {
+		return doError(err)
+	}
+
+	url := fmt.Sprintf("%s/%s", restRoutingRules, name)
+	_, resp, err := rm.Put(url, bytes.NewBuffer(buf))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DeleteRoutingRuleByName removes the named routing rule
+func DeleteRoutingRuleByName(rm RM, name string) error {
+	url := fmt.Sprintf("%s/%s", restRoutingRules, name)
+
+	if resp, err := rm.Del(url); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("routing rule not deleted '%s': %v", name, err)
+	}
+
+	return nil
+}
+
+type repositoryRoutingRule struct {
+	RoutingRule     string `json:"routingRule"`
+	RoutingRuleName string `json:"routingRuleName"`
+}
+
+// GetRepositoryRoutingRule returns the routing rule assigned to the named proxy repository.
+// The returned bool is false if the repository does not have a routing rule.
+func GetRepositoryRoutingRule(rm RM, repo string) (RoutingRule, bool, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not get routing rule of repository '%s': %v", repo, err)
+	}
+
+	repository, err := GetRepositoryByName(rm, repo)
+	if err != nil {
+		return RoutingRule{}, false, doError(err)
+	}
+
+	if repository.Type != "proxy" {
+		return RoutingRule{}, false, doError(fmt.Errorf("routing rules only apply to proxy repositories"))
+	}
+
+	endpoint := proxyRepositoryEndpoint(parseRepositoryFormat(repository.Format))
+	if endpoint == "" {
+		return RoutingRule{}, false, doError(fmt.Errorf("unsupported format '%s'", repository.Format))
+	}
+
+	body, resp, err := rm.Get(fmt.Sprintf("%s/%s", endpoint, repo))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return RoutingRule{}, false, doError(err)
+	}
+
+	var config repositoryRoutingRule
+	if err := json.Unmarshal(body, &config); err != nil {
+		return RoutingRule{}, false, doError(err)
+	}
+
+	name := config.RoutingRuleName
+	if name == "" {
+		name = config.RoutingRule
+	}
+	if name == "" {
+		return RoutingRule{}, false, nil
+	}
+
+	rule, err := GetRoutingRuleByName(rm, name)
+	if err != nil {
+		return RoutingRule{}, false, doError(err)
+	}
+
+	return rule, true, nil
+}
+
+// IsPathBlocked returns true if a request for the given path would be blocked by the routing rule of the named proxy repository
+func IsPathBlocked(rm RM, repo, path string) (bool, error) {
+	rule, ok, err := GetRepositoryRoutingRule(rm, repo)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return rule.Blocks(path)
+}
@@ -0,0 +1,229 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dummyRoutingRules = []RoutingRule{
+	{Name: "block-acme", Description: "keep acme internal", Mode: RoutingRuleBlock, Matchers: []string{"^/com/acme/.*", "^/@acme/.*"}},
+	{Name: "allow-apache", Description: "only apache", Mode: RoutingRuleAllow, Matchers: []string{"/org/apache/.*"}},
+}
+
+var dummyRepositoryRoutingRules = map[string]string{
+	"repo-npm": "block-acme",
+}
+
+func routingRulesTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	getRuleByName := func(name string) (int, RoutingRule, bool) {
+		for i, rule := range dummyRoutingRules {
+			if rule.Name == name {
+				return i, rule, true
+			}
+		}
+		return 0, RoutingRule{}, false
+	}
+
+	readRule := func() (rule RoutingRule, ok bool) {
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		return rule, json.Unmarshal(body, &rule) == nil
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restRoutingRules), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		resp, err := json.Marshal(dummyRoutingRules)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet:
+		if _, rule, ok := getRuleByName(name); ok {
+			resp, err := json.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost:
+		rule, ok := readRule()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyRoutingRules = append(dummyRoutingRules, rule)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		i, _, ok := getRuleByName(name)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		rule, ok := readRule()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyRoutingRules[i] = rule
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if i, _, ok := getRuleByName(name); ok {
+			dummyRoutingRules = append(dummyRoutingRules[:i], dummyRoutingRules[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func routingRulesTestRM(t *testing.T) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path[1:], restRoutingRules):
+			routingRulesTestFunc(t, w, r)
+		case strings.HasPrefix(r.URL.Path[1:], restRepositoriesProxyNpm+"/"):
+			repo := strings.TrimPrefix(r.URL.Path[1:], restRepositoriesProxyNpm+"/")
+			resp, err := json.Marshal(repositoryRoutingRule{RoutingRuleName: dummyRepositoryRoutingRules[repo]})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		default:
+			repositoriesTestFunc(t, w, r)
+		}
+	})
+}
+
+func TestGetRoutingRules(t *testing.T) {
+	rm, mock := routingRulesTestRM(t)
+	defer mock.Close()
+
+	rules, err := GetRoutingRules(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rules, dummyRoutingRules) {
+		t.Errorf("Did not receive expected routing rules: %v", rules)
+	}
+}
+
+func TestCreateUpdateDeleteRoutingRule(t *testing.T) {
+	rm, mock := routingRulesTestRM(t)
+	defer mock.Close()
+
+	rule := RoutingRule{Name: "block-internal", Mode: RoutingRuleBlock, Matchers: []string{"/internal/.*"}}
+	if err := CreateRoutingRule(rm, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	rule.Matchers = append(rule.Matchers, "/private/.*")
+	if err := UpdateRoutingRule(rm, rule.Name, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetRoutingRuleByName(rm, rule.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rule) {
+		t.Errorf("Did not receive updated routing rule: %v", got)
+	}
+
+	if err := DeleteRoutingRuleByName(rm, rule.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetRoutingRuleByName(rm, rule.Name); err == nil {
+		t.Error("Routing rule not deleted")
+	}
+}
+
+func TestCreateRoutingRuleInvalid(t *testing.T) {
+	rm, mock := routingRulesTestRM(t)
+	defer mock.Close()
+
+	invalid := []RoutingRule{
+		{Name: "bad-mode", Mode: "DENY", Matchers: []string{".*"}},
+		{Name: "no-matchers", Mode: RoutingRuleBlock},
+		{Name: "bad-regex", Mode: RoutingRuleBlock, Matchers: []string{"/org/(acme"}},
+	}
+
+	for _, rule := range invalid {
+		if err := CreateRoutingRule(rm, rule); err == nil {
+			t.Errorf("Expected rule %s to be rejected", rule.Name)
+		}
+	}
+}
+
+func TestRoutingRuleBlocks(t *testing.T) {
+	tests := []struct {
+		rule    RoutingRule
+		path    string
+		blocked bool
+	}{
+		{dummyRoutingRules[0], "/com/acme/widget/1.0/widget-1.0.jar", true},
+		{dummyRoutingRules[0], "com/acme/widget/1.0/widget-1.0.jar", true},
+		{dummyRoutingRules[0], "/@acme/widget", true},
+		{dummyRoutingRules[0], "/org/apache/commons/1.0/commons-1.0.jar", false},
+		{dummyRoutingRules[1], "/org/apache/commons/1.0/commons-1.0.jar", false},
+		{dummyRoutingRules[1], "/com/acme/widget/1.0/widget-1.0.jar", true},
+		{RoutingRule{Mode: RoutingRuleBlock, Matchers: []string{"/org"}}, "/org/apache", false},
+	}
+
+	for _, test := range tests {
+		blocked, err := test.rule.Blocks(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blocked != test.blocked {
+			t.Errorf("Expected %s blocked=%v with rule %v", test.path, test.blocked, test.rule)
+		}
+	}
+}
+
+func TestIsPathBlocked(t *testing.T) {
+	rm, mock := routingRulesTestRM(t)
+	defer mock.Close()
+
+	blocked, err := IsPathBlocked(rm, "repo-npm", "/@acme/widget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blocked {
+		t.Error("Expected path to be blocked")
+	}
+
+	blocked, err = IsPathBlocked(rm, "repo-npm", "/lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked {
+		t.Error("Expected path to not be blocked")
+	}
+
+	if _, err = IsPathBlocked(rm, "repo-maven", "/org/acme"); err == nil {
+		t.Error("Expected error when querying a hosted repository")
+	}
+}
@@ -83,7 +83,7 @@ func TestSearchComponents(t *testing.T) {
 		t.Fatalf("Did not complete search: %v", err)
 	}
 
-	t.Logf("%q\n", components)
+	t.Logf("%v\n", components)
 
 	if len(components) != len(dummyComponents[repo]) {
 		t.Errorf("Received %d components instead of %d\n", len(components), len(dummyComponents[repo]))
@@ -108,7 +108,7 @@ func TestSearchAssets(t *testing.T) {
 		t.Error(err)
 	}
 
-	t.Logf("%q\n", assets)
+	t.Logf("%v\n", assets)
 
 	if len(assets) != len(dummyAssets[repo]) {
 		t.Errorf("Received %d assets instead of %d\n", len(assets), len(dummyAssets[repo]))
@@ -121,6 +121,24 @@ func TestSearchAssets(t *testing.T) {
 	}
 }
 
+func TestSearchComponentsEscapesQuery(t *testing.T) {
+	var version, name string
+	rm, mock := newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		version, name = r.URL.Query().Get("version"), r.URL.Query().Get("name")
+		fmt.Fprintln(w, `{"items":[]}`)
+	})
+	defer mock.Close()
+
+	query := NewSearchQueryBuilder().Repository("repo-maven").Name("app&more").Version("1.0+build.1")
+	if _, err := SearchComponents(rm, query); err != nil {
+		t.Fatal(err)
+	}
+
+	if version != "1.0+build.1" || name != "app&more" {
+		t.Errorf("Criteria not escaped, got version %q and name %q", version, name)
+	}
+}
+
 func ExampleSearchComponents() {
 	rm, err := New("http://localhost:8081", "username", "password")
 	if err != nil {
@@ -1,12 +1,17 @@
 package nexusrm
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 )
 
 const (
 	restStatusReadable = "service/rest/v1/status"
 	restStatusWritable = "service/rest/v1/status/writable"
+	restStatusCheck    = "service/rest/v1/status/check"
 )
 
 // StatusReadable returns true if the RM instance can serve read requests
@@ -20,3 +25,55 @@ func StatusWritable(rm RM) (_ bool) {
 	_, resp, err := rm.Get(restStatusWritable)
 	return err == nil && resp.StatusCode == http.StatusOK
 }
+
+// HealthCheck is the result of one of the named health checks performed by RM
+type HealthCheck struct {
+	Name    string
+	Healthy bool
+	Message string
+	Time    time.Time
+}
+
+type statusCheckResult struct {
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message"`
+	Time    int64  `json:"time"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// GetStatusCheck returns the results of every health check RM performs, such as blob stores, file descriptors and thread pools
+func GetStatusCheck(rm RM) ([]HealthCheck, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not get status checks: %v", err)
+	}
+
+	body, resp, err := rm.Get(restStatusCheck)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	var results map[string]statusCheckResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, doError(err)
+	}
+
+	checks := make([]HealthCheck, 0, len(results))
+	for name, r := range results {
+		check := HealthCheck{
+			Name:    name,
+			Healthy: r.Healthy,
+			Message: r.Message,
+			Time:    time.Unix(0, r.Time*int64(time.Millisecond)),
+		}
+		if check.Message == "" && r.Error != nil {
+			check.Message = r.Error.Message
+		}
+		checks = append(checks, check)
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	return checks, nil
+}
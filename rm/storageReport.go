@@ -0,0 +1,320 @@
+package nexusrm
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StorageReportOptions selects what a storage report covers
+type StorageReportOptions struct {
+	// Repositories selects the hosted and proxy repositories to walk. A nil filter selects every repository.
+	Repositories RepositoryFilter
+	// StaleCutoff reports the assets which were not downloaded since then. The zero time disables the check.
+	StaleCutoff time.Time
+	// LargestComponents is the number of largest components to report. Zero disables the check.
+	LargestComponents int
+}
+
+// StorageUsage is the number and total size of a set of assets
+type StorageUsage struct {
+	Assets int   `json:"assets"`
+	Bytes  int64 `json:"bytes"`
+}
+
+func (u *StorageUsage) add(size int64) {
+	u.Assets++
+	u.Bytes += size
+}
+
+// ComponentUsage is the storage used by the assets of a component
+type ComponentUsage struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository"`
+	Format     string `json:"format"`
+	Group      string `json:"group,omitempty"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	StorageUsage
+}
+
+// StorageReport describes how storage is used across repositories.
+// Group repositories do not store content themselves and are attributed the usage of their members.
+type StorageReport struct {
+	Generated         time.Time               `json:"generated"`
+	Total             StorageUsage            `json:"total"`
+	ByRepository      map[string]StorageUsage `json:"byRepository"`
+	ByFormat          map[string]StorageUsage `json:"byFormat"`
+	ByGroup           map[string]StorageUsage `json:"byGroup"`
+	ByBlobStore       map[string]StorageUsage `json:"byBlobStore"`
+	LargestComponents []ComponentUsage        `json:"largestComponents,omitempty"`
+	StaleCutoff       time.Time               `json:"staleCutoff"`
+	Stale             StorageUsage            `json:"stale"`
+	StaleAssets       []RepositoryItemAsset   `json:"staleAssets,omitempty"`
+	// UnknownAge is the usage of the assets which RM has no download, creation or modification time for.
+	// They cannot be compared to the cutoff, so they are not reported as stale.
+	UnknownAge StorageUsage `json:"unknownAge"`
+	// UnknownSize is the number of assets which RM has no size for. They are counted in the number of assets
+	// of each usage but not in its bytes, which are therefore a lower bound when this is not zero.
+	UnknownSize int `json:"unknownSize"`
+}
+
+// lastUsed returns when the asset was last downloaded or, if it never was, when it was stored.
+// Returns the zero time if RM has none of these times for the asset.
+func (a RepositoryItemAsset) lastUsed() time.Time {
+	switch {
+	case !a.LastDownloaded.IsZero():
+		return a.LastDownloaded
+	case !a.BlobCreated.IsZero():
+		return a.BlobCreated
+	default:
+		return a.LastModified
+	}
+}
+
+// GenerateStorageReport walks the assets of the selected repositories and aggregates their size
+// by repository, format, group repository and blob store. Depending on the options, it also finds
+// the largest components and the assets which were not downloaded since a cutoff. Assets without
+// any time or size are counted separately in UnknownAge and UnknownSize.
+func GenerateStorageReport(rm RM, options StorageReportOptions) (StorageReport, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not generate storage report: %v", err)
+	}
+
+	report := StorageReport{
+		Generated:    time.Now(),
+		ByRepository: make(map[string]StorageUsage),
+		ByFormat:     make(map[string]StorageUsage),
+		ByGroup:      make(map[string]StorageUsage),
+		ByBlobStore:  make(map[string]StorageUsage),
+		StaleCutoff:  options.StaleCutoff,
+		StaleAssets:  make([]RepositoryItemAsset, 0),
+	}
+
+	repos, err := GetRepositories(rm)
+	if err != nil {
+		return report, doError(err)
+	}
+
+	// older RM versions do not provide settings, in which case blob stores and groups are not reported
+	blobStores := make(map[string]string)
+	groups := make(map[string][]string)
+	if settings, err := GetRepositorySettings(rm); err == nil {
+		for _, s := range settings {
+			blobStores[s.Name] = s.Storage.BlobStoreName
+			if s.Type == "group" {
+				groups[s.Name] = s.Group.MemberNames
+			}
+		}
+	}
+
+	for _, repo := range repos {
+		if repo.Type == "group" || (options.Repositories != nil && !options.Repositories(repo)) {
+			continue
+		}
+
+		assets, err := GetAssets(rm, repo.Name)
+		if err != nil {
+			return report, doError(err)
+		}
+
+		usage := StorageUsage{}
+		for _, a := range assets {
+			usage.add(a.FileSize)
+			if a.FileSize == 0 {
+				report.UnknownSize++
+			}
+
+			if options.StaleCutoff.IsZero() {
+				continue
+			}
+
+			switch lastUsed := a.lastUsed(); {
+			case lastUsed.IsZero():
+				report.UnknownAge.add(a.FileSize)
+			case lastUsed.Before(options.StaleCutoff):
+				report.Stale.add(a.FileSize)
+				report.StaleAssets = append(report.StaleAssets, a)
+			}
+		}
+
+		report.ByRepository[repo.Name] = usage
+		report.Total.Assets += usage.Assets
+		report.Total.Bytes += usage.Bytes
+
+		byFormat := report.ByFormat[repo.Format]
+		byFormat.Assets += usage.Assets
+		byFormat.Bytes += usage.Bytes
+		report.ByFormat[repo.Format] = byFormat
+
+		if store, ok := blobStores[repo.Name]; ok {
+			byStore := report.ByBlobStore[store]
+			byStore.Assets += usage.Assets
+			byStore.Bytes += usage.Bytes
+			report.ByBlobStore[store] = byStore
+		}
+
+		if options.LargestComponents > 0 {
+			components, err := GetComponents(rm, repo.Name)
+			if err != nil {
+				return report, doError(err)
+			}
+
+			for _, c := range components {
+				cu := ComponentUsage{ID: c.ID, Repository: c.Repository, Format: c.Format, Group: c.Group, Name: c.Name, Version: c.Version}
+				for _, a := range c.Assets {
+					cu.add(a.FileSize)
+				}
+				report.LargestComponents = append(report.LargestComponents, cu)
+			}
+
+			sort.SliceStable(report.LargestComponents, func(i, j int) bool {
+				return report.LargestComponents[i].Bytes > report.LargestComponents[j].Bytes
+			})
+			if len(report.LargestComponents) > options.LargestComponents {
+				report.LargestComponents = report.LargestComponents[:options.LargestComponents]
+			}
+		}
+	}
+
+	for group := range groups {
+		usage := StorageUsage{}
+		for _, member := range groupMembers(groups, group) {
+			if u, ok := report.ByRepository[member]; ok {
+				usage.Assets += u.Assets
+				usage.Bytes += u.Bytes
+			}
+		}
+		report.ByGroup[group] = usage
+	}
+
+	sort.Slice(report.StaleAssets, func(i, j int) bool {
+		a, b := report.StaleAssets[i], report.StaleAssets[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Path < b.Path
+	})
+
+	return report, nil
+}
+
+// groupMembers returns the repositories which are members of the group, including through nested groups
+func groupMembers(groups map[string][]string, group string) []string {
+	members := make([]string, 0)
+	seen := map[string]bool{group: true}
+
+	queue := append([]string{}, groups[group]...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if nested, ok := groups[name]; ok {
+			queue = append(queue, nested...)
+			continue
+		}
+		members = append(members, name)
+	}
+
+	sort.Strings(members)
+	return members
+}
+
+// WriteJSON writes the report as JSON
+func (r StorageReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteUsageCSV writes the aggregated usage as CSV with the columns category, name, assets and bytes
+func (r StorageReport) WriteUsageCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"category", "name", "assets", "bytes"}); err != nil {
+		return err
+	}
+
+	write := func(category string, usage map[string]StorageUsage) error {
+		names := make([]string, 0, len(usage))
+		for name := range usage {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			u := usage[name]
+			if err := cw.Write([]string{category, name, strconv.Itoa(u.Assets), strconv.FormatInt(u.Bytes, 10)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, c := range []struct {
+		category string
+		usage    map[string]StorageUsage
+	}{
+		{"total", map[string]StorageUsage{"": r.Total}},
+		{"repository", r.ByRepository},
+		{"format", r.ByFormat},
+		{"group", r.ByGroup},
+		{"blobstore", r.ByBlobStore},
+	} {
+		if err := write(c.category, c.usage); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteLargestComponentsCSV writes the largest components as CSV
+func (r StorageReport) WriteLargestComponentsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repository", "format", "group", "name", "version", "assets", "bytes"}); err != nil {
+		return err
+	}
+
+	for _, c := range r.LargestComponents {
+		if err := cw.Write([]string{c.Repository, c.Format, c.Group, c.Name, c.Version, strconv.Itoa(c.Assets), strconv.FormatInt(c.Bytes, 10)}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteStaleAssetsCSV writes the assets which were not downloaded since the cutoff as CSV
+func (r StorageReport) WriteStaleAssetsCSV(w io.Writer) error {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repository", "path", "bytes", "lastDownloaded", "blobCreated"}); err != nil {
+		return err
+	}
+
+	for _, a := range r.StaleAssets {
+		if err := cw.Write([]string{a.Repository, a.Path, strconv.FormatInt(a.FileSize, 10), formatTime(a.LastDownloaded), formatTime(a.BlobCreated)}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,262 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type inventory struct {
+	repos      []RepositorySettings
+	components []RepositoryItem
+	assets     []RepositoryItemAsset // assets which do not belong to a component
+}
+
+func (inv inventory) repoAssets(repo string) []RepositoryItemAsset {
+	assets := make([]RepositoryItemAsset, 0)
+	for _, c := range inv.components {
+		if c.Repository == repo {
+			assets = append(assets, c.Assets...)
+		}
+	}
+	for _, a := range inv.assets {
+		if a.Repository == repo {
+			assets = append(assets, a)
+		}
+	}
+	return assets
+}
+
+func inventoryAsset(repo, format, path string, size int64, lastDownloaded time.Time) RepositoryItemAsset {
+	return RepositoryItemAsset{
+		ID:             repo + "/" + path,
+		DownloadURL:    fmt.Sprintf("http://localhost:8081/repository/%s/%s", repo, path),
+		Path:           path,
+		Repository:     repo,
+		Format:         format,
+		FileSize:       size,
+		LastDownloaded: lastDownloaded,
+		BlobCreated:    time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func inventoryRepository(name, format, typ, blobStore string, members ...string) RepositorySettings {
+	s := RepositorySettings{Name: name, Format: format, Type: typ, Online: true}
+	s.Storage.BlobStoreName = blobStore
+	s.Group.MemberNames = members
+	return s
+}
+
+var (
+	inventoryRecent = time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	inventoryOld    = time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func dummyInventory() inventory {
+	return inventory{
+		repos: []RepositorySettings{
+			inventoryRepository("maven-releases", "maven2", "hosted", "default"),
+			inventoryRepository("maven-central", "maven2", "proxy", "proxies"),
+			inventoryRepository("npm-proxy", "npm", "proxy", "proxies"),
+			inventoryRepository("maven-public", "maven2", "group", "default", "maven-releases", "maven-central"),
+			inventoryRepository("all", "maven2", "group", "default", "maven-public", "npm-proxy"),
+		},
+		components: []RepositoryItem{
+			{ID: "c1", Repository: "maven-releases", Format: "maven2", Group: "org.test", Name: "app", Version: "1.0", Assets: []RepositoryItemAsset{
+				inventoryAsset("maven-releases", "maven2", "org/test/app/1.0/app-1.0.jar", 1000, inventoryRecent),
+				inventoryAsset("maven-releases", "maven2", "org/test/app/1.0/app-1.0.pom", 10, inventoryOld),
+			}},
+			{ID: "c2", Repository: "maven-central", Format: "maven2", Group: "org.lib", Name: "lib", Version: "2.0", Assets: []RepositoryItemAsset{
+				inventoryAsset("maven-central", "maven2", "org/lib/lib/2.0/lib-2.0.jar", 5000, time.Time{}),
+			}},
+			{ID: "c3", Repository: "npm-proxy", Format: "npm", Name: "left-pad", Version: "1.3.0", Assets: []RepositoryItemAsset{
+				inventoryAsset("npm-proxy", "npm", "left-pad/-/left-pad-1.3.0.tgz", 300, inventoryRecent),
+			}},
+		},
+		assets: []RepositoryItemAsset{
+			inventoryAsset("maven-releases", "maven2", "org/test/app/maven-metadata.xml", 5, inventoryRecent),
+		},
+	}
+}
+
+func inventoryTestFunc(inv inventory) func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	return func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var resp interface{}
+		switch r.URL.Path[1:] {
+		case restRepositories:
+			repos := make([]Repository, 0)
+			for _, s := range inv.repos {
+				repos = append(repos, Repository{Name: s.Name, Format: s.Format, Type: s.Type})
+			}
+			resp = repos
+		case restRepositorySettings:
+			resp = inv.repos
+		case restAssets:
+			resp = listAssetsResponse{Items: inv.repoAssets(r.URL.Query().Get("repository"))}
+		case restComponents:
+			components := make([]RepositoryItem, 0)
+			for _, c := range inv.components {
+				if c.Repository == r.URL.Query().Get("repository") {
+					components = append(components, c)
+				}
+			}
+			resp = listComponentsResponse{Items: components}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateStorageReport(t *testing.T) {
+	rm, mock := newTestRM(t, inventoryTestFunc(dummyInventory()))
+	defer mock.Close()
+
+	report, err := GenerateStorageReport(rm, StorageReportOptions{
+		StaleCutoff:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		LargestComponents: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != (StorageUsage{5, 6315}) {
+		t.Errorf("Unexpected total: %v", report.Total)
+	}
+
+	expectedRepos := map[string]StorageUsage{"maven-releases": {3, 1015}, "maven-central": {1, 5000}, "npm-proxy": {1, 300}}
+	if !reflect.DeepEqual(report.ByRepository, expectedRepos) {
+		t.Errorf("Expected %v but got %v", expectedRepos, report.ByRepository)
+	}
+
+	expectedFormats := map[string]StorageUsage{"maven2": {4, 6015}, "npm": {1, 300}}
+	if !reflect.DeepEqual(report.ByFormat, expectedFormats) {
+		t.Errorf("Expected %v but got %v", expectedFormats, report.ByFormat)
+	}
+
+	expectedGroups := map[string]StorageUsage{"maven-public": {4, 6015}, "all": {5, 6315}}
+	if !reflect.DeepEqual(report.ByGroup, expectedGroups) {
+		t.Errorf("Expected %v but got %v", expectedGroups, report.ByGroup)
+	}
+
+	expectedBlobStores := map[string]StorageUsage{"default": {3, 1015}, "proxies": {2, 5300}}
+	if !reflect.DeepEqual(report.ByBlobStore, expectedBlobStores) {
+		t.Errorf("Expected %v but got %v", expectedBlobStores, report.ByBlobStore)
+	}
+
+	if len(report.LargestComponents) != 2 || report.LargestComponents[0].ID != "c2" || report.LargestComponents[1].ID != "c1" {
+		t.Errorf("Unexpected largest components: %v", report.LargestComponents)
+	}
+
+	if report.Stale != (StorageUsage{2, 5010}) || len(report.StaleAssets) != 2 ||
+		report.StaleAssets[0].Path != "org/lib/lib/2.0/lib-2.0.jar" || report.StaleAssets[1].Path != "org/test/app/1.0/app-1.0.pom" {
+		t.Errorf("Unexpected stale assets: %v", report.StaleAssets)
+	}
+}
+
+func TestGenerateStorageReportFiltered(t *testing.T) {
+	rm, mock := newTestRM(t, inventoryTestFunc(dummyInventory()))
+	defer mock.Close()
+
+	report, err := GenerateStorageReport(rm, StorageReportOptions{Repositories: RepositoriesByFormat("npm")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.ByRepository) != 1 || report.Total != (StorageUsage{1, 300}) {
+		t.Errorf("Unexpected report: %v", report)
+	}
+
+	if len(report.StaleAssets) != 0 || len(report.LargestComponents) != 0 {
+		t.Errorf("Expected disabled checks to be empty: %v", report)
+	}
+}
+
+func TestGenerateStorageReportUnknownAgeAndSize(t *testing.T) {
+	inv := dummyInventory()
+
+	undated := inventoryAsset("npm-proxy", "npm", "left-pad/-/left-pad-1.2.0.tgz", 200, time.Time{})
+	undated.BlobCreated = time.Time{}
+	inv.assets = append(inv.assets, undated, inventoryAsset("npm-proxy", "npm", "left-pad", 0, inventoryRecent))
+
+	rm, mock := newTestRM(t, inventoryTestFunc(inv))
+	defer mock.Close()
+
+	report, err := GenerateStorageReport(rm, StorageReportOptions{
+		Repositories: RepositoriesByFormat("npm"),
+		StaleCutoff:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != (StorageUsage{3, 500}) || report.UnknownSize != 1 {
+		t.Errorf("Unexpected total %v with %d assets of unknown size", report.Total, report.UnknownSize)
+	}
+
+	if report.UnknownAge != (StorageUsage{1, 200}) || len(report.StaleAssets) != 0 {
+		t.Errorf("Expected the undated asset to be of unknown age rather than stale: %v, %v", report.UnknownAge, report.StaleAssets)
+	}
+}
+
+func TestStorageReportExport(t *testing.T) {
+	rm, mock := newTestRM(t, inventoryTestFunc(dummyInventory()))
+	defer mock.Close()
+
+	report, err := GenerateStorageReport(rm, StorageReportOptions{StaleCutoff: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), LargestComponents: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded StorageReport
+	if err = json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded.ByGroup, report.ByGroup) || len(decoded.StaleAssets) != len(report.StaleAssets) {
+		t.Errorf("JSON export does not match report: %s", buf.String())
+	}
+
+	for _, c := range []struct {
+		write func(*bytes.Buffer) error
+		rows  int
+		first []string
+	}{
+		{func(b *bytes.Buffer) error { return report.WriteUsageCSV(b) }, 1 + 1 + 3 + 2 + 2 + 2, []string{"total", "", "5", "6315"}},
+		{func(b *bytes.Buffer) error { return report.WriteLargestComponentsCSV(b) }, 1 + 3, []string{"maven-central", "maven2", "org.lib", "lib", "2.0", "1", "5000"}},
+		{func(b *bytes.Buffer) error { return report.WriteStaleAssetsCSV(b) }, 1 + 2, []string{"maven-central", "org/lib/lib/2.0/lib-2.0.jar", "5000", "", "2019-01-01T00:00:00Z"}},
+	} {
+		buf.Reset()
+		if err := c.write(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rows) != c.rows || !reflect.DeepEqual(rows[1], c.first) {
+			t.Errorf("Unexpected CSV: %v", rows)
+		}
+	}
+}
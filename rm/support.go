@@ -3,9 +3,17 @@ package nexusrm
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	nexus "github.com/overag3/gonexus"
 )
 
 const restSupportZip = "service/rest/v1/support/supportzip"
@@ -41,25 +49,86 @@ func NewSupportZipOptions() (o SupportZipOptions) {
 	return
 }
 
-// GetSupportZip generates a support zip with the given options
-func GetSupportZip(rm RM, options SupportZipOptions) ([]byte, string, error) {
+// DownloadSupportZip generates a support zip with the given options and streams it to the given writer.
+// Returns the name RM gave the zip, which is empty if RM did not provide one.
+func DownloadSupportZip(rm RM, options SupportZipOptions, w io.Writer) (string, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("error retrieving support zip: %v", err)
+	}
+
 	request, err := json.Marshal(options)
 	if err != nil {
-		return nil, "", fmt.Errorf("error retrieving support zip: %v", err)
+		return "", doError(err)
+	}
+
+	req, err := rm.NewRequest(http.MethodPost, restSupportZip, bytes.NewBuffer(request))
+	if err != nil {
+		return "", doError(err)
 	}
 
-	body, resp, err := rm.Post(restSupportZip, bytes.NewBuffer(request))
+	resp, err := nexus.Stream(rm, req)
 	if err != nil {
-		return nil, "", fmt.Errorf("error retrieving support zip: %v", err)
+		return "", doError(err)
 	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("error retrieving support zip: %s", resp.Status)
+		return "", doError(errors.New(resp.Status))
+	}
+
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		return "", doError(err)
+	}
+
+	var name string
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != "" {
+		_, params, err := mime.ParseMediaType(disposition)
+		if err != nil {
+			return "", fmt.Errorf("error determining name of support zip: %v", err)
+		}
+		name = filepath.Base(params["filename"])
+	}
+
+	return name, nil
+}
+
+// SaveSupportZip generates a support zip with the given options and streams it into the given directory.
+// The zip is named as RM named it, falling back to a timestamped name. Returns the path of the saved zip.
+func SaveSupportZip(rm RM, options SupportZipOptions, dir string) (string, error) {
+	tmp, err := ioutil.TempFile(dir, "support-*.zip.part")
+	if err != nil {
+		return "", fmt.Errorf("error creating support zip file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	name, err := DownloadSupportZip(rm, options, tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing support zip: %v", closeErr)
+	}
+	if err != nil {
+		return "", err
 	}
 
-	_, params, err := mime.ParseMediaType(resp.Header["Content-Disposition"][0])
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = fmt.Sprintf("support-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	path := filepath.Join(dir, name)
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("error saving support zip: %v", err)
+	}
+
+	return path, nil
+}
+
+// GetSupportZip generates a support zip with the given options and returns it in memory.
+// Prefer SaveSupportZip or DownloadSupportZip for large zips.
+func GetSupportZip(rm RM, options SupportZipOptions) ([]byte, string, error) {
+	var buf bytes.Buffer
+	name, err := DownloadSupportZip(rm, options, &buf)
 	if err != nil {
-		return nil, "", fmt.Errorf("error determining name of support zip: %v", err)
+		return nil, "", err
 	}
 
-	return body, params["filename"], nil
+	return buf.Bytes(), name, nil
 }
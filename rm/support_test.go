@@ -0,0 +1,108 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var dummySupportZip = []byte("PK\x03\x04 not really a zip")
+
+func supportTestRM(t *testing.T, disposition string) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path[1:] != restSupportZip {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var options SupportZipOptions
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if disposition != "" {
+			w.Header().Set("Content-Disposition", disposition)
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write(dummySupportZip)
+	})
+}
+
+func TestGetSupportZip(t *testing.T) {
+	rm, mock := supportTestRM(t, `attachment; filename="support-20200601-120000-1.zip"`)
+	defer mock.Close()
+
+	zip, name, err := GetSupportZip(rm, NewSupportZipOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(zip, dummySupportZip) {
+		t.Error("Did not receive expected zip")
+	}
+
+	if name != "support-20200601-120000-1.zip" {
+		t.Errorf("Unexpected name: %s", name)
+	}
+}
+
+func TestGetSupportZipWithoutDisposition(t *testing.T) {
+	rm, mock := supportTestRM(t, "")
+	defer mock.Close()
+
+	zip, name, err := GetSupportZip(rm, NewSupportZipOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(zip, dummySupportZip) || name != "" {
+		t.Errorf("Unexpected zip '%s' named '%s'", zip, name)
+	}
+}
+
+func TestSaveSupportZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "supportzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, disposition := range []string{`attachment; filename="../support.zip"`, ""} {
+		rm, mock := supportTestRM(t, disposition)
+
+		path, err := SaveSupportZip(rm, NewSupportZipOptions(), dir)
+		mock.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if filepath.Dir(path) != dir || !strings.HasSuffix(path, ".zip") {
+			t.Errorf("Zip saved to unexpected path: %s", path)
+		}
+
+		saved, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(saved, dummySupportZip) {
+			t.Error("Saved zip does not match")
+		}
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("Expected two zips but found %d files", len(files))
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 )
 
-const restTagging = "service/rest/v1/tags"
+const (
+	restTagging          = "service/rest/v1/tags"
+	restTaggingAssociate = "service/rest/v1/tags/associate/%s?%s"
+)
 
 // Tag contains the information about a component tag
 type Tag struct {
@@ -116,19 +121,24 @@ func GetTag(rm RM, tagName string) (Tag, error) {
 	return tag, nil
 }
 
-// AssociateTag associates a tag to any component which matches the search criteria
-func AssociateTag(rm RM, query QueryBuilder) error {
-	endpoint := fmt.Sprintf("%s?%s", restTagging, query.Build())
+// AssociateTag associates the named tag to any component which matches the search criteria
+func AssociateTag(rm RM, tagName string, query QueryBuilder) error {
+	endpoint := fmt.Sprintf(restTaggingAssociate, url.PathEscape(tagName), query.Build())
+
+	if _, _, err := rm.Post(endpoint, nil); err != nil {
+		return fmt.Errorf("could not associate tag %s: %v", tagName, err)
+	}
 
-	// TODO: handle response
-	_, _, err := rm.Post(endpoint, nil)
-	return err
+	return nil
 }
 
-// DisassociateTag associates a tag to any component which matches the search criteria
-func DisassociateTag(rm RM, query QueryBuilder) error {
-	endpoint := fmt.Sprintf("%s?%s", restTagging, query.Build())
+// DisassociateTag disassociates the named tag from any component which matches the search criteria
+func DisassociateTag(rm RM, tagName string, query QueryBuilder) error {
+	endpoint := fmt.Sprintf(restTaggingAssociate, url.PathEscape(tagName), query.Build())
+
+	if resp, err := rm.Del(endpoint); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not disassociate tag %s: %v", tagName, err)
+	}
 
-	_, err := rm.Del(endpoint)
-	return err
+	return nil
 }
@@ -159,3 +159,28 @@ func TestAddTag(t *testing.T) {
 		t.Fatal("Did not receive expected tag")
 	}
 }
+
+func TestAssociateTag(t *testing.T) {
+	f := newFakeRepositoryManager(t, inventoryRepository("releases", "maven2", "hosted", "default"))
+	defer f.Close()
+
+	f.addAsset("releases", "org.test", "app", "1.0+build.1", "org/test/app/1.0+build.1/app-1.0+build.1.jar", []byte("app jar"))
+	f.addAsset("releases", "org.test", "app", "2.0", "org/test/app/2.0/app-2.0.jar", []byte("app jar"))
+	f.tags["build-1"] = true
+
+	query := NewQueryBuilder().Repository("releases").Group("org.test").Name("app").Version("1.0+build.1")
+	if err := AssociateTag(f.rm, "build-1", *query); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range f.components {
+		tagged := reflect.DeepEqual(c.Tags, []string{"build-1"})
+		if tagged != (c.Version == "1.0+build.1") {
+			t.Errorf("Unexpected tags of %s: %v", c.Version, c.Tags)
+		}
+	}
+
+	if err := AssociateTag(f.rm, "unknown", *query); err == nil {
+		t.Error("Expected an unknown tag to be rejected")
+	}
+}
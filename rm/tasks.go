@@ -0,0 +1,256 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	restTasks     = "service/rest/v1/tasks"
+	restTasksRun  = "service/rest/v1/tasks/%s/run"
+	restTasksStop = "service/rest/v1/tasks/%s/stop"
+)
+
+// Some of the task types which can be filtered by
+const (
+	TaskTypeRebuildIndex       = "repository.rebuild-index"
+	TaskTypeCompactBlobStore   = "blobstore.compact"
+	TaskTypeCleanup            = "repository.cleanup"
+	TaskTypePurgeUnused        = "repository.purge-unused"
+	TaskTypeDockerGC           = "repository.docker.gc"
+	TaskTypeRebuildMetadata    = "repository.maven.rebuild-metadata"
+	TaskTypeRebuildYumMetadata = "repository.yum.rebuild.metadata"
+)
+
+// Enumerates the states a task can be in
+const (
+	TaskStateWaiting = "WAITING"
+	TaskStateRunning = "RUNNING"
+	TaskStateDone    = "DONE"
+)
+
+// Enumerates the results of a task run
+const (
+	TaskResultOK          = "OK"
+	TaskResultFailed      = "FAILED"
+	TaskResultCanceled    = "CANCELED"
+	TaskResultInterrupted = "INTERRUPTED"
+)
+
+// Task describes a scheduled task in the RM instance
+type Task struct {
+	ID            string
+	Name          string
+	Type          string
+	Message       string
+	CurrentState  string
+	LastRunResult string
+	NextRun       time.Time
+	LastRun       time.Time
+}
+
+type taskResponse struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Message       string `json:"message"`
+	CurrentState  string `json:"currentState"`
+	LastRunResult string `json:"lastRunResult"`
+	NextRun       string `json:"nextRun"`
+	LastRun       string `json:"lastRun"`
+}
+
+type listTasksResponse struct {
+	Items             []Task `json:"items"`
+	ContinuationToken string `json:"continuationToken"`
+}
+
+var rmTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05-0700",
+}
+
+// parseRMTime parses the timestamps returned by RM, returning the zero time for empty or zero values
+func parseRMTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	var err error
+	for _, layout := range rmTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			if t.IsZero() {
+				return time.Time{}, nil
+			}
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// UnmarshalJSON decodes a task and parses its timestamps
+func (t *Task) UnmarshalJSON(data []byte) error {
+	var resp taskResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+
+	nextRun, err := parseRMTime(resp.NextRun)
+	if err != nil {
+		return fmt.Errorf("could not parse next run of task '%s': %v", resp.ID, err)
+	}
+
+	lastRun, err := parseRMTime(resp.LastRun)
+	if err != nil {
+		return fmt.Errorf("could not parse last run of task '%s': %v", resp.ID, err)
+	}
+
+	*t = Task{
+		ID:            resp.ID,
+		Name:          resp.Name,
+		Type:          resp.Type,
+		Message:       resp.Message,
+		CurrentState:  resp.CurrentState,
+		LastRunResult: resp.LastRunResult,
+		NextRun:       nextRun,
+		LastRun:       lastRun,
+	}
+
+	return nil
+}
+
+// GetTasks returns the tasks of the given type, or all tasks if no type is given
+func GetTasks(rm RM, taskType string) ([]Task, error) {
+	continuation := ""
+
+	get := func() (listResp listTasksResponse, err error) {
+		query := url.Values{}
+		if taskType != "" {
+			query.Set("type", taskType)
+		}
+		if continuation != "" {
+			query.Set("continuationToken", continuation)
+		}
+
+		endpoint := restTasks
+		if len(query) > 0 {
+			endpoint += "?" + query.Encode()
+		}
+
+		body, resp, err := rm.Get(endpoint)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return
+		}
+
+		err = json.Unmarshal(body, &listResp)
+
+		return
+	}
+
+	tasks := make([]Task, 0)
+	for {
+		resp, err := get()
+		if err != nil {
+			return tasks, fmt.Errorf("could not get tasks: %v", err)
+		}
+
+		tasks = append(tasks, resp.Items...)
+
+		if resp.ContinuationToken == "" {
+			break
+		}
+
+		continuation = resp.ContinuationToken
+	}
+
+	return tasks, nil
+}
+
+// GetTaskByID returns the task with the given id
+func GetTaskByID(rm RM, id string) (Task, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("no task with id '%s': %v", id, err)
+	}
+
+	var task Task
+
+	url := fmt.Sprintf("%s/%s", restTasks, id)
+	body, resp, err := rm.Get(url)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return task, doError(err)
+	}
+
+	if err := json.Unmarshal(body, &task); err != nil {
+		return task, doError(err)
+	}
+
+	return task, nil
+}
+
+// RunTask starts the task with the given id
+func RunTask(rm RM, id string) error {
+	if _, resp, err := rm.Post(fmt.Sprintf(restTasksRun, id), nil); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not run task '%s': %v", id, err)
+	}
+
+	return nil
+}
+
+// StopTask stops the task with the given id
+func StopTask(rm RM, id string) error {
+	if _, resp, err := rm.Post(fmt.Sprintf(restTasksStop, id), nil); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("could not stop task '%s': %v", id, err)
+	}
+
+	return nil
+}
+
+// WaitForTask polls the task with the given id at the given interval until it is no longer running
+// and returns its final state. The wait can be bounded by giving a context with a timeout.
+// Use RunTaskAndWait to start a task, as a task which was just started may not be running yet.
+func WaitForTask(ctx context.Context, rm RM, id string, interval time.Duration) (Task, error) {
+	return waitForTask(ctx, rm, id, interval, time.Time{})
+}
+
+// RunTaskAndWait starts the task with the given id and waits for that run to complete
+func RunTaskAndWait(ctx context.Context, rm RM, id string, interval time.Duration) (Task, error) {
+	before, err := GetTaskByID(rm, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if err := RunTask(rm, id); err != nil {
+		return Task{}, err
+	}
+
+	return waitForTask(ctx, rm, id, interval, before.LastRun)
+}
+
+func waitForTask(ctx context.Context, rm RM, id string, interval time.Duration, previousRun time.Time) (Task, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		task, err := GetTaskByID(rm, id)
+		if err != nil {
+			return task, err
+		}
+
+		if task.CurrentState != TaskStateRunning && task.LastRun.After(previousRun) {
+			return task, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return task, fmt.Errorf("stopped waiting for task '%s': %v", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
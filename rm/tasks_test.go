@@ -0,0 +1,204 @@
+package nexusrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var dummyTasks = []taskResponse{
+	{ID: "task1", Name: "Rebuild maven index", Type: TaskTypeRebuildIndex, CurrentState: TaskStateWaiting, LastRunResult: TaskResultOK, NextRun: "2020-06-01T00:00:00.000+00:00", LastRun: "2020-05-01T00:00:00.000+00:00"},
+	{ID: "task2", Name: "Compact default", Type: TaskTypeCompactBlobStore, CurrentState: TaskStateWaiting},
+	{ID: "task3", Name: "Cleanup", Type: TaskTypeCleanup, CurrentState: TaskStateRunning, LastRun: "2020-05-02T10:00:00.000+0000"},
+}
+
+func tasksTestRM(t *testing.T, pollsUntilDone int) (rm RM, mock *httptest.Server) {
+	var mu sync.Mutex
+	tasks := make(map[string]*taskResponse)
+	polls := make(map[string]int)
+	for i := range dummyTasks {
+		task := dummyTasks[i]
+		tasks[task.ID] = &task
+	}
+
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restTasks), "/")
+		parts := strings.Split(path, "/")
+
+		switch {
+		case r.Method == http.MethodGet && path == "":
+			query := r.URL.Query()
+
+			var list struct {
+				Items             []taskResponse `json:"items"`
+				ContinuationToken string         `json:"continuationToken"`
+			}
+			for _, d := range dummyTasks {
+				if typ := query.Get("type"); typ == "" || typ == d.Type {
+					list.Items = append(list.Items, *tasks[d.ID])
+				}
+			}
+
+			// page the unfiltered list
+			if query.Get("type") == "" {
+				if query.Get("continuationToken") == "" {
+					list.Items = list.Items[:1]
+					list.ContinuationToken = dummyContinuationToken
+				} else {
+					list.Items = list.Items[1:]
+				}
+			}
+
+			resp, err := json.Marshal(list)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodGet && len(parts) == 1:
+			task, ok := tasks[parts[0]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if task.CurrentState == TaskStateRunning {
+				polls[task.ID]++
+				if polls[task.ID] > pollsUntilDone {
+					task.CurrentState = TaskStateWaiting
+					task.LastRunResult = TaskResultOK
+				}
+			}
+
+			resp, err := json.Marshal(task)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "run":
+			task, ok := tasks[parts[0]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			task.CurrentState = TaskStateRunning
+			task.LastRun = time.Now().UTC().Format(time.RFC3339Nano)
+			task.LastRunResult = ""
+			polls[task.ID] = 0
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "stop":
+			task, ok := tasks[parts[0]]
+			if !ok || task.CurrentState != TaskStateRunning {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+
+			task.CurrentState = TaskStateWaiting
+			task.LastRunResult = TaskResultCanceled
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestGetTasks(t *testing.T) {
+	rm, mock := tasksTestRM(t, 0)
+	defer mock.Close()
+
+	tasks, err := GetTasks(rm, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != len(dummyTasks) {
+		t.Fatalf("Received %d tasks instead of %d", len(tasks), len(dummyTasks))
+	}
+
+	expectedNextRun := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	if !tasks[0].NextRun.Equal(expectedNextRun) {
+		t.Errorf("Expected next run %v but got %v", expectedNextRun, tasks[0].NextRun)
+	}
+
+	if !tasks[1].LastRun.IsZero() {
+		t.Errorf("Expected task which never ran to have zero last run: %v", tasks[1].LastRun)
+	}
+
+	expectedLastRun := time.Date(2020, 5, 2, 10, 0, 0, 0, time.UTC)
+	if !tasks[2].LastRun.Equal(expectedLastRun) {
+		t.Errorf("Expected last run %v but got %v", expectedLastRun, tasks[2].LastRun)
+	}
+}
+
+func TestGetTasksByType(t *testing.T) {
+	rm, mock := tasksTestRM(t, 0)
+	defer mock.Close()
+
+	tasks, err := GetTasks(rm, TaskTypeCompactBlobStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].ID != "task2" {
+		t.Errorf("Did not receive expected tasks: %v", tasks)
+	}
+}
+
+func TestRunTaskAndWait(t *testing.T) {
+	rm, mock := tasksTestRM(t, 2)
+	defer mock.Close()
+
+	task, err := RunTaskAndWait(context.Background(), rm, "task1", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.CurrentState != TaskStateWaiting || task.LastRunResult != TaskResultOK {
+		t.Errorf("Unexpected final task state: %v", task)
+	}
+}
+
+func TestWaitForTaskTimeout(t *testing.T) {
+	rm, mock := tasksTestRM(t, 1000)
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := WaitForTask(ctx, rm, "task3", time.Millisecond); err == nil {
+		t.Error("Expected wait to time out")
+	}
+}
+
+func TestStopTask(t *testing.T) {
+	rm, mock := tasksTestRM(t, 1000)
+	defer mock.Close()
+
+	if err := StopTask(rm, "task3"); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := GetTaskByID(rm, "task3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.LastRunResult != TaskResultCanceled {
+		t.Errorf("Task not stopped: %v", task)
+	}
+
+	if err := StopTask(rm, "task2"); err == nil {
+		t.Error("Expected error stopping a task which is not running")
+	}
+}
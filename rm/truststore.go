@@ -0,0 +1,232 @@
+package nexusrm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	restSSL        = "service/rest/v1/security/ssl"
+	restTrustStore = "service/rest/v1/security/ssl/truststore"
+)
+
+// TrustedCertificate describes a certificate in the RM truststore
+type TrustedCertificate struct {
+	ID           string
+	PEM          string
+	Fingerprint  string
+	SerialNumber string
+	Subject      string
+	Issuer       string
+	IssuedOn     time.Time
+	ExpiresOn    time.Time
+	Certificate  *x509.Certificate
+}
+
+// ExpiresWithin returns true if the certificate is expired or will expire within the given duration
+func (c TrustedCertificate) ExpiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).After(c.ExpiresOn)
+}
+
+type apiCertificate struct {
+	ID                        string `json:"id"`
+	PEM                       string `json:"pem"`
+	Fingerprint               string `json:"fingerprint"`
+	SerialNumber              string `json:"serialNumber"`
+	SubjectCommonName         string `json:"subjectCommonName"`
+	SubjectOrganization       string `json:"subjectOrganization"`
+	SubjectOrganizationalUnit string `json:"subjectOrganizationalUnit"`
+	IssuerCommonName          string `json:"issuerCommonName"`
+	IssuerOrganization        string `json:"issuerOrganization"`
+	IssuerOrganizationalUnit  string `json:"issuerOrganizationalUnit"`
+	IssuedOn                  int64  `json:"issuedOn"`
+	ExpiresOn                 int64  `json:"expiresOn"`
+}
+
+func parseCertificatePEM(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(hex, ":")
+}
+
+func (c apiCertificate) trustedCertificate() TrustedCertificate {
+	tc := TrustedCertificate{
+		ID:           c.ID,
+		PEM:          c.PEM,
+		Fingerprint:  c.Fingerprint,
+		SerialNumber: c.SerialNumber,
+		Subject:      c.SubjectCommonName,
+		Issuer:       c.IssuerCommonName,
+		IssuedOn:     time.Unix(0, c.IssuedOn*int64(time.Millisecond)),
+		ExpiresOn:    time.Unix(0, c.ExpiresOn*int64(time.Millisecond)),
+	}
+
+	// Prefer the values from the certificate itself when it can be parsed
+	if cert, err := parseCertificatePEM(c.PEM); err == nil {
+		tc.Certificate = cert
+		tc.Fingerprint = certificateFingerprint(cert)
+		tc.SerialNumber = cert.SerialNumber.String()
+		tc.Subject = cert.Subject.String()
+		tc.Issuer = cert.Issuer.String()
+		tc.IssuedOn = cert.NotBefore
+		tc.ExpiresOn = cert.NotAfter
+	}
+
+	return tc
+}
+
+// GetTrustStoreCertificates returns all of the certificates in the RM truststore
+func GetTrustStoreCertificates(rm RM) ([]TrustedCertificate, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list truststore certificates: %v", err)
+	}
+
+	body, resp, err := rm.Get(restTrustStore)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	var apiCerts []apiCertificate
+	if err := json.Unmarshal(body, &apiCerts); err != nil {
+		return nil, doError(err)
+	}
+
+	certs := make([]TrustedCertificate, len(apiCerts))
+	for i, c := range apiCerts {
+		certs[i] = c.trustedCertificate()
+	}
+
+	return certs, nil
+}
+
+// AddTrustStoreCertificate adds the given PEM encoded certificate to the RM truststore
+func AddTrustStoreCertificate(rm RM, certPEM string) (TrustedCertificate, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not add certificate to truststore: %v", err)
+	}
+
+	if _, err := parseCertificatePEM(certPEM); err != nil {
+		return TrustedCertificate{}, doError(err)
+	}
+
+	// the endpoint expects the PEM itself as the body, not a JSON string
+	body, resp, err := rm.Post(restTrustStore, bytes.NewBufferString(certPEM))
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusCreated) {
+		return TrustedCertificate{}, doError(err)
+	}
+
+	var added apiCertificate
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &added); err != nil {
+			return TrustedCertificate{}, doError(err)
+		}
+		return added.trustedCertificate(), nil
+	}
+
+	// The body of a 201 response is not returned by the client so look up the new certificate
+	added.PEM = certPEM
+	cert := added.trustedCertificate()
+
+	certs, err := GetTrustStoreCertificates(rm)
+	if err != nil {
+		return TrustedCertificate{}, doError(err)
+	}
+
+	for _, c := range certs {
+		if c.Fingerprint == cert.Fingerprint {
+			return c, nil
+		}
+	}
+
+	return cert, nil
+}
+
+// RetrieveRemoteCertificate has RM connect to the given host and return the certificate it presents.
+// RM only returns the leaf certificate of the host, not the rest of its chain, so when the leaf is signed by
+// an internal CA, the CA certificate has to be added to the truststore with AddTrustStoreCertificate instead.
+func RetrieveRemoteCertificate(rm RM, host string, port int) (TrustedCertificate, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not retrieve certificate of %s:%d: %v", host, port, err)
+	}
+
+	query := url.Values{}
+	query.Set("host", host)
+	query.Set("port", strconv.Itoa(port))
+
+	body, resp, err := rm.Get(restSSL + "?" + query.Encode())
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return TrustedCertificate{}, doError(err)
+	}
+
+	var cert apiCertificate
+	if err := json.Unmarshal(body, &cert); err != nil {
+		return TrustedCertificate{}, doError(err)
+	}
+
+	return cert.trustedCertificate(), nil
+}
+
+// AddTrustStoreCertificateFromHost retrieves the certificate presented by the given host through RM and adds it to the truststore
+func AddTrustStoreCertificateFromHost(rm RM, host string, port int) (TrustedCertificate, error) {
+	cert, err := RetrieveRemoteCertificate(rm, host, port)
+	if err != nil {
+		return TrustedCertificate{}, err
+	}
+
+	return AddTrustStoreCertificate(rm, cert.PEM)
+}
+
+// RemoveTrustStoreCertificate removes the certificate with the given id from the RM truststore
+func RemoveTrustStoreCertificate(rm RM, id string) error {
+	url := fmt.Sprintf("%s/%s", restTrustStore, id)
+
+	if resp, err := rm.Del(url); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("certificate not removed from truststore '%s': %v", id, err)
+	}
+
+	return nil
+}
+
+// GetExpiringTrustStoreCertificates returns the truststore certificates which are expired or will expire
+// within the given duration, sorted by the soonest expiration
+func GetExpiringTrustStoreCertificates(rm RM, within time.Duration) ([]TrustedCertificate, error) {
+	certs, err := GetTrustStoreCertificates(rm)
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := make([]TrustedCertificate, 0)
+	for _, c := range certs {
+		if c.ExpiresWithin(within) {
+			expiring = append(expiring, c)
+		}
+	}
+
+	sort.Slice(expiring, func(i, j int) bool { return expiring[i].ExpiresOn.Before(expiring[j].ExpiresOn) })
+
+	return expiring, nil
+}
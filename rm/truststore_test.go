@@ -0,0 +1,195 @@
+package nexusrm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCertificatePEM(t *testing.T, cn string, serial int64, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Acme"}},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func truststoreTestRM(t *testing.T) (rm RM, mock *httptest.Server, store map[string]apiCertificate) {
+	store = map[string]apiCertificate{
+		"expired": {ID: "expired", PEM: newTestCertificatePEM(t, "expired.example", 1, time.Now().Add(-24*time.Hour))},
+		"soon":    {ID: "soon", PEM: newTestCertificatePEM(t, "soon.example", 2, time.Now().Add(10*24*time.Hour))},
+		"later":   {ID: "later", PEM: newTestCertificatePEM(t, "later.example", 3, time.Now().Add(400*24*time.Hour))},
+	}
+	remote := newTestCertificatePEM(t, "upstream.example", 4, time.Now().Add(90*24*time.Hour))
+
+	rm, mock = newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path[1:] == restTrustStore:
+			certs := make([]apiCertificate, 0, len(store))
+			for _, c := range store {
+				certs = append(certs, c)
+			}
+
+			resp, err := json.Marshal(certs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodGet && r.URL.Path[1:] == restSSL:
+			if r.URL.Query().Get("host") != "upstream.example" || r.URL.Query().Get("port") != "443" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			resp, err := json.Marshal(apiCertificate{PEM: remote})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodPost && r.URL.Path[1:] == restTrustStore:
+			defer r.Body.Close()
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			certPEM := string(body)
+			if !strings.HasPrefix(certPEM, "-----BEGIN CERTIFICATE-----") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			cert := apiCertificate{ID: fmt.Sprintf("added%d", len(store)), PEM: certPEM}
+			store[cert.ID] = cert
+
+			resp, err := json.Marshal(cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintln(w, string(resp))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path[1:], restTrustStore+"/"):
+			id := strings.TrimPrefix(r.URL.Path[1:], restTrustStore+"/")
+			if _, ok := store[id]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			delete(store, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	return
+}
+
+func TestGetTrustStoreCertificates(t *testing.T) {
+	rm, mock, store := truststoreTestRM(t)
+	defer mock.Close()
+
+	certs, err := GetTrustStoreCertificates(rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != len(store) {
+		t.Fatalf("Received %d certificates instead of %d", len(certs), len(store))
+	}
+
+	for _, c := range certs {
+		if c.Certificate == nil {
+			t.Fatalf("Certificate %s was not parsed", c.ID)
+		}
+		if !strings.Contains(c.Subject, "CN="+c.ID+".example") {
+			t.Errorf("Unexpected subject %q for %s", c.Subject, c.ID)
+		}
+		if len(c.Fingerprint) != 59 {
+			t.Errorf("Unexpected fingerprint format %q", c.Fingerprint)
+		}
+	}
+}
+
+func TestAddAndRemoveTrustStoreCertificate(t *testing.T) {
+	rm, mock, store := truststoreTestRM(t)
+	defer mock.Close()
+
+	certPEM := newTestCertificatePEM(t, "internal.example", 5, time.Now().Add(30*24*time.Hour))
+
+	added, err := AddTrustStoreCertificate(rm, certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(added.Subject, "CN=internal.example") || added.SerialNumber != "5" {
+		t.Errorf("Unexpected certificate added: %v", added)
+	}
+
+	if err := RemoveTrustStoreCertificate(rm, added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store[added.ID]; ok {
+		t.Error("Certificate not removed")
+	}
+
+	if _, err := AddTrustStoreCertificate(rm, "not a certificate"); err == nil {
+		t.Error("Expected invalid PEM to be rejected")
+	}
+}
+
+func TestAddTrustStoreCertificateFromHost(t *testing.T) {
+	rm, mock, _ := truststoreTestRM(t)
+	defer mock.Close()
+
+	added, err := AddTrustStoreCertificateFromHost(rm, "upstream.example", 443)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(added.Subject, "CN=upstream.example") {
+		t.Errorf("Unexpected certificate added: %v", added)
+	}
+}
+
+func TestGetExpiringTrustStoreCertificates(t *testing.T) {
+	rm, mock, _ := truststoreTestRM(t)
+	defer mock.Close()
+
+	expiring, err := GetExpiringTrustStoreCertificates(rm, 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expiring) != 2 || expiring[0].ID != "expired" || expiring[1].ID != "soon" {
+		t.Errorf("Did not receive expected expiring certificates: %v", expiring)
+	}
+}
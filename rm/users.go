@@ -0,0 +1,248 @@
+package nexusrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	restUsers               = "service/rest/v1/security/users"
+	restUsersChangePassword = "service/rest/v1/security/users/%s/change-password"
+)
+
+// Enumerates the statuses a user can have
+const (
+	UserStatusActive         = "active"
+	UserStatusLocked         = "locked"
+	UserStatusDisabled       = "disabled"
+	UserStatusChangePassword = "changepassword"
+)
+
+// SecurityUser encapsulates a Repository Manager user as managed by the security API
+type SecurityUser struct {
+	UserID        string   `json:"userId"`
+	FirstName     string   `json:"firstName"`
+	LastName      string   `json:"lastName"`
+	EmailAddress  string   `json:"emailAddress"`
+	Source        string   `json:"source,omitempty"`
+	Status        string   `json:"status"`
+	ReadOnly      bool     `json:"readOnly,omitempty"`
+	Roles         []string `json:"roles"`
+	ExternalRoles []string `json:"externalRoles,omitempty"`
+}
+
+type createUserRequest struct {
+	SecurityUser
+	Password string `json:"password"`
+}
+
+// GetUsers returns the users which match the given user id and source.
+// Empty values will not filter the results.
+func GetUsers(rm RM, userID, source string) ([]SecurityUser, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not list users: %v", err)
+	}
+
+	query := url.Values{}
+	if userID != "" {
+		query.Set("userId", userID)
+	}
+	if source != "" {
+		query.Set("source", source)
+	}
+
+	endpoint := restUsers
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	body, resp, err := rm.Get(endpoint)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, doError(err)
+	}
+
+	users := make([]SecurityUser, 0)
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, doError(err)
+	}
+
+	return users, nil
+}
+
+// GetUserByID returns the user with the given id
+func GetUserByID(rm RM, userID string) (SecurityUser, error) {
+	users, err := GetUsers(rm, userID, "")
+	if err != nil {
+		return SecurityUser{}, fmt.Errorf("could not find user '%s': %v", userID, err)
+	}
+
+	// the userId filter is a prefix match
+	for _, u := range users {
+		if u.UserID == userID {
+			return u, nil
+		}
+	}
+
+	return SecurityUser{}, fmt.Errorf("did not find user '%s'", userID)
+}
+
+// CreateUser creates a new user in the default source with the given password
+func CreateUser(rm RM, user SecurityUser, password string) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not create user '%s': %v", user.UserID, err)
+	}
+
+	buf, err := json.Marshal(createUserRequest{user, password})
+	if err != nil {
+		return doError(err)
+	}
+
+	if _, _, err := rm.Post(restUsers, bytes.NewBuffer(buf)); err != nil {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// UpdateUser replaces the user which has the same id as the given user
+func UpdateUser(rm RM, user SecurityUser) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not update user '%s': %v", user.UserID, err)
+	}
+
+	buf, err := json.Marshal(user)
+	if err != nil {
+		return doError(err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s", restUsers, user.UserID)
+	if _, resp, err := rm.Put(endpoint, bytes.NewBuffer(buf)); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// DeleteUserByID removes the user with the given id
+func DeleteUserByID(rm RM, userID string) error {
+	endpoint := fmt.Sprintf("%s/%s", restUsers, userID)
+
+	if resp, err := rm.Del(endpoint); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return fmt.Errorf("user not deleted '%s': %v", userID, err)
+	}
+
+	return nil
+}
+
+// ChangeUserPassword sets the password of the user with the given id
+func ChangeUserPassword(rm RM, userID, password string) error {
+	doError := func(err error) error {
+		return fmt.Errorf("could not change password of user '%s': %v", userID, err)
+	}
+
+	req, err := rm.NewRequest(http.MethodPut, fmt.Sprintf(restUsersChangePassword, userID), strings.NewReader(password))
+	if err != nil {
+		return doError(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	if _, resp, err := rm.Do(req); err != nil && (resp == nil || resp.StatusCode != http.StatusNoContent) {
+		return doError(err)
+	}
+
+	return nil
+}
+
+// ExpandRoles returns the given roles along with every role they contain, directly or through other roles
+func ExpandRoles(rm RM, roleIDs []string) ([]Role, error) {
+	expanded := make([]Role, 0)
+	seen := make(map[string]bool)
+
+	queue := append([]string{}, roleIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		role, err := GetRoleById(rm, id)
+		if err != nil {
+			return nil, fmt.Errorf("could not expand roles: %v", err)
+		}
+
+		expanded = append(expanded, role)
+		queue = append(queue, role.Roles...)
+	}
+
+	return expanded, nil
+}
+
+// GetUserEffectivePrivileges returns every privilege granted to the user through its roles, its mapped external roles
+// and their nested roles
+func GetUserEffectivePrivileges(rm RM, userID string) ([]Privilege, error) {
+	doError := func(err error) error {
+		return fmt.Errorf("could not resolve privileges of user '%s': %v", userID, err)
+	}
+
+	user, err := GetUserByID(rm, userID)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	roleIDs := append([]string{}, user.Roles...)
+	if len(user.ExternalRoles) > 0 {
+		// external roles, such as LDAP groups, only grant the privileges of the role they are mapped to,
+		// which has the same id, and none when they are not mapped
+		known, err := GetRoles(rm, "")
+		if err != nil {
+			return nil, doError(err)
+		}
+
+		mapped := make(map[string]bool)
+		for _, r := range known {
+			mapped[r.Id] = true
+		}
+
+		for _, id := range user.ExternalRoles {
+			if mapped[id] {
+				roleIDs = append(roleIDs, id)
+			}
+		}
+	}
+
+	roles, err := ExpandRoles(rm, roleIDs)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	names := make(map[string]bool)
+	for _, r := range roles {
+		for _, p := range r.Privileges {
+			names[p] = true
+		}
+	}
+
+	all, err := GetPrivileges(rm)
+	if err != nil {
+		return nil, doError(err)
+	}
+
+	privileges := make([]Privilege, 0, len(names))
+	for _, p := range all {
+		if names[p.Name] {
+			privileges = append(privileges, p)
+		}
+	}
+
+	sort.Slice(privileges, func(i, j int) bool { return privileges[i].Name < privileges[j].Name })
+
+	return privileges, nil
+}
@@ -0,0 +1,366 @@
+package nexusrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dummyUsers = []SecurityUser{
+	{UserID: "admin", FirstName: "Admin", LastName: "User", EmailAddress: "admin@example.org", Source: "default", Status: UserStatusActive, Roles: []string{"nx-admin"}},
+	{UserID: "deployer", FirstName: "Deploy", LastName: "Bot", EmailAddress: "deploy@example.org", Source: "default", Status: UserStatusActive, Roles: []string{"deployer"}},
+	{UserID: "ldap", FirstName: "Ldap", LastName: "User", EmailAddress: "ldap@example.org", Source: "LDAP", Status: UserStatusActive, Roles: []string{}, ExternalRoles: []string{"scripter", "unmapped-group"}},
+}
+
+var dummyRoles = []Role{
+	{Id: "nx-admin", Source: "default", Name: "nx-admin", Privileges: []string{"nx-all"}, Roles: []string{}},
+	{Id: "deployer", Source: "default", Name: "deployer", Privileges: []string{"nx-repository-view-maven2-*-edit"}, Roles: []string{"reader", "scripter"}},
+	{Id: "reader", Source: "default", Name: "reader", Privileges: []string{"nx-repository-view-*-*-read"}, Roles: []string{"deployer"}},
+	{Id: "scripter", Source: "default", Name: "scripter", Privileges: []string{"nx-script-*-run", "nx-repository-view-*-*-read"}, Roles: []string{}},
+}
+
+var dummyPasswords = map[string]string{}
+
+func usersTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	getUserByID := func(id string) (int, bool) {
+		for i, u := range dummyUsers {
+			if u.UserID == id {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restUsers), "/")
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		users := make([]SecurityUser, 0)
+		for _, u := range dummyUsers {
+			if strings.HasPrefix(u.UserID, r.URL.Query().Get("userId")) {
+				users = append(users, u)
+			}
+		}
+
+		resp, err := json.Marshal(users)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodPost && path == "":
+		var req createUserRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req.SecurityUser.Source = "default"
+		dummyUsers = append(dummyUsers, req.SecurityUser)
+		dummyPasswords[req.UserID] = req.Password
+
+		resp, err := json.Marshal(req.SecurityUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodPut && strings.HasSuffix(path, "/change-password"):
+		id := strings.TrimSuffix(path, "/change-password")
+		if _, ok := getUserByID(id); !ok || r.Header.Get("Content-Type") != "text/plain" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyPasswords[id] = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		i, ok := getUserByID(path)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var user SecurityUser
+		if err := json.Unmarshal(body, &user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		dummyUsers[i] = user
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		if i, ok := getUserByID(path); ok {
+			dummyUsers = append(dummyUsers[:i], dummyUsers[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func rolesTestFunc(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path[1:], restRole), "/")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		roles := make([]Role, 0)
+		for _, role := range dummyRoles {
+			if source := r.URL.Query().Get("source"); source == "" || role.Source == source {
+				roles = append(roles, role)
+			}
+		}
+
+		resp, err := json.Marshal(roles)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintln(w, string(resp))
+	case r.Method == http.MethodGet:
+		for _, role := range dummyRoles {
+			if role.Id == id {
+				resp, err := json.Marshal(role)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				fmt.Fprintln(w, string(resp))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPut:
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var updated Role
+		if err := json.Unmarshal(body, &updated); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		for i, role := range dummyRoles {
+			if role.Id == id {
+				dummyRoles[i] = updated
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func securityTestRM(t *testing.T) (rm RM, mock *httptest.Server) {
+	return newTestRM(t, func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path[1:], restUsers):
+			usersTestFunc(t, w, r)
+		case strings.HasPrefix(r.URL.Path[1:], restRole):
+			rolesTestFunc(t, w, r)
+		case strings.HasPrefix(r.URL.Path[1:], restPrivileges):
+			privilegesTestFunc(t, w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestGetUsers(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	users, err := GetUsers(rm, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(users, dummyUsers) {
+		t.Errorf("Did not receive expected users: %v", users)
+	}
+}
+
+func TestGetUserByID(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	user, err := GetUserByID(rm, dummyUsers[0].UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(user, dummyUsers[0]) {
+		t.Errorf("Did not receive expected user: %v", user)
+	}
+
+	if _, err := GetUserByID(rm, "adm"); err == nil {
+		t.Error("Expected an error when only a prefix of the user id matches")
+	}
+}
+
+func TestCreateUpdateDeleteUser(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	user := SecurityUser{UserID: "tester", FirstName: "Test", LastName: "Er", EmailAddress: "test@example.org", Status: UserStatusActive, Roles: []string{"reader"}}
+	if err := CreateUser(rm, user, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if dummyPasswords[user.UserID] != "s3cret" {
+		t.Error("Password not sent when creating user")
+	}
+
+	user.Source = "default"
+	user.Status = UserStatusDisabled
+	if err := UpdateUser(rm, user); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetUserByID(rm, user.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, user) {
+		t.Errorf("Did not receive updated user: %v", got)
+	}
+
+	if err := ChangeUserPassword(rm, user.UserID, "n3w"); err != nil {
+		t.Fatal(err)
+	}
+	if dummyPasswords[user.UserID] != "n3w" {
+		t.Error("Password not changed")
+	}
+
+	if err := DeleteUserByID(rm, user.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetUserByID(rm, user.UserID); err == nil {
+		t.Error("User not deleted")
+	}
+}
+
+func TestGetRoles(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	roles, err := GetRoles(rm, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(roles, dummyRoles) {
+		t.Errorf("Did not receive expected roles: %v", roles)
+	}
+
+	roles, err = GetRoles(rm, "default#other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roles) != 0 {
+		t.Errorf("Expected the source to be escaped but got roles %v", roles)
+	}
+}
+
+func TestUpdateRole(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	role := dummyRoles[0]
+	role.Description = "updated"
+
+	if err := UpdateRole(rm, role); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetRoleById(rm, role.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, role) {
+		t.Errorf("Did not receive updated role: %v", got)
+	}
+}
+
+func TestExpandRoles(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	roles, err := ExpandRoles(rm, []string{"deployer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(roles))
+	for i, r := range roles {
+		ids[i] = r.Id
+	}
+
+	// reader references deployer back, which must not loop forever
+	if expected := []string{"deployer", "reader", "scripter"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected roles %v but got %v", expected, ids)
+	}
+}
+
+func TestGetUserEffectivePrivileges(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	privileges, err := GetUserEffectivePrivileges(rm, "deployer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(privileges))
+	for i, p := range privileges {
+		names[i] = p.Name
+	}
+
+	expected := []string{"nx-repository-view-*-*-read", "nx-repository-view-maven2-*-edit", "nx-script-*-run"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected privileges %v but got %v", expected, names)
+	}
+}
+
+func TestGetUserEffectivePrivilegesExternalRoles(t *testing.T) {
+	rm, mock := securityTestRM(t)
+	defer mock.Close()
+
+	// the unmapped group grants nothing and must not fail the expansion
+	privileges, err := GetUserEffectivePrivileges(rm, "ldap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(privileges))
+	for i, p := range privileges {
+		names[i] = p.Name
+	}
+
+	expected := []string{"nx-repository-view-*-*-read", "nx-script-*-run"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected privileges %v but got %v", expected, names)
+	}
+}
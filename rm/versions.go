@@ -0,0 +1,78 @@
+package nexusrm
+
+import (
+	"sort"
+
+	nexus "github.com/overag3/gonexus"
+	"github.com/overag3/gonexus/versions"
+)
+
+// SortComponentsByVersion sorts components from their lowest to their highest version,
+// following the versioning scheme of their format
+func SortComponentsByVersion(components []RepositoryItem) {
+	sort.SliceStable(components, func(i, j int) bool {
+		scheme := versions.ForFormat(components[i].Format)
+		return scheme.Compare(components[i].Version, components[j].Version) < 0
+	})
+}
+
+// LatestComponent returns the component with the highest version in the given range, expressed in the
+// range syntax of the format of the components (see versions.ParseRange). An empty range matches any version.
+// The components are expected to be versions of the same component, such as those of a search by name.
+func LatestComponent(components []RepositoryItem, versionRange string) (RepositoryItem, bool, error) {
+	ranges := make(map[versions.Scheme]versions.Range)
+
+	var latest RepositoryItem
+	found := false
+	for _, c := range components {
+		scheme := versions.ForFormat(c.Format)
+
+		r, ok := ranges[scheme]
+		if !ok {
+			var err error
+			if r, err = versions.ParseRange(scheme, versionRange); err != nil {
+				return RepositoryItem{}, false, err
+			}
+			ranges[scheme] = r
+		}
+
+		if !r.Contains(c.Version) {
+			continue
+		}
+
+		if !found || scheme.Compare(c.Version, latest.Version) > 0 {
+			latest, found = c, true
+		}
+	}
+
+	return latest, found, nil
+}
+
+// LatestRepositoryComponent returns the component of the repository with the given group and name,
+// which can be empty for formats without groups, and the highest version in the given range
+func LatestRepositoryComponent(rm RM, repo, group, name, versionRange string) (RepositoryItem, bool, error) {
+	components, err := GetComponents(rm, repo)
+	if err != nil {
+		return RepositoryItem{}, false, err
+	}
+
+	matching := make([]RepositoryItem, 0)
+	for _, c := range components {
+		if c.Group == group && c.Name == name {
+			matching = append(matching, c)
+		}
+	}
+
+	return LatestComponent(matching, versionRange)
+}
+
+// SearchLatestComponent searches for components and returns the one with the highest version in the given range.
+// The query should identify a single component, such as by its group and name, without a version.
+func SearchLatestComponent(rm RM, query nexus.SearchQueryBuilder, versionRange string) (RepositoryItem, bool, error) {
+	components, err := SearchComponents(rm, query)
+	if err != nil {
+		return RepositoryItem{}, false, err
+	}
+
+	return LatestComponent(components, versionRange)
+}
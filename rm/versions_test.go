@@ -0,0 +1,57 @@
+package nexusrm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortComponentsByVersion(t *testing.T) {
+	components := []RepositoryItem{
+		{Format: "maven2", Name: "app", Version: "1.0"},
+		{Format: "maven2", Name: "app", Version: "1.10"},
+		{Format: "maven2", Name: "app", Version: "1.0-SNAPSHOT"},
+		{Format: "maven2", Name: "app", Version: "1.9"},
+	}
+
+	SortComponentsByVersion(components)
+
+	actual := make([]string, 0)
+	for _, c := range components {
+		actual = append(actual, c.Version)
+	}
+
+	expected := []string{"1.0-SNAPSHOT", "1.0", "1.9", "1.10"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestLatestRepositoryComponent(t *testing.T) {
+	f := newFakeRepositoryManager(t, inventoryRepository("npm-hosted", "npm", "hosted", "default"))
+	defer f.Close()
+
+	for _, v := range []string{"1.2.0", "1.10.1", "1.11.0-beta.1", "2.0.0"} {
+		f.addAsset("npm-hosted", "", "left-pad", v, "left-pad/-/left-pad-"+v+".tgz", []byte(v))
+	}
+	f.addAsset("npm-hosted", "", "right-pad", "1.99.0", "right-pad/-/right-pad-1.99.0.tgz", []byte("right-pad"))
+
+	latest, found, err := LatestRepositoryComponent(f.rm, "npm-hosted", "", "left-pad", "^1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || latest.Version != "1.10.1" {
+		t.Errorf("Expected 1.10.1 but got %v", latest)
+	}
+
+	if latest, found, _ = LatestRepositoryComponent(f.rm, "npm-hosted", "", "left-pad", ""); !found || latest.Version != "2.0.0" {
+		t.Errorf("Expected 2.0.0 but got %v", latest)
+	}
+
+	if _, found, _ = LatestRepositoryComponent(f.rm, "npm-hosted", "", "left-pad", "^3.0.0"); found {
+		t.Error("Expected no component in range")
+	}
+
+	if _, _, err = LatestRepositoryComponent(f.rm, "npm-hosted", "", "left-pad", "^1.a"); err == nil {
+		t.Error("Expected invalid range to be rejected")
+	}
+}
@@ -0,0 +1,616 @@
+package nexusrm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A minimal YAML parser for the documents of helm repositories, index.yaml and Chart.yaml, which supports block
+// mappings and sequences, plain and quoted scalars spanning one or more lines, literal and folded block scalars
+// and flow sequences and mappings. Anchors, aliases, tags and multiple documents are not supported.
+// Mappings are parsed to map[string]interface{}, sequences to []interface{} and scalars to strings.
+
+var yamlKey = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s#'"\[\]{},:-][^#]*?|-[^\s#][^#]*?):(?:\s+|$)`)
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	raw   []string
+	pos   int
+}
+
+// parseYAML parses a YAML document
+func parseYAML(doc string) (interface{}, error) {
+	// the line break which ends the document does not start an empty line
+	doc = strings.TrimSuffix(strings.Replace(doc, "\r\n", "\n", -1), "\n")
+	p := &yamlParser{raw: strings.Split(doc, "\n")}
+
+	for i, line := range p.raw {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "%") {
+			continue
+		}
+		if strings.Contains(line, "\t") && strings.TrimLeft(line, "\t ") != strings.TrimLeft(line, " ") {
+			return nil, fmt.Errorf("yaml line %d: tabs cannot be used for indentation", i+1)
+		}
+
+		text := strings.TrimLeft(line, " ")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{number: i, indent: len(line) - len(text), text: strings.TrimRight(text, " ")})
+	}
+
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	node, err := p.parseNode(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("yaml line %d: unexpected indentation", p.lines[p.pos].number+1)
+	}
+	return node, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	seq := make([]interface{}, 0)
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSequenceItem(line.text) {
+			break
+		}
+
+		item := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		switch {
+		case item == "":
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				node, err := p.parseNode(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				seq = append(seq, node)
+			} else {
+				seq = append(seq, nil)
+			}
+		case yamlKey.MatchString(item) || isYAMLSequenceItem(item):
+			// the item is a mapping or a sequence starting on the line of its dash
+			p.lines[p.pos] = yamlLine{number: line.number, indent: indent + len(line.text) - len(item), text: item}
+			node, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, node)
+		default:
+			value, err := p.parseValue(line, item, indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, value)
+		}
+	}
+
+	return seq, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.indent == indent && isYAMLSequenceItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("yaml line %d: unexpected indentation", line.number+1)
+		}
+
+		match := yamlKey.FindStringSubmatch(line.text)
+		if match == nil {
+			return nil, fmt.Errorf("yaml line %d: expected a key", line.number+1)
+		}
+		key, err := parseYAMLScalar(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("yaml line %d: %v", line.number+1, err)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("yaml line %d: duplicate key '%s'", line.number+1, key)
+		}
+
+		rest := strings.TrimSpace(line.text[len(match[0]):])
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.pos++
+			switch {
+			case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+				m[key], err = p.parseNode(p.lines[p.pos].indent)
+			case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text):
+				// sequences can be at the same indentation as their key
+				m[key], err = p.parseSequence(indent)
+			default:
+				m[key] = nil
+			}
+		} else {
+			m[key], err = p.parseValue(line, rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// parseValue parses the value of a key or sequence item which starts on its line. The value can continue on the
+// next lines which are more indented than the key or item, as a block scalar, a multi-line plain or quoted
+// scalar or a flow collection.
+func (p *yamlParser) parseValue(line yamlLine, value string, indent int) (interface{}, error) {
+	p.pos++
+	next := p.continuation(line, indent)
+
+	if value[0] == '|' || value[0] == '>' {
+		return parseYAMLBlockScalar(value, next), nil
+	}
+
+	switch value[0] {
+	case '"', '\'':
+		value = foldYAMLQuoted(value, next)
+	case '[', '{':
+		value = joinYAMLFlow(value, next)
+	default:
+		var err error
+		if value, err = foldYAMLPlain(value, next); err != nil {
+			return nil, fmt.Errorf("yaml line %d: %v", line.number+1, err)
+		}
+	}
+
+	v, err := parseYAMLFlow(value)
+	if err != nil {
+		return nil, fmt.Errorf("yaml line %d: %v", line.number+1, err)
+	}
+	return v, nil
+}
+
+// continuation returns the lines which follow the given line and are more indented than the key or item
+// it starts, or blank, and moves past them. Comments which follow these lines are not part of them.
+func (p *yamlParser) continuation(line yamlLine, indent int) []string {
+	end := len(p.raw)
+	for p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		p.pos++
+	}
+	if p.pos < len(p.lines) {
+		end = p.lines[p.pos].number
+	}
+	for end > line.number+1 {
+		l := p.raw[end-1]
+		if text := strings.TrimLeft(l, " "); text != "" && len(l)-len(text) <= indent {
+			end--
+			continue
+		}
+		break
+	}
+
+	return p.raw[line.number+1 : end]
+}
+
+// parseYAMLBlockScalar parses a literal or folded block scalar from its header and its lines
+func parseYAMLBlockScalar(header string, block []string) string {
+	header = strings.TrimSpace(strings.SplitN(header, "#", 2)[0])
+	folded, chomp := header[0] == '>', ""
+	if strings.ContainsAny(header, "-+") {
+		chomp = header[len(header)-1:]
+	}
+
+	blockIndent := -1
+	for _, l := range block {
+		if text := strings.TrimLeft(l, " "); text != "" && (blockIndent < 0 || len(l)-len(text) < blockIndent) {
+			blockIndent = len(l) - len(text)
+		}
+	}
+
+	lines := make([]string, len(block))
+	for i, l := range block {
+		if len(l) >= blockIndent && blockIndent >= 0 {
+			lines[i] = l[blockIndent:]
+		} else {
+			lines[i] = strings.TrimLeft(l, " ")
+		}
+	}
+
+	// trailing blank lines are kept only if the block says so
+	trailing := len(lines)
+	for trailing > 0 && lines[trailing-1] == "" {
+		trailing--
+	}
+	content := lines[:trailing]
+
+	var text string
+	if folded {
+		var b strings.Builder
+		for i, l := range content {
+			switch {
+			case i == 0, content[i-1] == "" && l != "":
+			case l == "":
+				b.WriteString("\n")
+			case strings.HasPrefix(l, " ") || strings.HasPrefix(content[i-1], " "):
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(l)
+		}
+		text = b.String()
+	} else {
+		text = strings.Join(content, "\n")
+	}
+
+	switch chomp {
+	case "-":
+	case "+":
+		text += strings.Repeat("\n", len(lines)-trailing+1)
+	default:
+		if text != "" {
+			text += "\n"
+		}
+	}
+
+	return text
+}
+
+// stripYAMLComment removes the comment which can follow a plain scalar
+func stripYAMLComment(s string) string {
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// foldYAMLPlain folds the lines of a multi-line plain scalar into its value: line breaks become spaces
+// and blank lines become line breaks. A key on one of the next lines is an error, as a mapping cannot
+// be more indented than the key of a plain scalar.
+func foldYAMLPlain(first string, next []string) (string, error) {
+	folded, breaks := stripYAMLComment(first), 0
+	for _, l := range next {
+		text := strings.TrimSpace(l)
+		switch {
+		case text == "":
+			breaks++
+			continue
+		case strings.HasPrefix(text, "#"):
+			continue
+		case breaks > 0:
+			folded += strings.Repeat("\n", breaks)
+		default:
+			folded += " "
+		}
+		text = stripYAMLComment(text)
+		if strings.Contains(text, ": ") || strings.HasSuffix(text, ":") {
+			return "", errors.New("unexpected indentation")
+		}
+		folded += text
+		breaks = 0
+	}
+	return folded, nil
+}
+
+// foldYAMLQuoted folds the lines of a multi-line quoted scalar into a quoted scalar on a single line.
+// Line breaks become spaces, blank lines become line breaks, and a line break escaped in a double-quoted
+// scalar is removed along with the indentation of the next line.
+func foldYAMLQuoted(first string, next []string) string {
+	double := first[0] == '"'
+	newline := "\n"
+	if double {
+		newline = `\n`
+	}
+
+	folded, breaks := strings.TrimRight(first, " \t"), 0
+	for _, l := range next {
+		text := strings.TrimSpace(l)
+		if text == "" {
+			breaks++
+			continue
+		}
+
+		trailing := len(folded) - len(strings.TrimRight(folded, `\`))
+		switch {
+		case double && trailing%2 == 1:
+			folded = folded[:len(folded)-1] + strings.Repeat(newline, breaks)
+		case breaks > 0:
+			folded += strings.Repeat(newline, breaks)
+		default:
+			folded += " "
+		}
+		folded += text
+		breaks = 0
+	}
+	return folded
+}
+
+// joinYAMLFlow joins the lines of a flow collection written on several lines, without their comments
+func joinYAMLFlow(first string, next []string) string {
+	joined := stripYAMLFlowComment(first)
+	for _, l := range next {
+		if text := stripYAMLFlowComment(l); text != "" {
+			joined += " " + text
+		}
+	}
+	return joined
+}
+
+// stripYAMLFlowComment removes the comment which ends a line of a flow collection, outside of its quoted scalars
+func stripYAMLFlowComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// parseYAMLFlow parses a value written on a single line, which is a scalar or a flow collection
+func parseYAMLFlow(value string) (interface{}, error) {
+	if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		f := &yamlFlow{s: value}
+		node, err := f.node()
+		if err != nil {
+			return nil, err
+		}
+		if rest := strings.TrimSpace(value[f.pos:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, errors.New("unexpected content after flow collection")
+		}
+		return node, nil
+	}
+
+	s, err := parseYAMLScalar(value)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") && (s == "~" || s == "null") {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// yamlFlow parses flow collections, which can be nested, and their scalars
+type yamlFlow struct {
+	s   string
+	pos int
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.pos < len(f.s) && (f.s[f.pos] == ' ' || f.s[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) node() (interface{}, error) {
+	f.skipSpace()
+	if f.pos >= len(f.s) {
+		return nil, errors.New("unterminated flow collection")
+	}
+
+	switch f.s[f.pos] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	}
+
+	s, quoted, err := f.scalar()
+	if err != nil {
+		return nil, err
+	}
+	if !quoted && (s == "" || s == "~" || s == "null") {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// separator consumes the comma which follows an entry of a collection, unless the collection ends
+func (f *yamlFlow) separator(end byte) error {
+	f.skipSpace()
+	switch {
+	case f.pos >= len(f.s):
+		return errors.New("unterminated flow collection")
+	case f.s[f.pos] == ',':
+		f.pos++
+	case f.s[f.pos] != end:
+		return fmt.Errorf("unexpected '%c' in flow collection", f.s[f.pos])
+	}
+	return nil
+}
+
+func (f *yamlFlow) sequence() ([]interface{}, error) {
+	f.pos++
+
+	seq := make([]interface{}, 0)
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, errors.New("unterminated flow sequence")
+		}
+		if f.s[f.pos] == ']' {
+			f.pos++
+			return seq, nil
+		}
+
+		item, err := f.node()
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, item)
+
+		if err = f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (map[string]interface{}, error) {
+	f.pos++
+
+	m := make(map[string]interface{})
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.s) {
+			return nil, errors.New("unterminated flow mapping")
+		}
+		if f.s[f.pos] == '}' {
+			f.pos++
+			return m, nil
+		}
+
+		key, _, err := f.scalar()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("duplicate key '%s'", key)
+		}
+
+		// a key without a value has a null value
+		f.skipSpace()
+		m[key] = nil
+		if f.pos < len(f.s) && f.s[f.pos] == ':' {
+			f.pos++
+			f.skipSpace()
+			if f.pos < len(f.s) && f.s[f.pos] != ',' && f.s[f.pos] != '}' {
+				if m[key], err = f.node(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if err = f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// scalar parses a quoted scalar, or a plain scalar which ends before an indicator of the flow collection
+func (f *yamlFlow) scalar() (string, bool, error) {
+	f.skipSpace()
+	if f.pos < len(f.s) && (f.s[f.pos] == '"' || f.s[f.pos] == '\'') {
+		s, n, err := scanYAMLQuoted(f.s[f.pos:])
+		f.pos += n
+		return s, true, err
+	}
+
+	start := f.pos
+	for ; f.pos < len(f.s); f.pos++ {
+		c := f.s[f.pos]
+		if strings.IndexByte(",[]{}", c) >= 0 {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.s) || strings.IndexByte(" ,[]{}", f.s[f.pos+1]) >= 0) {
+			break
+		}
+	}
+	return strings.TrimSpace(f.s[start:f.pos]), false, nil
+}
+
+// parseYAMLScalar parses a plain or quoted scalar, without the comment which can follow it
+func parseYAMLScalar(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "'") {
+		return stripYAMLComment(s), nil
+	}
+
+	value, n, err := scanYAMLQuoted(s)
+	if err != nil {
+		return "", err
+	}
+	if rest := strings.TrimSpace(s[n:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", errors.New("unexpected content after quoted scalar")
+	}
+	return value, nil
+}
+
+// yamlEscapes are the escape sequences of double-quoted scalars which stand for a single character
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+	'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`, 'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// scanYAMLQuoted parses the quoted scalar at the start of s.
+// Returns its value and the length of the scalar, including its quotes.
+func scanYAMLQuoted(s string) (string, int, error) {
+	var b strings.Builder
+
+	if s[0] == '\'' {
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		return "", len(s), errors.New("unterminated single-quoted scalar")
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", len(s), errors.New("unterminated double-quoted scalar")
+			}
+			i++
+			if e, ok := yamlEscapes[s[i]]; ok {
+				b.WriteString(e)
+				continue
+			}
+
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+			if digits == 0 || i+digits >= len(s) {
+				return "", len(s), fmt.Errorf("invalid escape sequence '\\%c'", s[i])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+			if err != nil {
+				return "", len(s), fmt.Errorf("invalid escape sequence '\\%s'", s[i:i+1+digits])
+			}
+			b.WriteRune(rune(r))
+			i += digits
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", len(s), errors.New("unterminated double-quoted scalar")
+}
@@ -0,0 +1,134 @@
+package nexusrm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected interface{}
+	}{
+		{
+			name:     "scalars",
+			doc:      "a: 1\nb: \"two # not a comment\"\nc: 'it''s' # comment\nd: ~\ne:\n",
+			expected: map[string]interface{}{"a": "1", "b": "two # not a comment", "c": "it's", "d": nil, "e": nil},
+		},
+		{
+			name: "nested",
+			doc: `# comment
+top:
+  list:
+  - one
+  - two
+  items:
+    - name: x
+      tags: [a, "b, c"]
+    - name: y
+  empty: {}
+`,
+			expected: map[string]interface{}{"top": map[string]interface{}{
+				"list": []interface{}{"one", "two"},
+				"items": []interface{}{
+					map[string]interface{}{"name": "x", "tags": []interface{}{"a", "b, c"}},
+					map[string]interface{}{"name": "y"},
+				},
+				"empty": map[string]interface{}{},
+			}},
+		},
+		{
+			name:     "literal",
+			doc:      "text: |\n  line one\n    indented\n\n  last\n\n# comment\nnext: v\n",
+			expected: map[string]interface{}{"text": "line one\n  indented\n\nlast\n", "next": "v"},
+		},
+		{
+			name:     "folded",
+			doc:      "text: >-\n  folded\n  line\n\n  paragraph\nkeep: |+\n  kept\n\n",
+			expected: map[string]interface{}{"text": "folded line\nparagraph", "keep": "kept\n\n"},
+		},
+		{
+			name: "multi-line plain",
+			doc: `description: A chart which has a long description
+  wrapped onto the next lines
+
+  and a second paragraph # comment
+list:
+- an item
+  wrapped
+next: v
+`,
+			expected: map[string]interface{}{
+				"description": "A chart which has a long description wrapped onto the next lines\nand a second paragraph",
+				"list":        []interface{}{"an item wrapped"},
+				"next":        "v",
+			},
+		},
+		{
+			name: "multi-line quoted",
+			doc: `double: "A chart which is escaped \
+  \"across\" lines
+  which are folded\t\u00e9"
+single: 'it''s
+  folded
+
+  twice'
+closed: "value" # comment
+`,
+			expected: map[string]interface{}{
+				"double": "A chart which is escaped \"across\" lines which are folded\t\u00e9",
+				"single": "it's folded\ntwice",
+				"closed": "value",
+			},
+		},
+		{
+			name:     "unicode escapes",
+			doc:      "escaped: \"a\\_b\\Lc\\Pd\\Ne\\x41\\u00e9\\U0001F600\"\n",
+			expected: map[string]interface{}{"escaped": "a\u00a0b\u2028c\u2029d\u0085eA\u00e9\U0001F600"},
+		},
+		{
+			name: "flow",
+			doc: `annotations: {category: Database, "quoted: key": 'a, b', empty: , nested: {list: [1, {k: v}]}}
+multi: [a,
+  "b # not a comment", # comment
+  it's]
+urls: ["http://example.com/a:b"]
+`,
+			expected: map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"category":    "Database",
+					"quoted: key": "a, b",
+					"empty":       nil,
+					"nested":      map[string]interface{}{"list": []interface{}{"1", map[string]interface{}{"k": "v"}}},
+				},
+				"multi": []interface{}{"a", "b # not a comment", "it's"},
+				"urls":  []interface{}{"http://example.com/a:b"},
+			},
+		},
+		{
+			name:     "sequence",
+			doc:      "- a\n- - b\n  - c\n-\n",
+			expected: []interface{}{"a", []interface{}{"b", "c"}, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := parseYAML(test.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("Expected %#v but got %#v", test.expected, actual)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"a: 1\n  b: 2\n", "a: 1\na: 2\n", "a: [b\n", "a: {b: 1, b: 2}\n", "a: \"b\n", "a: \"\\q\"\n", "\ta: 1\n", "just text\n"} {
+		if _, err := parseYAML(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+/*
+Package versions compares, sorts and matches versions following the rules of each package format,
+so that questions such as "what is the latest version of X" can be answered.
+
+	v := []string{"1.0", "1.0-SNAPSHOT", "1.0-rc1", "1.0.1"}
+	versions.Sort(versions.Maven, v) // 1.0-rc1, 1.0-SNAPSHOT, 1.0, 1.0.1
+
+	r, err := versions.ParseRange(versions.SemVer, "^1.2.0")
+	if err != nil {
+	    panic(err)
+	}
+
+	latest, ok := r.Latest([]string{"1.2.0", "1.9.3", "2.0.0", "1.10.0-beta.1"}) // 1.9.3, true
+
+The scheme of a repository format, as reported by Nexus Repository Manager, is returned by ForFormat.
+*/
+package versions
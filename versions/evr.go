@@ -0,0 +1,200 @@
+package versions
+
+import "strings"
+
+// splitEVR splits an epoch:version-release string. The release starts after the last hyphen.
+func splitEVR(v string) (epoch, version, release string) {
+	v = strings.TrimSpace(v)
+
+	epoch = "0"
+	if i := strings.Index(v, ":"); i >= 0 && allDigits(v[:i]) {
+		epoch, v = v[:i], v[i+1:]
+	}
+
+	version = v
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		version, release = v[:i], v[i+1:]
+	}
+
+	return
+}
+
+// debianOrder orders the characters of the non-digit parts of a Debian version:
+// a tilde sorts before anything, even the end of the part, and letters sort before other characters
+func debianOrder(s string, i int) int {
+	switch {
+	case i >= len(s):
+		return 0
+	case s[i] == '~':
+		return -1
+	case isLetter(s[i]):
+		return int(s[i])
+	default:
+		return int(s[i]) + 256
+	}
+}
+
+// compareDebianPart compares the upstream version or revision of two versions as dpkg does
+func compareDebianPart(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			x, y := debianOrder(a, i), debianOrder(b, j)
+			if i < len(a) && isDigit(a[i]) {
+				x = 0
+			}
+			if j < len(b) && isDigit(b[j]) {
+				y = 0
+			}
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+			i++
+			j++
+		}
+
+		si := i
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		sj := j
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+
+		if c := compareInts(a[si:i], b[sj:j]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareDebian(a, b string) int {
+	ea, va, ra := splitEVR(a)
+	eb, vb, rb := splitEVR(b)
+
+	if c := compareInts(ea, eb); c != 0 {
+		return c
+	}
+	if c := compareDebianPart(va, vb); c != 0 {
+		return c
+	}
+	return compareDebianPart(ra, rb)
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isLetter(c)
+}
+
+// rpmvercmp compares the version or release of two packages as rpm does
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && !isAlnum(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		// a tilde sorts before everything, even the end of the version
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// a caret sorts after the end of the version, but before anything else
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		var i, j int
+		numeric := isDigit(a[0])
+		if numeric {
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+		} else {
+			for i < len(a) && isLetter(a[i]) {
+				i++
+			}
+			for j < len(b) && isLetter(b[j]) {
+				j++
+			}
+		}
+
+		// numbers are newer than letters
+		if j == 0 {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		if numeric {
+			c = compareInts(a[:i], b[:j])
+		} else {
+			c = compareStrings(a[:i], b[:j])
+		}
+		if c != 0 {
+			return c
+		}
+
+		a, b = a[i:], b[j:]
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareRPM(a, b string) int {
+	ea, va, ra := splitEVR(a)
+	eb, vb, rb := splitEVR(b)
+
+	if c := compareInts(ea, eb); c != 0 {
+		return c
+	}
+	if c := rpmvercmp(va, vb); c != 0 {
+		return c
+	}
+	return rpmvercmp(ra, rb)
+}
@@ -0,0 +1,277 @@
+package versions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The qualifiers known to Maven in their order. Unknown qualifiers sort after all of them, alphabetically.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// mavenItem is an element of a parsed maven version. Comparing against nil compares against an absent item.
+type mavenItem interface {
+	compare(other mavenItem) int
+	isNull() bool
+}
+
+type mavenInt string
+
+func (i mavenInt) isNull() bool {
+	return strings.TrimLeft(string(i), "0") == ""
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		return compareInts(string(i), string(o))
+	default:
+		// 1.1 > 1-sp > 1-1
+		return 1
+	}
+}
+
+type mavenString string
+
+func newMavenString(value string, followedByDigit bool) mavenString {
+	if followedByDigit && len(value) == 1 {
+		switch value[0] {
+		case 'a':
+			value = "alpha"
+		case 'b':
+			value = "beta"
+		case 'm':
+			value = "milestone"
+		}
+	}
+	if alias, ok := mavenAliases[value]; ok {
+		value = alias
+	}
+	return mavenString(value)
+}
+
+// comparable returns a string which orders the qualifier against the others
+func (s mavenString) comparable() string {
+	for i, q := range mavenQualifiers {
+		if q == string(s) {
+			return string(rune('0' + i))
+		}
+	}
+	return string(rune('0'+len(mavenQualifiers))) + "-" + string(s)
+}
+
+func (s mavenString) isNull() bool {
+	return s.comparable() == mavenString("").comparable()
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		// 1-rc < 1, 1-sp > 1
+		return compareStrings(s.comparable(), mavenString("").comparable())
+	case mavenString:
+		return compareStrings(s.comparable(), o.comparable())
+	default:
+		return -1
+	}
+}
+
+type mavenList []mavenItem
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+func (l mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case mavenList:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var left, right mavenItem
+			if i < len(l) {
+				left = l[i]
+			}
+			if i < len(o) {
+				right = o[i]
+			}
+
+			var result int
+			if left == nil {
+				if right != nil {
+					result = -right.compare(nil)
+				}
+			} else {
+				result = left.compare(right)
+			}
+
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+// normalize removes the null items at the end of the list, which do not change its order
+func (l *mavenList) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, ok := item.(mavenList); !ok {
+			break
+		}
+	}
+}
+
+func parseMavenItem(isDigit bool, value string) mavenItem {
+	if isDigit {
+		return mavenInt(value)
+	}
+	return newMavenString(value, false)
+}
+
+// parseMaven splits a version into items as Maven's ComparableVersion does: numbers and qualifiers separated
+// by dots, and sublists started by hyphens or by transitions between digits and letters
+func parseMaven(version string) mavenList {
+	version = strings.ToLower(version)
+
+	// lists are built bottom up, so they are tracked as pointers while parsing
+	type node struct {
+		items  mavenList
+		parent *node
+		index  int // position of this list within its parent
+	}
+
+	root := &node{}
+	list := root
+	stack := []*node{root}
+
+	addList := func() {
+		child := &node{parent: list, index: len(list.items)}
+		list.items = append(list.items, mavenList(nil))
+		list = child
+		stack = append(stack, child)
+	}
+
+	isDigitRun := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				list.items = append(list.items, mavenInt("0"))
+			} else {
+				list.items = append(list.items, parseMavenItem(isDigitRun, version[start:i]))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				list.items = append(list.items, mavenInt("0"))
+			} else {
+				list.items = append(list.items, parseMavenItem(isDigitRun, version[start:i]))
+			}
+			start = i + 1
+			addList()
+		case isDigit(c):
+			if !isDigitRun && i > start {
+				list.items = append(list.items, newMavenString(version[start:i], true))
+				start = i
+				addList()
+			}
+			isDigitRun = true
+		default:
+			if isDigitRun && i > start {
+				list.items = append(list.items, parseMavenItem(true, version[start:i]))
+				start = i
+				addList()
+			}
+			isDigitRun = false
+		}
+	}
+
+	if len(version) > start {
+		list.items = append(list.items, parseMavenItem(isDigitRun, version[start:]))
+	}
+
+	// normalize and attach the lists, innermost first
+	for i := len(stack) - 1; i >= 0; i-- {
+		n := stack[i]
+		n.items.normalize()
+		if n.parent != nil {
+			n.parent.items[n.index] = n.items
+		}
+	}
+
+	return root.items
+}
+
+var mavenTimestampedSnapshot = regexp.MustCompile(`^(.*)-(\d{8}\.\d{6})-(\d+)$`)
+
+// mavenSnapshot returns the base version of a timestamped SNAPSHOT, such as 1.0-SNAPSHOT for 1.0-20200601.120000-1,
+// and its timestamp and build number
+func mavenSnapshot(v string) (base, timestamp, build string) {
+	if m := mavenTimestampedSnapshot.FindStringSubmatch(v); m != nil {
+		return m[1] + "-SNAPSHOT", m[2], m[3]
+	}
+	return v, "", ""
+}
+
+// compareMaven compares versions as Maven does. A timestamped SNAPSHOT is compared as its base version,
+// then by timestamp and build number.
+func compareMaven(a, b string) int {
+	baseA, timestampA, buildA := mavenSnapshot(a)
+	baseB, timestampB, buildB := mavenSnapshot(b)
+
+	if c := parseMaven(baseA).compare(parseMaven(baseB)); c != 0 {
+		return c
+	}
+
+	if c := compareStrings(timestampA, timestampB); c != 0 {
+		return c
+	}
+	return compareInts(buildA, buildB)
+}
+
+// mavenPrerelease returns true if the version has a qualifier which sorts before a release
+func mavenPrerelease(v string) bool {
+	var find func(l mavenList) bool
+	find = func(l mavenList) bool {
+		for _, item := range l {
+			switch i := item.(type) {
+			case mavenString:
+				if i.compare(nil) < 0 {
+					return true
+				}
+			case mavenList:
+				if find(i) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	base, _, _ := mavenSnapshot(v)
+	return find(parseMaven(base))
+}
@@ -0,0 +1,204 @@
+package versions
+
+import (
+	"regexp"
+	"strings"
+)
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d+)?)?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d+)?)?` +
+	`(?:[-_.]?(dev)[-_.]?(\d+)?)?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+type pep440Pre struct {
+	label  string // a, b or rc
+	number string
+}
+
+type pep440 struct {
+	epoch   string
+	release []string
+	pre     *pep440Pre
+	post    *string
+	dev     *string
+	local   []string
+}
+
+func optionalNumber(n string) string {
+	if n == "" {
+		return "0"
+	}
+	return n
+}
+
+// parsePEP440 parses a version, accepting the alternative spellings which PEP 440 normalizes
+func parsePEP440(v string) (pep440, bool) {
+	var p pep440
+
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return p, false
+	}
+
+	p.epoch = optionalNumber(m[1])
+	p.release = strings.Split(m[2], ".")
+
+	if m[3] != "" {
+		label := m[3]
+		switch label {
+		case "alpha":
+			label = "a"
+		case "beta":
+			label = "b"
+		case "c", "pre", "preview":
+			label = "rc"
+		}
+		p.pre = &pep440Pre{label, optionalNumber(m[4])}
+	}
+
+	switch {
+	case m[5] != "":
+		p.post = &m[5]
+	case m[6] != "":
+		post := optionalNumber(m[7])
+		p.post = &post
+	}
+
+	if m[8] != "" {
+		dev := optionalNumber(m[9])
+		p.dev = &dev
+	}
+
+	if m[10] != "" {
+		p.local = strings.FieldsFunc(m[10], func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	}
+
+	return p, true
+}
+
+func comparePEP440Release(a, b []string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := "0", "0"
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// preRank orders the prerelease part: a development release of a final version sorts before its prereleases,
+// and a final version sorts after them
+func (p pep440) preRank() (rank int, label, number string) {
+	switch {
+	case p.pre != nil:
+		return 1, p.pre.label, p.pre.number
+	case p.post == nil && p.dev != nil:
+		return 0, "", ""
+	default:
+		return 2, "", ""
+	}
+}
+
+func compareOptional(a, b *string, absentFirst bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		if absentFirst {
+			return -1
+		}
+		return 1
+	case b == nil:
+		if absentFirst {
+			return 1
+		}
+		return -1
+	default:
+		return compareInts(*a, *b)
+	}
+}
+
+func comparePEP440Local(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		xNum, yNum := allDigits(a[i]), allDigits(b[i])
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareInts(a[i], b[i])
+		case xNum:
+			c = 1
+		case yNum:
+			c = -1
+		default:
+			c = compareStrings(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (p pep440) compare(o pep440) int {
+	if c := compareInts(p.epoch, o.epoch); c != 0 {
+		return c
+	}
+
+	if c := comparePEP440Release(p.release, o.release); c != 0 {
+		return c
+	}
+
+	rankA, labelA, numberA := p.preRank()
+	rankB, labelB, numberB := o.preRank()
+	switch {
+	case rankA != rankB:
+		return compareInts(string(rune('0'+rankA)), string(rune('0'+rankB)))
+	case labelA != labelB:
+		// a < b < rc
+		return compareStrings(labelA, labelB)
+	}
+	if c := compareInts(numberA, numberB); c != 0 {
+		return c
+	}
+
+	if c := compareOptional(p.post, o.post, true); c != 0 {
+		return c
+	}
+
+	if c := compareOptional(p.dev, o.dev, false); c != 0 {
+		return c
+	}
+
+	return comparePEP440Local(p.local, o.local)
+}
+
+func comparePEP440(a, b string) int {
+	pa, okA := parsePEP440(a)
+	pb, okB := parsePEP440(b)
+
+	switch {
+	case okA && okB:
+		return pa.compare(pb)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	default:
+		return compareMaven(a, b)
+	}
+}
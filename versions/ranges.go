@@ -0,0 +1,507 @@
+package versions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// constraint compares a version against a bound
+type constraint struct {
+	op      string // one of =, !=, <, <=, >, >=
+	version string
+}
+
+func (c constraint) matches(s Scheme, v string) bool {
+	cmp := s.Compare(v, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// alternative is a set of constraints which a version must all satisfy
+type alternative struct {
+	constraints []constraint
+	// exclusions are sets of constraints which a version must not satisfy,
+	// such as the versions of a wildcard excluded by a PEP 440 != specifier
+	exclusions [][]constraint
+}
+
+// Range is a set of versions, expressed in the range syntax of a scheme. A version is in the range
+// if it is in any of its alternatives.
+type Range struct {
+	scheme       Scheme
+	expr         string
+	alternatives []alternative
+}
+
+func (r Range) String() string {
+	return r.expr
+}
+
+// Contains returns true if the version is in the range. As in their package managers, prerelease SemVer,
+// NuGet, PEP 440, RubyGems and Maven versions are only in a range which explicitly mentions a prerelease;
+// for SemVer, it must be a prerelease of the same major, minor and patch numbers.
+func (r Range) Contains(v string) bool {
+	for _, a := range r.alternatives {
+		if r.satisfies(a.constraints, v) && r.allowsPrerelease(a.constraints, v) && !r.excludes(a.exclusions, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Range) excludes(exclusions [][]constraint, v string) bool {
+	for _, excluded := range exclusions {
+		if r.satisfies(excluded, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Range) satisfies(constraints []constraint, v string) bool {
+	for _, c := range constraints {
+		if !c.matches(r.scheme, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Range) allowsPrerelease(constraints []constraint, v string) bool {
+	switch r.scheme {
+	case Debian, RPM:
+		return true
+	}
+
+	if !r.scheme.IsPrerelease(v) {
+		return true
+	}
+
+	for _, c := range constraints {
+		if !r.scheme.IsPrerelease(c.version) {
+			continue
+		}
+
+		if r.scheme != SemVer {
+			return true
+		}
+
+		pv, _ := parseSemVer(v, false)
+		pc, _ := parseSemVer(c.version, false)
+		if strings.Join(pv.numbers, ".") == strings.Join(pc.numbers, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the highest of the versions which are in the range
+func (r Range) Latest(versions []string) (string, bool) {
+	matching := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if r.Contains(v) {
+			matching = append(matching, v)
+		}
+	}
+	return Latest(r.scheme, matching)
+}
+
+// ParseRange parses a range in the syntax of the scheme:
+//
+//	Maven:    [1.0,2.0), (,1.0],[1.2,) or 1.0 for exactly that version
+//	NuGet:    [1.0,2.0) or 1.0 for that version or higher
+//	SemVer:   ^1.2.3, ~1.2, 1.x, 1.2.3 - 2.3.4, >=1.0.0 <2.0.0 || 3.x
+//	PEP440:   >=1.0,<2.0, ~=1.4.5, ==1.*, !=1.3.*
+//	RubyGems: ~> 1.2, >= 1.0, < 2
+//	Debian:   >= 1.0-1, << 2.0 (also RPM and Generic)
+//
+// Comparison operators (=, !=, <, <=, >, >=) can be combined with commas or spaces in every scheme,
+// and alternatives separated by || are accepted by every scheme but Maven and NuGet. An empty range or *
+// contains every version.
+func ParseRange(s Scheme, expr string) (Range, error) {
+	r := Range{scheme: s, expr: expr}
+
+	var err error
+	switch s {
+	case Maven, NuGet:
+		var intervals [][]constraint
+		intervals, err = parseIntervals(s, expr)
+		for _, constraints := range intervals {
+			r.alternatives = append(r.alternatives, alternative{constraints: constraints})
+		}
+	default:
+		for _, part := range strings.Split(expr, "||") {
+			var a alternative
+			switch s {
+			case SemVer:
+				a.constraints, err = parseSemVerRange(part)
+			case PEP440:
+				a.constraints, a.exclusions, err = parsePEP440Specifiers(part)
+			default:
+				a.constraints, err = parseComparators(s, part)
+			}
+			if err != nil {
+				break
+			}
+			r.alternatives = append(r.alternatives, a)
+		}
+	}
+
+	if err != nil {
+		return r, fmt.Errorf("invalid %s range '%s': %v", s, expr, err)
+	}
+	return r, nil
+}
+
+var comparatorPattern = regexp.MustCompile(`^(~>|<<|>>|<=|>=|==|!=|<|>|=)?\s*([0-9A-Za-z]\S*)$`)
+
+// splitComparators splits a list of comparators separated by commas or spaces, keeping operators with their versions
+func splitComparators(expr string) []string {
+	fields := strings.FieldsFunc(expr, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+
+	comparators := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// operators separated from their versions
+		if strings.Trim(f, "~^<>=!") == "" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		comparators = append(comparators, f)
+	}
+	return comparators
+}
+
+// parseComparators parses comparison operators, as well as the pessimistic ~> operator of RubyGems
+func parseComparators(s Scheme, expr string) ([]constraint, error) {
+	constraints := make([]constraint, 0)
+	for _, c := range splitComparators(expr) {
+		if c == "*" {
+			continue
+		}
+
+		m := comparatorPattern.FindStringSubmatch(c)
+		if m == nil {
+			return nil, fmt.Errorf("invalid comparator '%s'", c)
+		}
+
+		op, v := m[1], m[2]
+		switch op {
+		case "", "==":
+			op = "="
+		case "<<":
+			op = "<"
+		case ">>":
+			op = ">"
+		case "~>":
+			constraints = append(constraints, constraint{">=", v}, constraint{"<", bumpRubyGems(v)})
+			continue
+		}
+		constraints = append(constraints, constraint{op, v})
+	}
+	return constraints, nil
+}
+
+// parseIntervals parses the interval notation of Maven and NuGet, such as [1.0,2.0),[3.0,)
+func parseIntervals(s Scheme, expr string) ([][]constraint, error) {
+	expr = strings.Replace(strings.TrimSpace(expr), " ", "", -1)
+	if expr == "" || expr == "*" {
+		return [][]constraint{{}}, nil
+	}
+
+	if expr[0] != '[' && expr[0] != '(' {
+		if strings.ContainsAny(expr, "[](),") {
+			return nil, fmt.Errorf("unexpected characters")
+		}
+		// a plain version is an exact requirement for Maven and a minimum for NuGet
+		if s == NuGet {
+			return [][]constraint{{{">=", expr}}}, nil
+		}
+		return [][]constraint{{{"=", expr}}}, nil
+	}
+
+	alternatives := make([][]constraint, 0)
+	for expr != "" {
+		end := strings.IndexAny(expr, "])")
+		if end < 0 || (expr[0] != '[' && expr[0] != '(') {
+			return nil, fmt.Errorf("unbalanced interval")
+		}
+
+		interval := expr[1:end]
+		lowerInclusive, upperInclusive := expr[0] == '[', expr[end] == ']'
+
+		bounds := strings.Split(interval, ",")
+		constraints := make([]constraint, 0, 2)
+		switch len(bounds) {
+		case 1:
+			if !lowerInclusive || !upperInclusive || bounds[0] == "" {
+				return nil, fmt.Errorf("a single version must be enclosed in []")
+			}
+			constraints = append(constraints, constraint{"=", bounds[0]})
+		case 2:
+			if bounds[0] != "" {
+				op := ">"
+				if lowerInclusive {
+					op = ">="
+				}
+				constraints = append(constraints, constraint{op, bounds[0]})
+			}
+			if bounds[1] != "" {
+				op := "<"
+				if upperInclusive {
+					op = "<="
+				}
+				constraints = append(constraints, constraint{op, bounds[1]})
+			}
+		default:
+			return nil, fmt.Errorf("an interval has at most two bounds")
+		}
+		alternatives = append(alternatives, constraints)
+
+		expr = strings.TrimPrefix(expr[end+1:], ",")
+	}
+
+	return alternatives, nil
+}
+
+// partialSemVer is a version of a SemVer range in which trailing numbers can be missing or wildcards
+type partialSemVer struct {
+	numbers    []string // only the numbers which were given
+	prerelease string
+}
+
+func parsePartialSemVer(v string) (partialSemVer, error) {
+	var p partialSemVer
+
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "=")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		v, p.prerelease = v[:i], v[i+1:]
+	}
+
+	for _, n := range strings.Split(v, ".") {
+		if n == "x" || n == "X" || n == "*" {
+			break
+		}
+		if !allDigits(n) {
+			return p, fmt.Errorf("invalid version '%s'", v)
+		}
+		p.numbers = append(p.numbers, n)
+	}
+
+	if len(p.numbers) > 3 {
+		return p, fmt.Errorf("invalid version '%s'", v)
+	}
+
+	return p, nil
+}
+
+// version returns the lowest full version matching the partial version
+func (p partialSemVer) version() string {
+	numbers := append([]string{}, p.numbers...)
+	for len(numbers) < 3 {
+		numbers = append(numbers, "0")
+	}
+
+	v := strings.Join(numbers, ".")
+	if p.prerelease != "" && len(p.numbers) == 3 {
+		v += "-" + p.prerelease
+	}
+	return v
+}
+
+// bump returns the lowest version above those matching the first n numbers of the partial version
+func (p partialSemVer) bump(n int) string {
+	numbers := append([]string{}, p.numbers[:n]...)
+	numbers[n-1] = incrementInt(numbers[n-1])
+	for len(numbers) < 3 {
+		numbers = append(numbers, "0")
+	}
+	// prereleases of the bound are below it
+	return strings.Join(numbers, ".") + "-0"
+}
+
+// parseSemVerRange parses a set of npm comparators, expanding hyphen, x, tilde and caret ranges into bounds
+func parseSemVerRange(expr string) ([]constraint, error) {
+	fields := splitComparators(expr)
+
+	// hyphen ranges: 1.2.3 - 2.3.4
+	if len(fields) == 3 && fields[1] == "-" {
+		lower, err := parsePartialSemVer(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		upper, err := parsePartialSemVer(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		constraints := []constraint{{">=", lower.version()}}
+		switch {
+		case len(upper.numbers) == 3:
+			constraints = append(constraints, constraint{"<=", upper.version()})
+		case len(upper.numbers) > 0:
+			constraints = append(constraints, constraint{"<", upper.bump(len(upper.numbers))})
+		}
+		return constraints, nil
+	}
+
+	constraints := make([]constraint, 0)
+	for _, f := range fields {
+		op := ""
+		for _, candidate := range []string{"<=", ">=", "<", ">", "=", "^", "~"} {
+			if strings.HasPrefix(f, candidate) {
+				op, f = candidate, strings.TrimSpace(f[len(candidate):])
+				break
+			}
+		}
+
+		p, err := parsePartialSemVer(f)
+		if err != nil {
+			return nil, err
+		}
+		n := len(p.numbers)
+
+		switch op {
+		case "^":
+			// the first non-zero number, or the last given one, may not change
+			fixed := n
+			for i, number := range p.numbers {
+				if strings.TrimLeft(number, "0") != "" {
+					fixed = i + 1
+					break
+				}
+			}
+			if fixed == 0 {
+				fixed = 1
+			}
+			if n == 0 {
+				continue
+			}
+			if fixed > n {
+				fixed = n
+			}
+			constraints = append(constraints, constraint{">=", p.version()}, constraint{"<", p.bump(fixed)})
+		case "~":
+			if n == 0 {
+				continue
+			}
+			fixed := 2
+			if n == 1 {
+				fixed = 1
+			}
+			constraints = append(constraints, constraint{">=", p.version()}, constraint{"<", p.bump(fixed)})
+		case "", "=":
+			switch {
+			case n == 0:
+			case n == 3:
+				constraints = append(constraints, constraint{"=", p.version()})
+			default:
+				constraints = append(constraints, constraint{">=", p.version()}, constraint{"<", p.bump(n)})
+			}
+		case ">", "<=":
+			switch {
+			case n == 0:
+				if op == ">" {
+					// nothing is greater than any version
+					constraints = append(constraints, constraint{"<", "0.0.0-0"})
+				}
+			case n == 3:
+				constraints = append(constraints, constraint{op, p.version()})
+			case op == ">":
+				constraints = append(constraints, constraint{">=", p.bump(n)})
+			default:
+				constraints = append(constraints, constraint{"<", p.bump(n)})
+			}
+		case ">=", "<":
+			if n == 0 {
+				if op == "<" {
+					constraints = append(constraints, constraint{"<", "0.0.0-0"})
+				}
+				continue
+			}
+			constraints = append(constraints, constraint{op, p.version()})
+		}
+	}
+
+	return constraints, nil
+}
+
+var pep440SpecifierPattern = regexp.MustCompile(`^(~=|===|==|!=|<=|>=|<|>)\s*(\S+)$`)
+
+// pep440Prefix returns the bounds of the versions matching a prefix such as 1.4.*
+func pep440Prefix(prefix string) (lower, upper string, err error) {
+	p, ok := parsePEP440(prefix)
+	if !ok || p.pre != nil || p.post != nil || p.dev != nil || p.local != nil {
+		return "", "", fmt.Errorf("invalid prefix '%s.*'", prefix)
+	}
+
+	release := append([]string{}, p.release...)
+	release[len(release)-1] = incrementInt(release[len(release)-1])
+
+	epoch := ""
+	if p.epoch != "0" {
+		epoch = p.epoch + "!"
+	}
+	return epoch + strings.Join(p.release, ".") + ".dev0", epoch + strings.Join(release, ".") + ".dev0", nil
+}
+
+// parsePEP440Specifiers parses a comma-separated list of PEP 440 version specifiers
+func parsePEP440Specifiers(expr string) (constraints []constraint, exclusions [][]constraint, err error) {
+	constraints = make([]constraint, 0)
+	for _, spec := range strings.Split(expr, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || spec == "*" {
+			continue
+		}
+
+		m := pep440SpecifierPattern.FindStringSubmatch(spec)
+		if m == nil {
+			return nil, nil, fmt.Errorf("invalid specifier '%s'", spec)
+		}
+
+		op, v := m[1], m[2]
+		switch {
+		case strings.HasSuffix(v, ".*") && (op == "==" || op == "!="):
+			lower, upper, err := pep440Prefix(strings.TrimSuffix(v, ".*"))
+			if err != nil {
+				return nil, nil, err
+			}
+			if op == "==" {
+				constraints = append(constraints, constraint{">=", lower}, constraint{"<", upper})
+			} else {
+				exclusions = append(exclusions, []constraint{{">=", lower}, {"<", upper}})
+			}
+		case op == "~=":
+			p, ok := parsePEP440(v)
+			if !ok || len(p.release) < 2 {
+				return nil, nil, fmt.Errorf("invalid compatible release '%s'", v)
+			}
+			_, upper, err := pep440Prefix(strings.Join(p.release[:len(p.release)-1], "."))
+			if err != nil {
+				return nil, nil, err
+			}
+			constraints = append(constraints, constraint{">=", v}, constraint{"<", upper})
+		case op == "==" || op == "===":
+			constraints = append(constraints, constraint{"=", v})
+		default:
+			constraints = append(constraints, constraint{op, v})
+		}
+	}
+	return constraints, exclusions, nil
+}
@@ -0,0 +1,91 @@
+package versions
+
+import (
+	"regexp"
+	"strings"
+)
+
+var rubyGemsSegment = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+// rubyGemsSegments splits a version into numbers and letters as Gem::Version does.
+// A hyphen denotes a prerelease, as in 1.0-1 which is 1.0.pre.1.
+func rubyGemsSegments(v string) []string {
+	v = strings.Replace(strings.TrimSpace(v), "-", ".pre.", -1)
+	return rubyGemsSegment.FindAllString(v, -1)
+}
+
+func rubyGemsPrerelease(v string) bool {
+	for _, s := range rubyGemsSegments(v) {
+		if !allDigits(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareRubyGems(a, b string) int {
+	sa, sb := rubyGemsSegments(a), rubyGemsSegments(b)
+
+	for i := 0; i < len(sa) || i < len(sb); i++ {
+		x, y := "0", "0"
+		if i < len(sa) {
+			x = sa[i]
+		}
+		if i < len(sb) {
+			y = sb[i]
+		}
+
+		xNum, yNum := allDigits(x), allDigits(y)
+		switch {
+		case xNum && yNum:
+			if c := compareInts(x, y); c != 0 {
+				return c
+			}
+		case xNum:
+			// letters denote a prerelease, which sorts before any number
+			return 1
+		case yNum:
+			return -1
+		default:
+			if c := compareStrings(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// bumpRubyGems returns the upper bound of a pessimistic ~> requirement:
+// the prerelease part and the last number are dropped and the number before it is incremented
+func bumpRubyGems(v string) string {
+	segments := rubyGemsSegments(v)
+	for i, s := range segments {
+		if !allDigits(s) {
+			segments = segments[:i]
+			break
+		}
+	}
+	if len(segments) > 1 {
+		segments = segments[:len(segments)-1]
+	}
+	if len(segments) == 0 {
+		return v
+	}
+
+	segments[len(segments)-1] = incrementInt(segments[len(segments)-1])
+	return strings.Join(segments, ".")
+}
+
+// incrementInt adds one to a string of digits
+func incrementInt(n string) string {
+	digits := []byte(strings.TrimLeft(n, "0"))
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+	return "1" + string(digits)
+}
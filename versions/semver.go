@@ -0,0 +1,118 @@
+package versions
+
+import "strings"
+
+type semVer struct {
+	numbers    []string // major, minor, patch and, for NuGet, revision
+	prerelease []string
+}
+
+// parseSemVer parses a semantic version. Missing minor and patch numbers are taken as zero,
+// build metadata is ignored and, for NuGet, a fourth number is allowed.
+func parseSemVer(v string, nuget bool) (semVer, bool) {
+	var p semVer
+
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "=")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+
+	if i := strings.Index(v, "-"); i >= 0 {
+		p.prerelease = strings.Split(v[i+1:], ".")
+		for _, id := range p.prerelease {
+			if id == "" {
+				return p, false
+			}
+		}
+		v = v[:i]
+	}
+
+	p.numbers = strings.Split(v, ".")
+	max := 3
+	if nuget {
+		max = 4
+	}
+	if len(p.numbers) > max {
+		return p, false
+	}
+	for _, n := range p.numbers {
+		if !allDigits(n) {
+			return p, false
+		}
+	}
+	for len(p.numbers) < max {
+		p.numbers = append(p.numbers, "0")
+	}
+
+	return p, true
+}
+
+func compareSemVerPrerelease(a, b []string, ignoreCase bool) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		if ignoreCase {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+
+		xNum, yNum := allDigits(x), allDigits(y)
+		var c int
+		switch {
+		case xNum && yNum:
+			c = compareInts(x, y)
+		case xNum:
+			c = -1
+		case yNum:
+			c = 1
+		default:
+			c = compareStrings(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (p semVer) compare(o semVer, ignoreCase bool) int {
+	for i := range p.numbers {
+		if c := compareInts(p.numbers[i], o.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	return compareSemVerPrerelease(p.prerelease, o.prerelease, ignoreCase)
+}
+
+func compareSemVer(a, b string, nuget bool) int {
+	pa, okA := parseSemVer(a, nuget)
+	pb, okB := parseSemVer(b, nuget)
+
+	switch {
+	case okA && okB:
+		return pa.compare(pb, nuget)
+	case okA:
+		// valid versions sort after invalid ones
+		return 1
+	case okB:
+		return -1
+	default:
+		return compareMaven(a, b)
+	}
+}
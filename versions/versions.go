@@ -0,0 +1,180 @@
+package versions
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scheme identifies the versioning rules of a package format
+type Scheme int
+
+// Supported versioning schemes
+const (
+	// Generic compares versions as Maven does, which gives a sensible order for most version strings
+	Generic Scheme = iota
+	// Maven follows the ComparableVersion rules of Maven, including qualifiers such as SNAPSHOT
+	Maven
+	// SemVer follows Semantic Versioning 2.0, as used by npm, Go modules and Helm charts.
+	// A leading v, as used by Go modules, is ignored.
+	SemVer
+	// PEP440 follows the version scheme of Python packages
+	PEP440
+	// RubyGems follows the version rules of Gem::Version
+	RubyGems
+	// NuGet follows NuGet SemVer 2.0, which allows a fourth version number and ignores the case of prerelease labels
+	NuGet
+	// Debian follows the epoch:upstream-revision version rules of dpkg
+	Debian
+	// RPM follows the epoch:version-release version rules of rpm
+	RPM
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case Maven:
+		return "maven"
+	case SemVer:
+		return "semver"
+	case PEP440:
+		return "pep440"
+	case RubyGems:
+		return "rubygems"
+	case NuGet:
+		return "nuget"
+	case Debian:
+		return "debian"
+	case RPM:
+		return "rpm"
+	default:
+		return "generic"
+	}
+}
+
+// ForFormat returns the versioning scheme of a repository format, such as "maven2" or "npm"
+func ForFormat(format string) Scheme {
+	switch strings.ToLower(format) {
+	case "maven2", "maven":
+		return Maven
+	case "npm", "go", "golang", "helm", "bower":
+		return SemVer
+	case "pypi":
+		return PEP440
+	case "rubygems":
+		return RubyGems
+	case "nuget":
+		return NuGet
+	case "apt":
+		return Debian
+	case "yum":
+		return RPM
+	default:
+		return Generic
+	}
+}
+
+// Compare returns -1, 0 or 1 if version a is lower than, equal to or greater than version b.
+// Versions which are not valid for the scheme are ordered as with the Generic scheme.
+func (s Scheme) Compare(a, b string) int {
+	switch s {
+	case SemVer:
+		return compareSemVer(a, b, false)
+	case NuGet:
+		return compareSemVer(a, b, true)
+	case PEP440:
+		return comparePEP440(a, b)
+	case RubyGems:
+		return compareRubyGems(a, b)
+	case Debian:
+		return compareDebian(a, b)
+	case RPM:
+		return compareRPM(a, b)
+	default:
+		return compareMaven(a, b)
+	}
+}
+
+// IsPrerelease returns true if the version is a prerelease, which ranges only match when they ask for one
+func (s Scheme) IsPrerelease(v string) bool {
+	switch s {
+	case SemVer, NuGet:
+		p, ok := parseSemVer(v, s == NuGet)
+		return ok && len(p.prerelease) > 0
+	case PEP440:
+		p, ok := parsePEP440(v)
+		return ok && (p.pre != nil || p.dev != nil)
+	case RubyGems:
+		return rubyGemsPrerelease(v)
+	case Maven, Generic:
+		return mavenPrerelease(v)
+	default:
+		return false
+	}
+}
+
+// Compare returns -1, 0 or 1 if version a is lower than, equal to or greater than version b in the given scheme
+func Compare(s Scheme, a, b string) int {
+	return s.Compare(a, b)
+}
+
+// Sort sorts the versions from lowest to highest in the given scheme
+func Sort(s Scheme, versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return s.Compare(versions[i], versions[j]) < 0
+	})
+}
+
+// Latest returns the highest of the versions in the given scheme
+func Latest(s Scheme, versions []string) (string, bool) {
+	if len(versions) == 0 {
+		return "", false
+	}
+
+	latest := versions[0]
+	for _, v := range versions[1:] {
+		if s.Compare(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest, true
+}
+
+// compareInts compares two strings of digits by numeric value, without limiting their size
+func compareInts(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareStrings(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
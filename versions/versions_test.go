@@ -0,0 +1,201 @@
+package versions
+
+import (
+	"reflect"
+	"testing"
+)
+
+// orderedVersions lists versions of each scheme from lowest to highest
+var orderedVersions = map[Scheme][]string{
+	Maven: {
+		"1-alpha-1", "1.0-alpha2", "1.0-beta-1", "1.0-m1", "1.0-rc1", "1.0-SNAPSHOT",
+		"1", "1.0.0-sp1", "1.0-abc", "1-1", "1.0.1", "1.1", "1.10", "2.0-20200601.120000-1", "2.0",
+	},
+	SemVer: {
+		"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "v1.0.0", "1.2.0", "1.10.0", "v2.0.0-20200601120000-abcdef123456",
+	},
+	PEP440: {
+		"1.0.dev1", "1.0a1", "1.0a2.dev1", "1.0a2", "1.0b1", "1.0rc1", "1.0", "1.0+local.1",
+		"1.0.post1.dev1", "1.0.post1", "1.0.1", "1.1", "1!0.5",
+	},
+	RubyGems: {"1.0.a", "1.0.b1", "1.0.pre.2", "1.0", "1.0.1", "1.1", "1.10"},
+	NuGet: {
+		"1.0.0-Alpha", "1.0.0-beta", "1.0.0-beta.2", "1.0.0", "1.0.0.1", "1.0.1", "2.0.0",
+	},
+	Debian: {
+		"1.0~rc1-1", "1.0-1", "1.0-1ubuntu1", "1.0-2", "1.0a-1", "1.0+dfsg-1", "1.1-1", "1:0.9-1",
+	},
+	RPM: {
+		"1.0~rc1-1", "1.0-1", "1.0-1.el7", "1.0-2", "1.0^git1-1", "1.0a-1", "1.0.1-1", "1.10-1", "1:0.9-1",
+	},
+}
+
+func TestCompare(t *testing.T) {
+	for scheme, ordered := range orderedVersions {
+		for i := range ordered {
+			for j := range ordered {
+				expected := 0
+				switch {
+				case i < j:
+					expected = -1
+				case i > j:
+					expected = 1
+				}
+
+				if actual := scheme.Compare(ordered[i], ordered[j]); actual != expected {
+					t.Errorf("%s: expected %s compared to %s to be %d but got %d", scheme, ordered[i], ordered[j], expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestCompareEquivalent(t *testing.T) {
+	equivalent := []struct {
+		scheme Scheme
+		a, b   string
+	}{
+		{Maven, "1", "1.0.0"},
+		{Maven, "1.0-ga", "1.0"},
+		{Maven, "1.0-final", "1"},
+		{Maven, "1.0-cr1", "1.0-rc-1"},
+		{Maven, "1.0.RELEASE", "1.0"},
+		{SemVer, "v1.2.3", "1.2.3+build.5"},
+		{NuGet, "1.0.0-RC.1", "1.0.0-rc.1"},
+		{NuGet, "1.0", "1.0.0.0"},
+		{PEP440, "1.0", "1.0.0"},
+		{PEP440, "1.0-alpha.1", "1.0a1"},
+		{PEP440, "1.0-1", "1.0.post1"},
+		{RubyGems, "1.0", "1"},
+		{Debian, "0:1.0-1", "1.0-1"},
+		{RPM, "1.0-1", "1.0-1"},
+	}
+
+	for _, e := range equivalent {
+		if c := e.scheme.Compare(e.a, e.b); c != 0 {
+			t.Errorf("%s: expected %s and %s to be equal but got %d", e.scheme, e.a, e.b, c)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	v := []string{"1.0", "1.0-SNAPSHOT", "1.0.1", "1.0-rc1"}
+	Sort(Maven, v)
+
+	expected := []string{"1.0-rc1", "1.0-SNAPSHOT", "1.0", "1.0.1"}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected %v but got %v", expected, v)
+	}
+
+	if latest, ok := Latest(SemVer, []string{"1.9.0", "1.10.0", "1.2.0"}); !ok || latest != "1.10.0" {
+		t.Errorf("Unexpected latest version %s", latest)
+	}
+
+	if _, ok := Latest(SemVer, nil); ok {
+		t.Error("Expected no latest version of nothing")
+	}
+}
+
+func TestForFormat(t *testing.T) {
+	for format, expected := range map[string]Scheme{
+		"maven2": Maven, "npm": SemVer, "go": SemVer, "helm": SemVer, "pypi": PEP440,
+		"rubygems": RubyGems, "nuget": NuGet, "apt": Debian, "yum": RPM, "raw": Generic,
+	} {
+		if actual := ForFormat(format); actual != expected {
+			t.Errorf("Expected scheme %s for %s but got %s", expected, format, actual)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		scheme      Scheme
+		expr        string
+		contains    []string
+		notContains []string
+	}{
+		{Maven, "[1.0,2.0)", []string{"1.0", "1.5", "1.9.9"}, []string{"0.9", "2.0", "1.5-SNAPSHOT"}},
+		{Maven, "(,1.0],[1.2,)", []string{"0.5", "1.0", "1.2", "3.0"}, []string{"1.1"}},
+		{Maven, "[1.5]", []string{"1.5", "1.5.0"}, []string{"1.5.1"}},
+		{Maven, "[1.0-SNAPSHOT,1.0]", []string{"1.0-SNAPSHOT", "1.0"}, []string{"1.0-sp1"}},
+		{Maven, "1.5", []string{"1.5"}, []string{"1.6"}},
+		{NuGet, "1.0", []string{"1.0.0", "3.2.1"}, []string{"0.9.0", "2.0.0-beta"}},
+		{NuGet, "(1.0,2.0]", []string{"1.0.1", "2.0"}, []string{"1.0", "2.0.1"}},
+		{SemVer, "^1.2.3", []string{"1.2.3", "1.9.0", "v1.10.0"}, []string{"1.2.2", "2.0.0", "2.0.0-alpha", "1.5.0-beta"}},
+		{SemVer, "^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{SemVer, "^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{SemVer, "~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0", "1.1.9"}},
+		{SemVer, "~1.2.3-beta.2", []string{"1.2.3-beta.4", "1.2.5"}, []string{"1.2.4-beta.1", "1.2.3-beta.1"}},
+		{SemVer, "1.x || >=3.0.0 <3.1", []string{"1.0.0", "1.99.0", "3.0.5"}, []string{"2.0.0", "3.1.0"}},
+		{SemVer, "1.2.3 - 2.3", []string{"1.2.3", "2.3.9"}, []string{"2.4.0", "1.2.2"}},
+		{SemVer, ">= 1.0.0", []string{"1.0.0", "5.0.0"}, []string{"0.9.0"}},
+		{SemVer, "*", []string{"0.0.1", "10.0.0"}, []string{"1.0.0-rc.1"}},
+		{PEP440, ">=1.0,<2.0,!=1.3.*", []string{"1.0", "1.2.9", "1.4"}, []string{"1.3", "1.3.5", "2.0", "1.5a1"}},
+		{PEP440, "~=1.4.5", []string{"1.4.5", "1.4.9"}, []string{"1.5", "1.4.4"}},
+		{PEP440, "~=2.2", []string{"2.2", "2.9"}, []string{"3.0"}},
+		{PEP440, "==1.*", []string{"1.0", "1.9.9"}, []string{"2.0", "0.9"}},
+		{PEP440, ">=1.0b1", []string{"1.0b2", "1.0"}, []string{"1.0a1"}},
+		{RubyGems, "~> 1.2", []string{"1.2", "1.9"}, []string{"2.0", "1.1"}},
+		{RubyGems, "~> 1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3", "1.3.0.a"}},
+		{RubyGems, ">= 1.0, < 2", []string{"1.0", "1.5.3"}, []string{"2.0", "1.5.a"}},
+		{Debian, ">= 1.0-1, << 2.0", []string{"1.0-1", "1.9-3"}, []string{"2.0", "1.0~rc1-1"}},
+		{RPM, "> 1.0-1", []string{"1.0-2", "1.1-1"}, []string{"1.0-1"}},
+	}
+
+	for _, test := range tests {
+		r, err := ParseRange(test.scheme, test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.scheme, err)
+			continue
+		}
+
+		for _, v := range test.contains {
+			if !r.Contains(v) {
+				t.Errorf("%s: expected %s to contain %s", test.scheme, test.expr, v)
+			}
+		}
+
+		for _, v := range test.notContains {
+			if r.Contains(v) {
+				t.Errorf("%s: expected %s not to contain %s", test.scheme, test.expr, v)
+			}
+		}
+	}
+}
+
+func TestRangeLatest(t *testing.T) {
+	r, err := ParseRange(SemVer, "^1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest, ok := r.Latest([]string{"1.2.0", "1.9.3", "2.0.0", "1.10.0-beta.1"}); !ok || latest != "1.9.3" {
+		t.Errorf("Expected 1.9.3 but got %s", latest)
+	}
+
+	if _, ok := r.Latest([]string{"0.1.0", "2.0.0"}); ok {
+		t.Error("Expected no version in range")
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	invalid := []struct {
+		scheme Scheme
+		expr   string
+	}{
+		{Maven, "[1.0,2.0"},
+		{Maven, "(1.0)"},
+		{Maven, "[1,2,3]"},
+		{SemVer, "^1.a"},
+		{PEP440, "~=1"},
+		{PEP440, "1.0"},
+		{Debian, "=> 1.0"},
+	}
+
+	for _, i := range invalid {
+		if _, err := ParseRange(i.scheme, i.expr); err == nil {
+			t.Errorf("%s: expected %s to be invalid", i.scheme, i.expr)
+		}
+	}
+}